@@ -0,0 +1,170 @@
+// backend/account_handler.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChangePasswordHandler - เปลี่ยนรหัสผ่าน (ต้องยืนยันรหัสเดิม) และ revoke session อื่นทั้งหมด
+func (s *Server) ChangePasswordHandler(c *gin.Context) {
+	var req struct {
+		CurrentPassword string `json:"current_password" binding:"required"`
+		NewPassword     string `json:"new_password" binding:"required,min=6"`
+	}
+	userIDContext, _ := c.Get("userID")
+	userID := userIDContext.(int)
+
+	if c.GetBool("isGuest") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Guest accounts must be upgraded first (POST /api/guest/upgrade)"})
+		return
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// เพิ่ม token_version -> token ที่ออกไปก่อนหน้านี้ใช้ไม่ได้ทันที
+	var user User
+	err := s.Users.WithUserLock(userID, func(tx UserTx, u *User) error {
+		if u.PasswordHash == "" {
+			return reject(http.StatusBadRequest, "This account signs in with SSO and has no password")
+		}
+		if !CheckPasswordHash(req.CurrentPassword, u.PasswordHash) {
+			return reject(http.StatusUnauthorized, "Current password is incorrect")
+		}
+
+		hashedPassword, err := HashPassword(req.NewPassword)
+		if err != nil {
+			return err
+		}
+		u.PasswordHash = hashedPassword
+		u.TokenVersion++
+		user = *u
+		return tx.UpdateUser(u)
+	})
+	if respondStoreError(c, err, "User not found", "Failed to change password") {
+		return
+	}
+
+	// ออก token ใหม่ให้ session ปัจจุบันใช้ต่อได้เลย
+	tokenString, err := GenerateToken(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password changed successfully. Other sessions have been signed out.",
+		"token":   tokenString,
+	})
+}
+
+// ChangeUsernameHandler - เปลี่ยนชื่อผู้ใช้ (ต้องไม่ซ้ำกับคนอื่น)
+func (s *Server) ChangeUsernameHandler(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required,alphanum,min=3,max=20"`
+	}
+	userIDContext, _ := c.Get("userID")
+	userID := userIDContext.(int)
+
+	if c.GetBool("isGuest") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Guest accounts must be upgraded first (POST /api/guest/upgrade)"})
+		return
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := s.Users.WithUserLock(userID, func(tx UserTx, u *User) error {
+		u.Username = req.Username
+		return tx.UpdateUser(u)
+	})
+	if errors.Is(err, ErrDuplicate) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+		return
+	}
+	if respondStoreError(c, err, "User not found", "Failed to change username") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Username changed successfully", "username": req.Username})
+}
+
+// DeleteAccountHandler - ลบบัญชี
+// ไม่ลบแถวจริงเพราะ games/moves อ้างอิง users(id) อยู่ แต่ล้างข้อมูลส่วนตัวออกแทน (anonymize)
+// ประวัติเกมเดิมจะยังอยู่ครบ แต่ชี้ไปที่ผู้ใช้ "deleted_<id>"
+func (s *Server) DeleteAccountHandler(c *gin.Context) {
+	var req struct {
+		Password string `json:"password"` // บัญชี SSO อย่างเดียวไม่ต้องใส่
+	}
+	userIDContext, _ := c.Get("userID")
+	userID := userIDContext.(int)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := s.Users.UserByID(userID)
+	if err != nil || user.DeletedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.PasswordHash != "" && !CheckPasswordHash(req.Password, user.PasswordHash) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password is incorrect"})
+		return
+	}
+
+	if err := s.forfeitActiveGames(userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to forfeit active games"})
+		return
+	}
+
+	err = s.Users.WithUserLock(userID, func(tx UserTx, u *User) error {
+		return tx.Anonymize()
+	})
+	if respondStoreError(c, err, "User not found", "Failed to delete account") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
+}
+
+// forfeitActiveGames - ปิดห้องที่ผู้ใช้ค้างอยู่ก่อนลบบัญชี
+// ห้องที่รอคนอยู่ (เป็น host) -> ทำลายห้องทิ้งเลย, เกมที่เล่นค้างอยู่ -> ถือว่ายอมแพ้ ให้อีกฝั่งชนะ (เหมือน LeaveGameHandler)
+func (s *Server) forfeitActiveGames(userID int) error {
+	// ผู้เล่นค้างอยู่ได้ทีละห้อง แต่เผื่อข้อมูลเก่าที่ค้างหลายห้องไว้ด้วย
+	for attempt := 0; attempt < 10; attempt++ {
+		roomCode, err := s.Games.ActiveRoomCode(userID)
+		if err != nil || roomCode == "" {
+			return err
+		}
+		deleted, err := s.Games.DeleteWaitingGame(roomCode, userID)
+		if err != nil {
+			return err
+		}
+		if deleted {
+			continue
+		}
+		err = s.Games.WithGameLock(roomCode, func(tx GameTx, g *Game) error {
+			if g.Status != "IN_PROGRESS" {
+				return nil
+			}
+			if err := applyGameEvent(tx, g, GameEvent{Type: EventResigned, PlayerID: &userID}); err != nil {
+				return err
+			}
+			return tx.UpdateGame(g)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("user %d still has active games", userID)
+}
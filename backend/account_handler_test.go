@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// TestChangePasswordRevokesOldTokens - เปลี่ยนรหัสผ่านแล้ว token เดิมต้องโดน 401 ส่วน token ใหม่ที่ได้กลับมาใช้ได้
+func TestChangePasswordRevokesOldTokens(t *testing.T) {
+	s, h := newTestServer(t)
+	userID, oldToken := newTestUser(t, s, "alice")
+	// cost ต่ำพอให้ test ไม่ช้า (HashPassword ใช้ cost 14)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Users.WithUserLock(userID, func(tx UserTx, u *User) error {
+		u.PasswordHash = string(hash)
+		return tx.UpdateUser(u)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := doRequest(t, h, http.MethodPut, "/api/account/password", oldToken, gin.H{"current_password": "wrong-pass", "new_password": "secret456"})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong current password: %d, want 401", w.Code)
+	}
+	if w := doRequest(t, h, http.MethodGet, "/api/games/me/active", oldToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("failed change revoked the session: %d", w.Code)
+	}
+
+	w = doRequest(t, h, http.MethodPut, "/api/account/password", oldToken, gin.H{"current_password": "secret123", "new_password": "secret456"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("change password: %d %s", w.Code, w.Body.String())
+	}
+	newToken := decodeBody(t, w)["token"].(string)
+
+	w = doRequest(t, h, http.MethodGet, "/api/games/me/active", oldToken, nil)
+	if w.Code != http.StatusUnauthorized || decodeBody(t, w)["error"] != "Session has been revoked. Please login again." {
+		t.Fatalf("old token: %d %s, want 401", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, h, http.MethodGet, "/api/games/me/active", newToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("new token: %d %s", w.Code, w.Body.String())
+	}
+}
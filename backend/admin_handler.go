@@ -0,0 +1,307 @@
+// backend/admin_handler.go
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// roleRank - ใช้เทียบสิทธิ์ moderator ห้ามจัดการคนที่ role เท่ากันหรือสูงกว่า
+var roleRank = map[string]int{RolePlayer: 0, RoleModerator: 1, RoleAdmin: 2}
+
+// auditWriter - GameTx และ UserTx (บันทึก audit log ใน transaction เดียวกับ action นั้น)
+type auditWriter interface {
+	AppendAudit(e *AuditEntry) error
+}
+
+// writeAuditLog - บันทึก action ของ moderator/admin ลง admin_audit_log ใน transaction เดียวกับ action นั้น
+func writeAuditLog(tx auditWriter, actorID int, action, targetType string, targetID int, details gin.H) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	return tx.AppendAudit(&AuditEntry{ActorID: actorID, Action: action, TargetType: targetType, TargetID: targetID, Details: detailsJSON})
+}
+
+// parsePagination - อ่าน ?limit=&offset= (ค่าเริ่มต้น 50 รายการ สูงสุด 200)
+func parsePagination(c *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// PromoteBootstrapAdmin - ตั้ง admin คนแรกจาก ADMIN_USERNAME (ไม่งั้นจะไม่มีใครเรียก /api/admin ได้เลย)
+func (s *Server) PromoteBootstrapAdmin() {
+	username := os.Getenv("ADMIN_USERNAME")
+	if username == "" {
+		return
+	}
+	user, err := s.Users.UserByUsername(username)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) {
+			log.Println("Failed to promote bootstrap admin:", err)
+		}
+		return
+	}
+
+	promoted := false
+	err = s.Users.WithUserLock(user.ID, func(tx UserTx, u *User) error {
+		if u.Role == RoleAdmin {
+			return nil
+		}
+		u.Role = RoleAdmin
+		u.TokenVersion++
+		promoted = true
+		return tx.UpdateUser(u)
+	})
+	if err != nil {
+		log.Println("Failed to promote bootstrap admin:", err)
+		return
+	}
+	if promoted {
+		log.Printf("Promoted %s to admin\n", username)
+	}
+}
+
+// AdminListUsersHandler - ค้นหาผู้ใช้ (?q= ค้นจาก username)
+func (s *Server) AdminListUsersHandler(c *gin.Context) {
+	limit, offset := parsePagination(c)
+
+	users, err := s.Users.ListUsers(c.Query("q"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"users": users, "limit": limit, "offset": offset})
+}
+
+// AdminListGamesHandler - ค้นหาเกม (?status= ?player_id= ?q= ค้นจาก room code)
+func (s *Server) AdminListGamesHandler(c *gin.Context) {
+	limit, offset := parsePagination(c)
+
+	playerID := 0
+	if v := c.Query("player_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "player_id must be a number"})
+			return
+		}
+		playerID = id
+	}
+
+	games, err := s.Games.SearchGames(c.Query("status"), playerID, c.Query("q"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch games"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"games": games, "limit": limit, "offset": offset})
+}
+
+// AdminEndGameHandler - บังคับจบเกมที่ค้างอยู่ (WAITING/IN_PROGRESS) แบบไม่มีผู้ชนะ
+func (s *Server) AdminEndGameHandler(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	roomCode := c.Param("id")
+	actorID := c.GetInt("userID")
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := s.Games.WithGameLock(roomCode, func(tx GameTx, g *Game) error {
+		if g.Status != "WAITING" && g.Status != "IN_PROGRESS" {
+			return reject(http.StatusBadRequest, "Game has already ended")
+		}
+		previousStatus := g.Status
+
+		if err := applyGameEvent(tx, g, GameEvent{Type: EventEnded}); err != nil {
+			return err
+		}
+		if err := tx.UpdateGame(g); err != nil {
+			return err
+		}
+		return writeAuditLog(tx, actorID, "end_game", "game", g.ID, gin.H{"room_code": roomCode, "previous_status": previousStatus, "reason": req.Reason})
+	})
+	if respondStoreError(c, err, "Game not found", "Failed to end game") {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Game ended by moderator", "status": "ABANDONED"})
+}
+
+// AdminAdjudicateGameHandler - ตัดสินผลเกม (ให้คนใดคนหนึ่งชนะ หรือให้เสมอ) ใช้ได้ทั้งเกมที่ค้างอยู่และเกมที่จบแล้ว
+func (s *Server) AdminAdjudicateGameHandler(c *gin.Context) {
+	var req struct {
+		WinnerID *int   `json:"winner_id"`
+		Draw     bool   `json:"draw"`
+		Reason   string `json:"reason" binding:"required"`
+	}
+	roomCode := c.Param("id")
+	actorID := c.GetInt("userID")
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if (req.WinnerID == nil) == !req.Draw {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Specify either winner_id or draw"})
+		return
+	}
+
+	newStatus := "FINISHED"
+	if req.Draw {
+		newStatus = "DRAW"
+	}
+	err := s.Games.WithGameLock(roomCode, func(tx GameTx, g *Game) error {
+		if g.Player2ID == nil || g.Status == "WAITING" {
+			return reject(http.StatusBadRequest, "Game has not started")
+		}
+		if req.WinnerID != nil && *req.WinnerID != g.Player1ID && *req.WinnerID != *g.Player2ID {
+			return reject(http.StatusBadRequest, "winner_id must be one of the players")
+		}
+		details := gin.H{
+			"room_code":       roomCode,
+			"previous_status": g.Status,
+			"previous_winner": g.WinnerID,
+			"winner_id":       req.WinnerID,
+			"draw":            req.Draw,
+			"reason":          req.Reason,
+		}
+
+		adjudicated := GameEvent{Type: EventAdjudicated, Data: GameEventData{Status: newStatus, WinnerID: req.WinnerID}}
+		if err := applyGameEvent(tx, g, adjudicated); err != nil {
+			return err
+		}
+		if err := tx.UpdateGame(g); err != nil {
+			return err
+		}
+		return writeAuditLog(tx, actorID, "adjudicate_game", "game", g.ID, details)
+	})
+	if respondStoreError(c, err, "Game not found", "Failed to adjudicate game") {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Game adjudicated", "status": newStatus, "winner_id": req.WinnerID})
+}
+
+// AdminVoidGameHandler - ยกเลิกผลเกมที่จบแล้ว (เช่น พบการโกง) สถานะเป็น VOIDED และไม่มีผู้ชนะ
+func (s *Server) AdminVoidGameHandler(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	roomCode := c.Param("id")
+	actorID := c.GetInt("userID")
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := s.Games.WithGameLock(roomCode, func(tx GameTx, g *Game) error {
+		if g.Status != "FINISHED" && g.Status != "DRAW" && g.Status != "ABANDONED" {
+			return reject(http.StatusBadRequest, "Only finished games can be voided")
+		}
+		details := gin.H{"room_code": roomCode, "previous_status": g.Status, "previous_winner": g.WinnerID, "reason": req.Reason}
+
+		if err := applyGameEvent(tx, g, GameEvent{Type: EventVoided}); err != nil {
+			return err
+		}
+		if err := tx.UpdateGame(g); err != nil {
+			return err
+		}
+		return writeAuditLog(tx, actorID, "void_game", "game", g.ID, details)
+	})
+	if respondStoreError(c, err, "Game not found", "Failed to void game") {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Game result voided", "status": "VOIDED"})
+}
+
+// targetUserID - id ของผู้ใช้เป้าหมายจาก path (/users/:id) ตอบ 400 แล้วคืน false ถ้าไม่ใช่ตัวเลข
+func targetUserID(c *gin.Context) (int, bool) {
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User id must be a number"})
+		return 0, false
+	}
+	return targetID, true
+}
+
+// checkCanManage - ใช้ใน WithUserLock ของผู้ใช้เป้าหมาย: ห้ามทำกับตัวเอง / คนที่ role เท่ากันหรือสูงกว่า
+func checkCanManage(c *gin.Context, target *User) error {
+	if target.ID == c.GetInt("userID") {
+		return reject(http.StatusBadRequest, "You cannot perform this action on yourself")
+	}
+	actorRole := c.GetString("role")
+	if actorRole != RoleAdmin && roleRank[target.Role] >= roleRank[actorRole] {
+		return reject(http.StatusForbidden, "You cannot manage a user with an equal or higher role")
+	}
+	return nil
+}
+
+// AdminSetRoleHandler - เปลี่ยน role ของผู้ใช้ (admin เท่านั้น) token เดิมของคนนั้นจะใช้ไม่ได้ ต้อง login ใหม่เพื่อรับ role ใหม่
+func (s *Server) AdminSetRoleHandler(c *gin.Context) {
+	var req struct {
+		Role string `json:"role" binding:"required,oneof=player moderator admin"`
+	}
+	actorID := c.GetInt("userID")
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	targetID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	err := s.Users.WithUserLock(targetID, func(tx UserTx, u *User) error {
+		if err := checkCanManage(c, u); err != nil {
+			return err
+		}
+		if u.IsGuest && req.Role != RolePlayer {
+			return reject(http.StatusBadRequest, "Guest accounts cannot be promoted")
+		}
+		previousRole := u.Role
+
+		u.Role = req.Role
+		u.TokenVersion++
+		if err := tx.UpdateUser(u); err != nil {
+			return err
+		}
+		return writeAuditLog(tx, actorID, "set_role", "user", targetID, gin.H{"previous_role": previousRole, "role": req.Role})
+	})
+	if respondStoreError(c, err, "User not found", "Failed to change role") {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated", "role": req.Role})
+}
+
+// AdminListAuditLogHandler - ดู audit log (?actor_id= ?action=)
+func (s *Server) AdminListAuditLogHandler(c *gin.Context) {
+	limit, offset := parsePagination(c)
+	actorID, _ := strconv.Atoi(c.Query("actor_id"))
+
+	entries, err := s.Users.ListAudit(actorID, c.Query("action"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch audit log"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "limit": limit, "offset": offset})
+}
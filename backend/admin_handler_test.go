@@ -0,0 +1,32 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestSetRoleRevokesOldTokens - เปลี่ยน role แล้ว token เดิม (ที่ยังถือ role เก่า) ต้องโดน 401
+func TestSetRoleRevokesOldTokens(t *testing.T) {
+	s, h := newTestServer(t)
+	_, adminToken := newTestAdmin(t, s, "admin")
+	userID, oldToken := newTestUser(t, s, "alice")
+	rolePath := "/api/admin/users/" + strconv.Itoa(userID) + "/role"
+
+	if w := doRequest(t, h, http.MethodPut, rolePath, oldToken, gin.H{"role": RoleAdmin}); w.Code != http.StatusForbidden {
+		t.Fatalf("player promoting themselves: %d, want 403", w.Code)
+	}
+	if w := doRequest(t, h, http.MethodPut, rolePath, adminToken, gin.H{"role": RoleModerator}); w.Code != http.StatusOK {
+		t.Fatalf("set role: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := doRequest(t, h, http.MethodGet, "/api/games/me/active", oldToken, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("old token: %d, want 401", w.Code)
+	}
+	// login ใหม่ได้ token ที่มี role moderator เข้า admin API ได้
+	if w := doRequest(t, h, http.MethodGet, "/api/admin/users/"+strconv.Itoa(userID)+"/ban-status", testToken(t, s, userID), nil); w.Code != http.StatusOK {
+		t.Fatalf("moderator token: %d %s", w.Code, w.Body.String())
+	}
+}
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"math"
 	"net/http"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -9,21 +12,38 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtKey = []byte("super_secret_tictactoe_key_2026")
+// ดึง Key จาก Environment (ใช้ key เดียวกันทั้งตอนออกและตอนตรวจ token)
+var jwtKey = func() []byte {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		secret = "super_secret_tictactoe_key_2026"
+	}
+	return []byte(secret)
+}()
+
+// token ชั่วคราวระหว่างรอกรอกรหัส 2FA อายุสั้นๆ พอให้เปิดแอป Authenticator ทัน
+const twoFactorPendingTTL = 5 * time.Minute
 
 type Claims struct {
-	UserID int `json:"user_id"`
+	UserID           int    `json:"user_id"`
+	TokenVersion     int    `json:"token_version"`         // ใช้ revoke token เก่าทั้งหมดเมื่อเปลี่ยนรหัสผ่าน/ลบบัญชี
+	TwoFactorPending bool   `json:"2fa_pending,omitempty"` // true = ผ่านรหัสผ่านแล้วแต่ยังไม่ผ่าน 2FA ใช้เรียก API อื่นไม่ได้
+	IsGuest          bool   `json:"guest,omitempty"`       // ผู้เล่นชั่วคราวที่ยังไม่ได้สมัครสมาชิก
+	Role             string `json:"role,omitempty"`        // player, moderator, admin
 	jwt.RegisteredClaims
 }
 
 // jwt token generation
-func GenerateToken(userID int) (string, error) {
+func GenerateToken(user User) (string, error) {
 	// กำหนดวันหมดอายุของ Token
 	expirationTime := time.Now().Add(24 * time.Hour)
 
 	// สร้าง Payload
 	claims := &Claims{
-		UserID: userID,
+		UserID:       user.ID,
+		TokenVersion: user.TokenVersion,
+		IsGuest:      user.IsGuest,
+		Role:         user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
@@ -34,6 +54,33 @@ func GenerateToken(userID int) (string, error) {
 	return token.SignedString(jwtKey)
 }
 
+// GeneratePendingToken - token "รอ 2FA" ใช้แลกเป็น token จริงที่ /api/login/2fa เท่านั้น
+func GeneratePendingToken(user User) (string, error) {
+	claims := &Claims{
+		UserID:           user.ID,
+		TokenVersion:     user.TokenVersion,
+		TwoFactorPending: true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(twoFactorPendingTTL)),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtKey)
+}
+
+// ParseToken - แกะ Token และตรวจสอบลายเซ็น/วันหมดอายุ
+func ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, err
+	}
+	return claims, nil
+}
+
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	return string(bytes), err
@@ -45,7 +92,7 @@ func CheckPasswordHash(password, hash string) bool {
 }
 
 // registerHandler - สมัครสมาชิกใหม่
-func RegisterHandler(c *gin.Context) {
+func (s *Server) RegisterHandler(c *gin.Context) {
 	var req struct {
 		Username string `json:"username" binding:"required,alphanum,min=3,max=20" `
 		Password string `json:"password" binding:"required,min=6"`
@@ -62,10 +109,7 @@ func RegisterHandler(c *gin.Context) {
 	}
 
 	// บันทึก User ลง Database
-	var userID int
-	query := `INSERT INTO users (username, password_hash) VALUES ($1, $2) RETURNING id`
-	err = DB.QueryRow(query, req.Username, hashedPassword).Scan(&userID)
-
+	userID, err := s.Users.CreateUser(req.Username, hashedPassword)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Username already exists"})
 		return
@@ -75,7 +119,7 @@ func RegisterHandler(c *gin.Context) {
 }
 
 // loginHandler - เข้าสู่ระบบ
-func LoginHandler(c *gin.Context) {
+func (s *Server) LoginHandler(c *gin.Context) {
 	var req struct {
 		Username string `json:"username" binding:"required,alphanum,min=3,max=20" `
 		Password string `json:"password" binding:"required,min=6"`
@@ -86,17 +130,84 @@ func LoginHandler(c *gin.Context) {
 		return
 	}
 
-	var userID int
-	var storedHash string
-	query := `SELECT id, password_hash FROM users WHERE username = $1`
-	err := DB.QueryRow(query, req.Username).Scan(&userID, &storedHash)
+	// กันเดารหัสผ่าน: ติดล็อกอยู่ -> ไม่ต้องเช็ครหัสผ่านเลย
+	clientIP := c.ClientIP()
+	wait, err := s.Throttle.Lockout(req.Username, clientIP)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check login attempts"})
+		return
+	}
+	if wait > 0 {
+		s.Throttle.RecordFailure(req.Username, clientIP, "locked_out")
+		respondLockedOut(c, wait)
+		return
+	}
 
-	if err != nil || !CheckPasswordHash(req.Password, storedHash) {
+	user, err := s.Users.UserByUsername(req.Username)
+
+	// บัญชี SSO อย่างเดียวไม่มี password_hash -> login ด้วยรหัสผ่านไม่ได้
+	if err != nil || user.PasswordHash == "" || !CheckPasswordHash(req.Password, user.PasswordHash) {
+		s.Throttle.RecordFailure(req.Username, clientIP, "invalid_credentials")
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 		return
 	}
 
-	tokenString, err := GenerateToken(userID)
+	if s.rejectIfBanned(c, user.ID) {
+		return
+	}
+
+	// ยังไม่ล้างตัวนับถ้ายังต้องผ่าน 2FA (ไม่งั้นรู้รหัสผ่านแล้วจะเดารหัส 2FA ได้ไม่จำกัด)
+	if !user.TOTPEnabled {
+		s.Throttle.Reset(user.Username)
+	}
+
+	payload, err := loginResponse(*user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		return
+	}
+	c.JSON(http.StatusOK, payload)
+}
+
+// respondLockedOut - ตอบ 429 พร้อม Retry-After (วินาที) ให้ client รู้ว่าต้องรอนานเท่าไหร่
+func respondLockedOut(c *gin.Context, wait time.Duration) {
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed login attempts. Please try again later.",
+		"retry_after": seconds,
+	})
+}
+
+// loginResponse - ผลลัพธ์หลังยืนยันตัวตนขั้นแรกสำเร็จ (รหัสผ่านหรือ SSO)
+// ถ้าเปิด 2FA ไว้ จะได้ pending token ไปยืนยันรหัสที่ /api/login/2fa ก่อน ยังไม่ได้ token จริง
+func loginResponse(user User) (gin.H, error) {
+	if user.TOTPEnabled {
+		pendingToken, err := GeneratePendingToken(user)
+		if err != nil {
+			return nil, err
+		}
+		return gin.H{
+			"message":             "Two-factor authentication required",
+			"two_factor_required": true,
+			"pending_token":       pendingToken,
+		}, nil
+	}
+
+	tokenString, err := GenerateToken(user)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"message": "Login successful",
+		"token":   tokenString,
+		"user_id": user.ID,
+	}, nil
+}
+
+// respondWithToken - ออก access token จริงแล้วตอบกลับในรูปแบบเดียวกับ LoginHandler
+func respondWithToken(c *gin.Context, user User) {
+	tokenString, err := GenerateToken(user)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
 		return
@@ -105,6 +216,6 @@ func LoginHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Login successful",
 		"token":   tokenString,
-		"user_id": userID,
+		"user_id": user.ID,
 	})
 }
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bcrypt cost 14 ช้า (~1 วินาทีต่อครั้ง) จึงสมัครผ่าน HTTP แค่ใน test นี้ ที่อื่นใช้ newTestUser
+func TestRegisterAndLoginHandlers(t *testing.T) {
+	_, h := newTestServer(t)
+
+	register := []struct {
+		name   string
+		body   gin.H
+		status int
+	}{
+		{"ok", gin.H{"username": "alice", "password": "secret123"}, http.StatusOK},
+		{"duplicate username", gin.H{"username": "alice", "password": "secret123"}, http.StatusInternalServerError},
+		{"username not alphanumeric", gin.H{"username": "al ice", "password": "secret123"}, http.StatusBadRequest},
+		{"username too short", gin.H{"username": "al", "password": "secret123"}, http.StatusBadRequest},
+		{"password too short", gin.H{"username": "bob", "password": "123"}, http.StatusBadRequest},
+		{"missing password", gin.H{"username": "bob"}, http.StatusBadRequest},
+	}
+	for _, tt := range register {
+		t.Run("register/"+tt.name, func(t *testing.T) {
+			w := doRequest(t, h, http.MethodPost, "/api/register", "", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+
+	login := []struct {
+		name   string
+		body   gin.H
+		status int
+	}{
+		{"ok", gin.H{"username": "alice", "password": "secret123"}, http.StatusOK},
+		{"wrong password", gin.H{"username": "alice", "password": "wrong-pass"}, http.StatusUnauthorized},
+		{"unknown user", gin.H{"username": "nobody", "password": "secret123"}, http.StatusUnauthorized},
+		{"invalid body", gin.H{"username": "alice"}, http.StatusBadRequest},
+	}
+	for _, tt := range login {
+		t.Run("login/"+tt.name, func(t *testing.T) {
+			w := doRequest(t, h, http.MethodPost, "/api/login", "", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+			token, _ := decodeBody(t, w)["token"].(string)
+			if w := doRequest(t, h, http.MethodGet, "/api/games/me/active", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("token from login rejected: %d %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	s, h := newTestServer(t)
+	userID, token := newTestUser(t, s, "alice")
+	pendingToken, err := GeneratePendingToken(User{ID: userID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{"valid token", "Bearer " + token, http.StatusOK},
+		{"missing header", "", http.StatusUnauthorized},
+		{"not a bearer token", "Token " + token, http.StatusUnauthorized},
+		{"garbage token", "Bearer not-a-jwt", http.StatusUnauthorized},
+		{"2fa pending token", "Bearer " + pendingToken, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var headers []string
+			if tt.header != "" {
+				headers = []string{"Authorization", tt.header}
+			}
+			w := doRequest(t, h, http.MethodGet, "/api/games/me/active", "", nil, headers...)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+// backend/board_image.go
+package main
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// ภาพกระดาน (SVG / PNG) วาดจาก boardScene ชุดเดียวกัน สีตามหน้าเกม: X แดง, O น้ำเงิน
+const (
+	imageWidth  = 360
+	imageHeight = 440
+	boardLeft   = 30
+	boardTop    = 70
+	cellSize    = 100
+	gridWidth   = 6
+	markWidth   = 12
+	markInset   = 24
+	// labelChars - ตัวอักษรมากสุดต่อบรรทัด (ตัวอักษร bitmap กว้าง 14px หลังขยาย ไม่เกินความกว้างกระดาน)
+	labelChars = 3 * cellSize / 14
+)
+
+var (
+	colorBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	colorGrid       = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	colorX          = color.RGBA{0xdc, 0x26, 0x26, 0xff} // red-600
+	colorO          = color.RGBA{0x25, 0x63, 0xeb, 0xff} // blue-600
+	colorHighlight  = color.RGBA{0xfe, 0xf0, 0x8a, 0xff} // yellow-200
+	colorText       = color.RGBA{0x11, 0x18, 0x27, 0xff}
+)
+
+// boardScene - สิ่งที่จะวาดลงภาพ
+type boardScene struct {
+	Board   string
+	Winning []int // ช่องที่เรียงกันชนะ (ว่าง = ไม่มี)
+	PlayerX string
+	PlayerO string
+	Caption string
+}
+
+// newBoardScene - ภาพของห้องหลังการเดินครั้งที่ upTo (upTo < 0 = state ปัจจุบันใน games)
+func newBoardScene(g *Game, moves []Move, names map[int]string, upTo int) *boardScene {
+	scene := &boardScene{
+		Board:   g.Board,
+		PlayerX: fitLabel("X: "+playerLabel(names, g.Player1ID), labelChars),
+		PlayerO: "O: waiting...",
+	}
+	if g.Player2ID != nil {
+		scene.PlayerO = fitLabel("O: "+playerLabel(names, *g.Player2ID), labelChars)
+	}
+
+	if upTo >= 0 {
+		board := []byte("---------")
+		for _, m := range moves[:upTo] {
+			mark := byte('X')
+			if m.PlayerID != g.Player1ID {
+				mark = 'O'
+			}
+			board[m.Y*3+m.X] = mark
+		}
+		scene.Board = string(board)
+		scene.Caption = fmt.Sprintf("Move %d of %d", upTo, len(moves))
+		switch winner, _ := CheckWinner(scene.Board); winner {
+		case "X", "O":
+			scene.Caption += " - " + winner + " wins"
+		case "DRAW":
+			scene.Caption += " - Draw"
+		}
+	} else {
+		scene.Caption = gameCaption(g, names)
+	}
+
+	_, scene.Winning = CheckWinner(scene.Board)
+	return scene
+}
+
+// fitLabel - ตัดข้อความที่ยาวเกิน max ตัวอักษร (username เป็น ASCII ล้วน ตัดทีละ byte ได้)
+func fitLabel(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-2] + ".."
+}
+
+func playerLabel(names map[int]string, id int) string {
+	if name, ok := names[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("player %d", id)
+}
+
+// gameCaption - สรุปผล/สถานะของเกมเป็นประโยคสั้นๆ (ชื่อยาวจะถูกตัดให้ประโยคพอดีหนึ่งบรรทัด)
+func gameCaption(g *Game, names map[int]string) string {
+	withName := func(id int, suffix string) string {
+		return fitLabel(playerLabel(names, id), labelChars-len(suffix)) + suffix
+	}
+	switch g.Status {
+	case "WAITING":
+		return "Waiting for opponent"
+	case "IN_PROGRESS":
+		return withName(g.CurrentTurnID, " to move")
+	case "DRAW":
+		return "Draw"
+	case "FINISHED":
+		if g.WinnerID != nil {
+			return withName(*g.WinnerID, " wins")
+		}
+	case "ABANDONED":
+		if g.WinnerID != nil {
+			return withName(*g.WinnerID, " wins by forfeit")
+		}
+		return "Abandoned"
+	case "VOIDED":
+		return "Result voided"
+	}
+	return g.Status
+}
+
+func cellOrigin(index int) (float64, float64) {
+	return float64(boardLeft + (index%3)*cellSize), float64(boardTop + (index/3)*cellSize)
+}
+
+func cellCenter(index int) (float64, float64) {
+	x, y := cellOrigin(index)
+	return x + cellSize/2, y + cellSize/2
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// SVG - ภาพแบบ vector
+func (s *boardScene) SVG() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		imageWidth, imageHeight, imageWidth, imageHeight)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`+"\n", imageWidth, imageHeight, hexColor(colorBackground))
+
+	text := func(x int, anchor string, y, size int, c color.RGBA, s string) {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" text-anchor="%s" font-family="Helvetica, Arial, sans-serif" font-size="%d" font-weight="bold" fill="%s">%s</text>`+"\n",
+			x, y, anchor, size, hexColor(c), html.EscapeString(s))
+	}
+	text(boardLeft, "start", 30, 18, colorX, s.PlayerX)
+	text(boardLeft, "start", 58, 18, colorO, s.PlayerO)
+
+	for _, index := range s.Winning {
+		x, y := cellOrigin(index)
+		fmt.Fprintf(&b, `<rect x="%g" y="%g" width="%d" height="%d" fill="%s"/>`+"\n", x, y, cellSize, cellSize, hexColor(colorHighlight))
+	}
+
+	for i := 1; i < 3; i++ {
+		offset := i * cellSize
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="%d"/>`+"\n",
+			boardLeft+offset, boardTop, boardLeft+offset, boardTop+3*cellSize, hexColor(colorGrid), gridWidth)
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="%d"/>`+"\n",
+			boardLeft, boardTop+offset, boardLeft+3*cellSize, boardTop+offset, hexColor(colorGrid), gridWidth)
+	}
+
+	for index, mark := range s.Board {
+		x, y := cellOrigin(index)
+		switch mark {
+		case 'X':
+			lo, hi := float64(markInset), float64(cellSize-markInset)
+			fmt.Fprintf(&b, `<path d="M%g %gL%g %gM%g %gL%g %g" stroke="%s" stroke-width="%d" stroke-linecap="round"/>`+"\n",
+				x+lo, y+lo, x+hi, y+hi, x+hi, y+lo, x+lo, y+hi, hexColor(colorX), markWidth)
+		case 'O':
+			cx, cy := cellCenter(index)
+			fmt.Fprintf(&b, `<circle cx="%g" cy="%g" r="%d" fill="none" stroke="%s" stroke-width="%d"/>`+"\n",
+				cx, cy, cellSize/2-markInset, hexColor(colorO), markWidth)
+		}
+	}
+
+	if len(s.Winning) > 0 {
+		x1, y1 := cellCenter(s.Winning[0])
+		x2, y2 := cellCenter(s.Winning[len(s.Winning)-1])
+		fmt.Fprintf(&b, `<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="%s" stroke-width="%d" stroke-linecap="round" opacity="0.6"/>`+"\n",
+			x1, y1, x2, y2, hexColor(colorGrid), gridWidth)
+	}
+
+	text(imageWidth/2, "middle", boardTop+3*cellSize+45, 20, colorText, s.Caption)
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// Image - ภาพแบบ raster (ใช้ทำ PNG / GIF)
+func (s *boardScene) Image() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, imageWidth, imageHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(colorBackground), image.Point{}, draw.Src)
+
+	drawText(img, boardLeft, 30, s.PlayerX, colorX, alignLeft)
+	drawText(img, boardLeft, 58, s.PlayerO, colorO, alignLeft)
+
+	for _, index := range s.Winning {
+		x, y := cellOrigin(index)
+		draw.Draw(img, image.Rect(int(x), int(y), int(x)+cellSize, int(y)+cellSize), image.NewUniform(colorHighlight), image.Point{}, draw.Src)
+	}
+
+	for i := 1; i < 3; i++ {
+		offset := float64(i * cellSize)
+		strokeLine(img, boardLeft+offset, boardTop, boardLeft+offset, boardTop+3*cellSize, gridWidth, colorGrid)
+		strokeLine(img, boardLeft, boardTop+offset, boardLeft+3*cellSize, boardTop+offset, gridWidth, colorGrid)
+	}
+
+	for index, mark := range s.Board {
+		x, y := cellOrigin(index)
+		switch mark {
+		case 'X':
+			lo, hi := float64(markInset), float64(cellSize-markInset)
+			strokeLine(img, x+lo, y+lo, x+hi, y+hi, markWidth, colorX)
+			strokeLine(img, x+hi, y+lo, x+lo, y+hi, markWidth, colorX)
+		case 'O':
+			cx, cy := cellCenter(index)
+			strokeCircle(img, cx, cy, cellSize/2-markInset, markWidth, colorO)
+		}
+	}
+
+	if len(s.Winning) > 0 {
+		x1, y1 := cellCenter(s.Winning[0])
+		x2, y2 := cellCenter(s.Winning[len(s.Winning)-1])
+		faded := colorGrid
+		faded.A = 0x99
+		strokeLine(img, x1, y1, x2, y2, gridWidth, faded)
+	}
+
+	drawText(img, imageWidth/2, boardTop+3*cellSize+45, s.Caption, colorText, alignCenter)
+	return img
+}
+
+// ---------- raster helpers ----------
+
+// blend - ผสมสี c ลงพิกเซล (x, y) ด้วยความทึบ coverage (0-1) คูณกับ alpha ของ c
+func blend(img *image.RGBA, x, y int, c color.RGBA, coverage float64) {
+	if !(image.Point{x, y}.In(img.Bounds())) || coverage <= 0 {
+		return
+	}
+	a := math.Min(coverage, 1) * float64(c.A) / 0xff
+	dst := img.RGBAAt(x, y)
+	mix := func(src, dst uint8) uint8 { return uint8(float64(src)*a + float64(dst)*(1-a) + 0.5) }
+	img.SetRGBA(x, y, color.RGBA{mix(c.R, dst.R), mix(c.G, dst.G), mix(c.B, dst.B), 0xff})
+}
+
+// strokeLine - เส้นหนา width หัวมน (anti-aliased จากระยะห่างของพิกเซลถึงเส้น)
+func strokeLine(img *image.RGBA, x1, y1, x2, y2, width float64, c color.RGBA) {
+	half := width / 2
+	minX, maxX := int(math.Min(x1, x2)-half-1), int(math.Max(x1, x2)+half+1)
+	minY, maxY := int(math.Min(y1, y2)-half-1), int(math.Max(y1, y2)+half+1)
+	dx, dy := x2-x1, y2-y1
+	length2 := dx*dx + dy*dy
+
+	for py := minY; py <= maxY; py++ {
+		for px := minX; px <= maxX; px++ {
+			fx, fy := float64(px)+0.5, float64(py)+0.5
+			t := 0.0
+			if length2 > 0 {
+				t = math.Max(0, math.Min(1, ((fx-x1)*dx+(fy-y1)*dy)/length2))
+			}
+			d := math.Hypot(fx-(x1+t*dx), fy-(y1+t*dy))
+			blend(img, px, py, c, half+0.5-d)
+		}
+	}
+}
+
+// strokeCircle - วงกลมกลวงรัศมี r เส้นหนา width
+func strokeCircle(img *image.RGBA, cx, cy, r, width float64, c color.RGBA) {
+	half := width / 2
+	for py := int(cy - r - half - 1); py <= int(cy+r+half+1); py++ {
+		for px := int(cx - r - half - 1); px <= int(cx+r+half+1); px++ {
+			d := math.Abs(math.Hypot(float64(px)+0.5-cx, float64(py)+0.5-cy) - r)
+			blend(img, px, py, c, half+0.5-d)
+		}
+	}
+}
+
+type textAlign int
+
+const (
+	alignLeft textAlign = iota
+	alignCenter
+	alignRight
+)
+
+// drawText - ตัวอักษร bitmap 7x13 ขยาย 2 เท่า (รองรับแค่ ASCII ตัวอื่นจะเป็นกล่องแทน)
+// baseline อยู่ที่ y
+func drawText(img *image.RGBA, x, y int, s string, c color.RGBA, align textAlign) {
+	const scale = 2
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, s).Ceil()
+
+	mask := image.NewAlpha(image.Rect(0, 0, width, face.Height))
+	d := font.Drawer{Dst: mask, Src: image.Opaque, Face: face, Dot: fixed.P(0, face.Ascent)}
+	d.DrawString(s)
+
+	left := x
+	switch align {
+	case alignCenter:
+		left = x - width*scale/2
+	case alignRight:
+		left = x - width*scale
+	}
+	top := y - face.Ascent*scale
+	for my := 0; my < face.Height; my++ {
+		for mx := 0; mx < width; mx++ {
+			coverage := float64(mask.AlphaAt(mx, my).A) / 0xff
+			if coverage == 0 {
+				continue
+			}
+			for sy := 0; sy < scale; sy++ {
+				for sx := 0; sx < scale; sx++ {
+					blend(img, left+mx*scale+sx, top+my*scale+sy, c, coverage)
+				}
+			}
+		}
+	}
+}
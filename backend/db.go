@@ -4,17 +4,79 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
 )
 
-var DB *sql.DB
+// Database ที่รองรับ (DB_DRIVER)
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite" // ไฟล์เดียว ไม่ต้องมี server แยก (SQLITE_PATH, ค่าเริ่มต้น tictactoe.db)
+)
+
+func dbDriver() string {
+	if driver := os.Getenv("DB_DRIVER"); driver != "" {
+		return driver
+	}
+	return DriverPostgres
+}
+
+// ConnectDB - ต่อ Database แล้วอัปเดต schema ให้เป็นเวอร์ชันล่าสุด (ปิดได้ด้วย AUTO_MIGRATE=false)
+func ConnectDB() *sql.DB {
+	db := openDB()
+
+	if !autoMigrateEnabled() {
+		return db
+	}
+	applied, err := MigrateUp(db)
+	if err != nil {
+		log.Fatal("Database migration failed: ", err)
+	}
+	for _, m := range applied {
+		fmt.Printf("Applied migration %04d_%s\n", m.Version, m.Name)
+	}
+	return db
+}
 
-func ConnectDB() {
+// openDB - ต่อ Database อย่างเดียว (ใช้กับ subcommand migrate ที่ต้องคุม migration เอง)
+func openDB() *sql.DB {
+	switch dbDriver() {
+	case DriverPostgres:
+		return openPostgres()
+	case DriverSQLite:
+		return openSQLite()
+	}
+	log.Fatalf("Unknown DB_DRIVER %q (use %q or %q)", dbDriver(), DriverPostgres, DriverSQLite)
+	return nil
+}
+
+func openSQLite() *sql.DB {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = "tictactoe.db"
+	}
+
+	db, err := sql.Open(sqliteDriverName, sqliteDSN(path))
+	if err == nil {
+		err = db.Ping()
+	}
+	if err != nil {
+		log.Fatal("Could not open SQLite database: ", err)
+	}
+	fmt.Println("Opened SQLite database", path)
+	return db
+}
+
+func openPostgres() *sql.DB {
+	var db *sql.DB
 	var err error
 	dsn := os.Getenv("DB_URL")
 
@@ -30,18 +92,43 @@ func ConnectDB() {
 
 	// ลอง Connect (Retry ได้เผื่อ DB ยังไม่ตื่น)
 	for i := 0; i < 5; i++ {
-		DB, err = sql.Open("postgres", dsn)
+		db, err = sql.Open("postgres", dsn)
 		if err == nil {
-			err = DB.Ping() // เช็คว่าต่อติดจริงๆ
+			err = db.Ping() // เช็คว่าต่อติดจริงๆ
 		}
 
 		if err == nil {
 			fmt.Println("Connected to Database successfully!")
-			return
+			return db
 		}
 
 		fmt.Printf("Failed to connect to DB (Attempt %d/5). Retrying in 2s...\n", i+1)
 		time.Sleep(2 * time.Second)
 	}
 	log.Fatal("Could not connect to database after 5 attempts:", err)
+	return nil
+}
+
+// ชื่อ constraint ของ Postgres -> คอลัมน์ที่ SQLite ระบุใน error (SQLite ไม่บอกชื่อ constraint)
+var sqliteUniqueColumns = map[string]string{
+	"unique_oidc_identity": "users.oidc_issuer, users.oidc_subject",
+	"unique_move_per_cell": "moves.game_id, moves.x, moves.y",
+}
+
+// isUniqueViolation - error เกิดจากข้อมูลซ้ำ UNIQUE constraint หรือไม่ (constraint = "" คือชนอันไหนก็ได้)
+func isUniqueViolation(err error, constraint string) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505" && (constraint == "" || pqErr.Constraint == constraint)
+	}
+
+	var liteErr *sqlite.Error
+	if errors.As(err, &liteErr) {
+		code := liteErr.Code()
+		if code != sqlite3.SQLITE_CONSTRAINT_UNIQUE && code != sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+			return false
+		}
+		return constraint == "" || strings.Contains(liteErr.Error(), sqliteUniqueColumns[constraint])
+	}
+	return false
 }
@@ -0,0 +1,298 @@
+// backend/game_events.go
+package main
+
+import (
+	"fmt"
+)
+
+// ประเภทของ game event
+// state ของห้อง (board, turn, status, winner, termination_reason, rematch) ได้จากการนำ event มา ApplyEvent ทีละตัวตามลำดับ
+// handler ใช้ ApplyEvent ตัวเดียวกันนี้เปลี่ยน state จึงสร้าง games ขึ้นใหม่จาก event ได้ตรงเสมอ
+const (
+	EventCreated          = "created"           // data: player1_id, player2_id, current_turn_id, variant, width, height, depth, win_length, board, status, draw_rule
+	EventJoined           = "joined"            // player_id = ผู้เล่นคนที่ 2
+	EventMoved            = "moved"             // data: x, y, z (z เฉพาะกระดาน 3 มิติ)
+	EventSpookyMoved      = "spooky_moved"      // quantum: data: x, y, x2, y2
+	EventCollapsed        = "collapsed"         // quantum: data: x, y = ช่องที่ mark ที่ปิด cycle ยุบลง
+	EventResigned         = "resigned"          // ออกกลางเกม / ลบบัญชี -> อีกฝั่งชนะ
+	EventLeft             = "left"              // ออกจากห้องหลังเกมจบ (ผลเดิมไม่เปลี่ยน)
+	EventRematchRequested = "rematch_requested" // player_id = คนที่กด rematch
+	EventRematchAgreed    = "rematch_agreed"    // data: next_room_code
+	EventEnded            = "ended"             // ผู้ดูแลสั่งจบเกม ไม่มีผู้ชนะ
+	EventAdjudicated      = "adjudicated"       // data: status, winner_id
+	EventVoided           = "voided"            // ผู้ดูแลยกเลิกผล
+)
+
+// สาเหตุที่เกมจบ (games.termination_reason) ApplyEvent เป็นคนกำหนดตาม event ที่ทำให้เกมจบ
+const (
+	ReasonLine         = "line"          // เรียงครบแถว (misère / notakto = คนที่เรียงครบแพ้)
+	ReasonDraw         = "draw"          // กระดานเต็มไม่มีใครชนะ
+	ReasonDeadPosition = "dead_position" // ไม่มีใครเรียงครบได้อีก (draw_rule = dead_position / perfect_play)
+	ReasonForcedDraw   = "forced_draw"   // เล่นดีที่สุดแล้วเสมอแน่นอน (draw_rule = perfect_play)
+	ReasonResignation  = "resignation"   // ออกกลางเกม / ลบบัญชี
+	ReasonAbandonment  = "abandonment"   // ผู้ดูแลสั่งจบเกมที่ค้างอยู่
+	ReasonAdjudication = "adjudication"  // ผู้ดูแลตัดสินผล
+)
+
+// endGame - ตั้ง status / ผู้ชนะ / สาเหตุที่จบ พร้อมกัน
+func endGame(g *Game, status string, winnerID *int, reason string) {
+	g.Status = status
+	g.WinnerID = winnerID
+	g.TerminationReason = &reason
+}
+
+// endIfDecided - จบเกมถ้ากระดานตัดสินผลแล้ว (หลังลงหมาก / collapse)
+// ผู้ชนะไม่จำเป็นต้องเป็นคนที่เพิ่งเดิน (misère / notakto คนที่เรียงครบแพ้) Outcome บอกเป็นฝั่ง X / O
+func endIfDecided(g *Game, variant Variant) {
+	winnerSign, _ := variant.Outcome(g)
+	if winnerSign == "DRAW" {
+		endGame(g, "DRAW", nil, ReasonDraw)
+	} else if winnerSign != "" {
+		winnerID := g.Player1ID
+		if winnerSign == "O" {
+			winnerID = *g.Player2ID
+		}
+		endGame(g, "FINISHED", &winnerID, ReasonLine)
+	} else if reason := EarlyDraw(g.Board, g.DrawRule); reason != "" {
+		// ห้องที่เลือกกติกาเสมอก่อนกระดานเต็ม
+		endGame(g, "DRAW", nil, reason)
+	}
+}
+
+// createdEvent - event แรกของทุกห้อง (store บันทึกให้เองตอน CreateGame)
+func createdEvent(g *Game) GameEvent {
+	return GameEvent{
+		GameID:   g.ID,
+		Type:     EventCreated,
+		PlayerID: &g.Player1ID,
+		Data: GameEventData{
+			Player1ID:     g.Player1ID,
+			Player2ID:     g.Player2ID,
+			CurrentTurnID: g.CurrentTurnID,
+			Variant:       g.Variant,
+			Width:         g.Width,
+			Height:        g.Height,
+			Depth:         g.Depth,
+			WinLength:     g.WinLength,
+			Board:         g.Board,
+			Status:        g.Status,
+			DrawRule:      g.DrawRule,
+		},
+	}
+}
+
+// ApplyEvent - เปลี่ยน state ของ g ตาม event (ไม่แตะ ID / RoomCode / Version / CreatedAt)
+// คืน error ถ้า event ใช้กับ state นี้ไม่ได้ (เช่นลงช่องที่มีหมากแล้ว) ซึ่งไม่ควรเกิดกับ event ที่ผ่าน handler มา
+func ApplyEvent(g *Game, e GameEvent) error {
+	player := 0
+	if e.PlayerID != nil {
+		player = *e.PlayerID
+	}
+
+	switch e.Type {
+	case EventCreated:
+		g.Player1ID = e.Data.Player1ID
+		g.Player2ID = e.Data.Player2ID
+		g.CurrentTurnID = e.Data.CurrentTurnID
+		g.Variant = e.Data.Variant
+		g.Width, g.Height, g.Depth, g.WinLength = e.Data.Width, e.Data.Height, e.Data.Depth, e.Data.WinLength
+		g.DrawRule = e.Data.DrawRule
+		g.Quantum = nil
+		setGameDefaults(g) // event เก่าที่มีก่อน variant / ขนาดกระดาน และ state ว่างของ quantum
+		g.Board = e.Data.Board
+		g.NextBoard = nil
+		g.Status = e.Data.Status
+		g.WinnerID = nil
+		g.TerminationReason = nil
+		g.NextRoomCode = nil
+		g.RematchP1, g.RematchP2 = false, false
+
+	case EventJoined:
+		if g.Status != "WAITING" || g.Player2ID != nil {
+			return fmt.Errorf("join while game is %s", g.Status)
+		}
+		g.Player2ID = &player
+		g.Status = "IN_PROGRESS"
+
+	case EventMoved:
+		if g.Status != "IN_PROGRESS" || g.Player2ID == nil {
+			return fmt.Errorf("move while game is %s", g.Status)
+		}
+		if e.Data.X == nil || e.Data.Y == nil {
+			return fmt.Errorf("move without coordinates")
+		}
+		z := 0
+		if e.Data.Z != nil {
+			z = *e.Data.Z
+		}
+		variant := variantOf(g)
+		if err := variant.CheckMove(g, *e.Data.X, *e.Data.Y, z); err != nil {
+			return fmt.Errorf("move to (%d, %d, %d): %w", *e.Data.X, *e.Data.Y, z, err)
+		}
+
+		nextTurn := *g.Player2ID
+		if player == *g.Player2ID {
+			nextTurn = g.Player1ID
+		}
+		variant.Play(g, *e.Data.X, *e.Data.Y, z, markOf(g, player))
+		g.CurrentTurnID = nextTurn
+		endIfDecided(g, variant)
+
+	case EventSpookyMoved:
+		if g.Status != "IN_PROGRESS" || g.Player2ID == nil {
+			return fmt.Errorf("move while game is %s", g.Status)
+		}
+		if e.Data.X == nil || e.Data.Y == nil || e.Data.X2 == nil || e.Data.Y2 == nil {
+			return fmt.Errorf("spooky move without both cells")
+		}
+		if err := CheckSpookyMove(g, *e.Data.X, *e.Data.Y, *e.Data.X2, *e.Data.Y2); err != nil {
+			return fmt.Errorf("spooky move to (%d, %d) and (%d, %d): %w", *e.Data.X, *e.Data.Y, *e.Data.X2, *e.Data.Y2, err)
+		}
+
+		// ถ้าปิด cycle อีกฝั่งต้อง collapse ก่อนเดินตาของตัวเอง จึงเปลี่ยนตาเหมือนกันทุกกรณี
+		nextTurn := *g.Player2ID
+		if player == *g.Player2ID {
+			nextTurn = g.Player1ID
+		}
+		playSpooky(g, *e.Data.X, *e.Data.Y, *e.Data.X2, *e.Data.Y2, markOf(g, player))
+		g.CurrentTurnID = nextTurn
+
+	case EventCollapsed:
+		if g.Status != "IN_PROGRESS" {
+			return fmt.Errorf("collapse while game is %s", g.Status)
+		}
+		if e.Data.X == nil || e.Data.Y == nil {
+			return fmt.Errorf("collapse without a cell")
+		}
+		if err := CheckCollapse(g, *e.Data.X, *e.Data.Y); err != nil {
+			return fmt.Errorf("collapse to (%d, %d): %w", *e.Data.X, *e.Data.Y, err)
+		}
+		// คนที่เลือกยังถือตาต่อ (เดิน spooky mark ของตัวเองหลัง collapse)
+		collapse(g, *e.Data.X, *e.Data.Y)
+		endIfDecided(g, variantOf(g))
+
+	case EventResigned:
+		if g.Status != "IN_PROGRESS" {
+			return fmt.Errorf("%s while game is %s", e.Type, g.Status)
+		}
+		// อีกฝั่งชนะ (เหมือน LeaveGameHandler เดิม) คนที่ไม่ได้อยู่ในห้องยอมแพ้แทนใครไม่ได้
+		var winnerID int
+		switch {
+		case player == g.Player1ID && g.Player2ID != nil:
+			winnerID = *g.Player2ID
+		case g.Player2ID != nil && player == *g.Player2ID:
+			winnerID = g.Player1ID
+		default:
+			return fmt.Errorf("%s by player %d who is not in the game", e.Type, player)
+		}
+		endGame(g, "ABANDONED", &winnerID, ReasonResignation)
+
+	case EventLeft:
+		// เกมจบไปแล้ว ผลและสาเหตุที่จบยังเป็นของเดิม
+		g.Status = "ABANDONED"
+
+	case EventRematchRequested:
+		if player == g.Player1ID {
+			g.RematchP1 = true
+		} else if g.Player2ID != nil && player == *g.Player2ID {
+			g.RematchP2 = true
+		} else {
+			return fmt.Errorf("rematch requested by a non-player")
+		}
+
+	case EventRematchAgreed:
+		next := e.Data.NextRoomCode
+		g.NextRoomCode = &next
+
+	case EventEnded:
+		endGame(g, "ABANDONED", nil, ReasonAbandonment)
+
+	case EventAdjudicated:
+		endGame(g, e.Data.Status, e.Data.WinnerID, ReasonAdjudication)
+
+	case EventVoided:
+		// ยกเลิกแค่ผล สาเหตุที่จบยังเป็นของเดิม (ดูได้ว่า void เกมที่จบแบบไหน)
+		g.Status = "VOIDED"
+		g.WinnerID = nil
+
+	default:
+		return fmt.Errorf("unknown event type %q", e.Type)
+	}
+	return nil
+}
+
+// ReplayGame - สร้าง state ของห้องใหม่จาก event ทั้งหมด (ต้องเริ่มด้วย created)
+func ReplayGame(events []GameEvent) (*Game, error) {
+	if len(events) == 0 || events[0].Type != EventCreated {
+		return nil, fmt.Errorf("event log does not start with %q", EventCreated)
+	}
+	g := &Game{ID: events[0].GameID}
+	for _, e := range events {
+		if err := ApplyEvent(g, e); err != nil {
+			return nil, fmt.Errorf("event #%d (%s): %w", e.Seq, e.Type, err)
+		}
+	}
+	return g, nil
+}
+
+// applyGameEvent - ใช้ใน WithGameLock: เปลี่ยน g ตาม event แล้วบันทึก event (handler ยังต้อง tx.UpdateGame(g) เอง)
+func applyGameEvent(tx GameTx, g *Game, e GameEvent) error {
+	e.GameID = g.ID
+	if err := ApplyEvent(g, e); err != nil {
+		return err
+	}
+	return tx.AppendEvent(&e)
+}
+
+// copyProjection - คัดลอกฟิลด์ที่ได้จาก event (ไม่แตะ ID / RoomCode / Version / CreatedAt)
+func copyProjection(dst, src *Game) {
+	dst.Player1ID = src.Player1ID
+	dst.Player2ID = src.Player2ID
+	dst.CurrentTurnID = src.CurrentTurnID
+	dst.Board = src.Board
+	dst.NextBoard = src.NextBoard
+	dst.Quantum = src.Quantum
+	dst.Status = src.Status
+	dst.WinnerID = src.WinnerID
+	dst.TerminationReason = src.TerminationReason
+	dst.NextRoomCode = src.NextRoomCode
+	dst.RematchP1 = src.RematchP1
+	dst.RematchP2 = src.RematchP2
+}
+
+// projectionDiff - ฟิลด์ที่ games (actual) ไม่ตรงกับที่ได้จากการ replay event (want)
+func projectionDiff(want, actual *Game) []string {
+	var diffs []string
+	check := func(field string, w, a interface{}) {
+		if w != a {
+			diffs = append(diffs, fmt.Sprintf("%s: events=%v games=%v", field, w, a))
+		}
+	}
+	check("board", want.Board, actual.Board)
+	check("next_board", intOrNil(want.NextBoard), intOrNil(actual.NextBoard))
+	check("quantum", quantumJSON(want.Quantum), quantumJSON(actual.Quantum))
+	check("status", want.Status, actual.Status)
+	check("current_turn_id", want.CurrentTurnID, actual.CurrentTurnID)
+	check("player1_id", want.Player1ID, actual.Player1ID)
+	check("player2_id", intOrNil(want.Player2ID), intOrNil(actual.Player2ID))
+	check("winner_id", intOrNil(want.WinnerID), intOrNil(actual.WinnerID))
+	check("termination_reason", stringOrNil(want.TerminationReason), stringOrNil(actual.TerminationReason))
+	check("next_room_code", stringOrNil(want.NextRoomCode), stringOrNil(actual.NextRoomCode))
+	check("rematch_p1", want.RematchP1, actual.RematchP1)
+	check("rematch_p2", want.RematchP2, actual.RematchP2)
+	return diffs
+}
+
+func intOrNil(v *int) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+func stringOrNil(v *string) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
@@ -0,0 +1,242 @@
+// backend/game_events_cmd.go
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// RunEventsCommand - go run . events check|rebuild|backfill [room_code]
+//   - check    replay event ของทุกห้องแล้วรายงานห้องที่ games ไม่ตรงกับ event (exit code 1 ถ้ามี)
+//   - rebuild  เขียน board/turn/status/winner/termination_reason ของ games ใหม่จาก event (แก้ projection ที่เพี้ยน)
+//   - backfill สร้าง event ให้ห้องเก่าที่มีก่อน game_events จาก games + moves
+func RunEventsCommand(db *sql.DB, args []string) error {
+	command := "check"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	var rooms []string
+	if len(args) > 1 {
+		rooms = args[1:]
+	} else {
+		var err error
+		if rooms, err = allRoomCodes(db); err != nil {
+			return err
+		}
+	}
+
+	store := &SQLGameStore{db: db}
+	switch command {
+	case "check":
+		return checkEventLogs(store, rooms)
+	case "rebuild":
+		return rebuildProjections(store, rooms)
+	case "backfill":
+		return backfillEvents(store, rooms)
+	}
+	return fmt.Errorf("unknown events command %q (use: check, rebuild, backfill [room_code...])", command)
+}
+
+func allRoomCodes(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`SELECT room_code FROM games ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rooms []string
+	for rows.Next() {
+		var room string
+		if err := rows.Scan(&room); err != nil {
+			return nil, err
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, rows.Err()
+}
+
+func checkEventLogs(store GameStore, rooms []string) error {
+	mismatched, missing := 0, 0
+	for _, room := range rooms {
+		g, err := store.GetGame(room)
+		if err != nil {
+			return fmt.Errorf("%s: %w", room, err)
+		}
+		events, err := store.ListEvents(room)
+		if err != nil {
+			return fmt.Errorf("%s: %w", room, err)
+		}
+		if len(events) == 0 {
+			missing++
+			fmt.Printf("%s  no events (run `events backfill`)\n", room)
+			continue
+		}
+
+		want, err := ReplayGame(events)
+		if err != nil {
+			mismatched++
+			fmt.Printf("%s  replay failed: %v\n", room, err)
+			continue
+		}
+		if diffs := projectionDiff(want, g); len(diffs) > 0 {
+			mismatched++
+			for _, d := range diffs {
+				fmt.Printf("%s  %s\n", room, d)
+			}
+		}
+	}
+
+	fmt.Printf("checked %d games: %d mismatched, %d without events\n", len(rooms), mismatched, missing)
+	if mismatched > 0 {
+		return fmt.Errorf("%d games do not match their event log", mismatched)
+	}
+	return nil
+}
+
+func rebuildProjections(store GameStore, rooms []string) error {
+	rebuilt, failed := 0, 0
+	for _, room := range rooms {
+		err := store.WithGameLock(room, func(tx GameTx, g *Game) error {
+			events, err := store.ListEvents(room)
+			if err != nil || len(events) == 0 {
+				return err
+			}
+			want, err := ReplayGame(events)
+			if err != nil {
+				return err
+			}
+			if len(projectionDiff(want, g)) == 0 {
+				return nil
+			}
+			copyProjection(g, want)
+			rebuilt++
+			return tx.UpdateGame(g)
+		})
+		if err != nil {
+			failed++
+			fmt.Printf("%s  rebuild failed: %v\n", room, err)
+		}
+	}
+
+	fmt.Printf("rebuilt %d of %d games\n", rebuilt, len(rooms))
+	if failed > 0 {
+		return fmt.Errorf("%d games could not be rebuilt", failed)
+	}
+	return nil
+}
+
+func backfillEvents(store GameStore, rooms []string) error {
+	backfilled, failed := 0, 0
+	for _, room := range rooms {
+		err := store.WithGameLock(room, func(tx GameTx, g *Game) error {
+			events, err := store.ListEvents(room)
+			if err != nil || len(events) > 0 {
+				return err
+			}
+			// ตาของ quantum (spooky mark / collapse) ไม่ลง moves จึงสร้างประวัติจาก moves ไม่ได้
+			if g.Variant == VariantQuantum {
+				return errQuantumHistory
+			}
+			moves, err := store.ListMoves(room)
+			if err != nil {
+				return err
+			}
+
+			synthesized := synthesizeEvents(g, moves)
+			want, err := ReplayGame(synthesized)
+			if err != nil {
+				return err
+			}
+			if diffs := projectionDiff(want, g); len(diffs) > 0 {
+				return fmt.Errorf("cannot reconstruct history (%v)", diffs)
+			}
+			for i := range synthesized {
+				synthesized[i].GameID = g.ID
+				if err := tx.AppendEvent(&synthesized[i]); err != nil {
+					return err
+				}
+			}
+			backfilled++
+			return nil
+		})
+		if err != nil {
+			failed++
+			fmt.Printf("%s  backfill failed: %v\n", room, err)
+		}
+	}
+
+	fmt.Printf("backfilled %d of %d games\n", backfilled, len(rooms))
+	if failed > 0 {
+		return fmt.Errorf("%d games could not be backfilled", failed)
+	}
+	return nil
+}
+
+// synthesizeEvents - เดาประวัติของห้องเก่าจาก state สุดท้ายใน games และประวัติการเดินใน moves
+// ผลลัพธ์ต้อง replay แล้วได้ games ตรงกันเท่านั้นถึงจะถูกบันทึก
+func synthesizeEvents(g *Game, moves []Move) []GameEvent {
+	p1 := g.Player1ID
+	events := []GameEvent{{
+		Type:     EventCreated,
+		PlayerID: &p1,
+		Data: GameEventData{Player1ID: p1, CurrentTurnID: p1, Variant: g.Variant, Width: g.Width, Height: g.Height, Depth: g.Depth,
+			WinLength: g.WinLength, Board: emptyBoard(g), Status: "WAITING", DrawRule: g.DrawRule},
+	}}
+	if g.Player2ID != nil {
+		p2 := *g.Player2ID
+		events = append(events, GameEvent{Type: EventJoined, PlayerID: &p2})
+	}
+	for _, m := range moves {
+		playerID, x, y, z := m.PlayerID, m.X, m.Y, m.Z
+		moved := GameEvent{Type: EventMoved, PlayerID: &playerID, Data: GameEventData{X: &x, Y: &y}}
+		if g.Depth > 1 {
+			moved.Data.Z = &z
+		}
+		events = append(events, moved)
+	}
+
+	// state หลังเดินครบแล้ว ใช้ดูว่าเกมจบด้วยอะไร
+	played := &Game{}
+	for _, e := range events {
+		if ApplyEvent(played, e) != nil {
+			return events
+		}
+	}
+
+	var closing []GameEvent
+	switch g.Status {
+	case "FINISHED", "DRAW":
+		if played.Status != g.Status || intOrNil(played.WinnerID) != intOrNil(g.WinnerID) {
+			events = append(events, GameEvent{Type: EventAdjudicated, Data: GameEventData{Status: g.Status, WinnerID: g.WinnerID}})
+		}
+	case "ABANDONED":
+		switch {
+		case played.Status == "IN_PROGRESS" && g.WinnerID != nil && played.Player2ID != nil:
+			// คนที่ไม่ได้ชนะคือคนที่ยอมแพ้
+			loser := played.Player1ID
+			if *g.WinnerID == played.Player1ID {
+				loser = *played.Player2ID
+			}
+			events = append(events, GameEvent{Type: EventResigned, PlayerID: &loser})
+		case played.Status == "FINISHED" || played.Status == "DRAW":
+			closing = append(closing, GameEvent{Type: EventLeft})
+		default:
+			events = append(events, GameEvent{Type: EventEnded})
+		}
+	case "VOIDED":
+		closing = append(closing, GameEvent{Type: EventVoided})
+	}
+
+	if g.RematchP1 {
+		events = append(events, GameEvent{Type: EventRematchRequested, PlayerID: &p1})
+	}
+	if g.RematchP2 && g.Player2ID != nil {
+		p2 := *g.Player2ID
+		events = append(events, GameEvent{Type: EventRematchRequested, PlayerID: &p2})
+	}
+	if g.NextRoomCode != nil {
+		events = append(events, GameEvent{Type: EventRematchAgreed, Data: GameEventData{NextRoomCode: *g.NextRoomCode}})
+	}
+	return append(events, closing...)
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestApplyEventResignRequiresPlayer(t *testing.T) {
+	p1, p2, stranger := 1, 2, 3
+	g := &Game{}
+	for _, e := range []GameEvent{
+		{Type: EventCreated, PlayerID: &p1, Data: GameEventData{Player1ID: p1, CurrentTurnID: p1, Board: "---------", Status: "WAITING"}},
+		{Type: EventJoined, PlayerID: &p2},
+	} {
+		if err := ApplyEvent(g, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := ApplyEvent(g, GameEvent{Type: EventResigned, PlayerID: &stranger}); err == nil {
+		t.Fatal("non-player ended the game")
+	}
+	if g.Status != "IN_PROGRESS" {
+		t.Fatalf("status = %s after rejected event", g.Status)
+	}
+
+	if err := ApplyEvent(g, GameEvent{Type: EventResigned, PlayerID: &p2}); err != nil {
+		t.Fatal(err)
+	}
+	if g.Status != "ABANDONED" || g.WinnerID == nil || *g.WinnerID != p1 || g.TerminationReason == nil || *g.TerminationReason != ReasonResignation {
+		t.Fatalf("status = %s, winner = %v, reason = %v", g.Status, g.WinnerID, g.TerminationReason)
+	}
+}
+
+// postOK - POST ที่ต้องสำเร็จ (200 / 201)
+func postOK(t *testing.T, h http.Handler, path, token string, body gin.H) map[string]interface{} {
+	t.Helper()
+	w := doRequest(t, h, http.MethodPost, path, token, body)
+	if w.Code != http.StatusOK && w.Code != http.StatusCreated {
+		t.Fatalf("POST %s %v: %d %s", path, body, w.Code, w.Body.String())
+	}
+	return decodeBody(t, w)
+}
+
+// assertEventLogMatches - replay event ของห้องแล้วต้องได้ projection เดียวกับ games
+func assertEventLogMatches(t *testing.T, s *Server, roomCode string) []GameEvent {
+	t.Helper()
+	g, err := s.Games.GetGame(roomCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := s.Games.ListEvents(roomCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ReplayGame(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diffs := projectionDiff(want, g); len(diffs) > 0 {
+		t.Fatalf("%s: projection differs from the event log: %v", roomCode, diffs)
+	}
+	return events
+}
+
+func eventTypes(events []GameEvent) []string {
+	types := make([]string, len(events))
+	for i, e := range events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func TestEventLogReplaysToProjection(t *testing.T) {
+	tests := []struct {
+		name string
+		// play - เล่นผ่าน HTTP คืนห้องที่ต้องตรวจ และ event ที่คาดไว้ของห้องแรก
+		play func(t *testing.T, h http.Handler, host, guest string) ([]string, []string)
+	}{
+		{"win and rematch", func(t *testing.T, h http.Handler, host, guest string) ([]string, []string) {
+			room := startTestGame(t, h, host, guest, nil)
+			playMoves(t, h, room, host, guest, [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}, {2, 0}})
+			postOK(t, h, "/api/games/"+room+"/rematch", host, nil)
+			next := postOK(t, h, "/api/games/"+room+"/rematch", guest, nil)["room_code"].(string)
+			postOK(t, h, "/api/games/move", guest, gin.H{"room_code": next, "x": 1, "y": 1})
+			return []string{room, next}, []string{EventCreated, EventJoined, EventMoved, EventMoved, EventMoved, EventMoved, EventMoved,
+				EventRematchRequested, EventRematchRequested, EventRematchAgreed}
+		}},
+		{"draw then leave", func(t *testing.T, h http.Handler, host, guest string) ([]string, []string) {
+			room := startTestGame(t, h, host, guest, nil)
+			playMoves(t, h, room, host, guest, [][2]int{{0, 0}, {1, 0}, {2, 0}, {1, 1}, {0, 1}, {0, 2}, {2, 1}, {2, 2}, {1, 2}})
+			postOK(t, h, "/api/games/"+room+"/leave", guest, nil)
+			return []string{room}, nil
+		}},
+		{"resign", func(t *testing.T, h http.Handler, host, guest string) ([]string, []string) {
+			room := startTestGame(t, h, host, guest, nil)
+			playMoves(t, h, room, host, guest, [][2]int{{1, 1}})
+			postOK(t, h, "/api/games/"+room+"/leave", guest, nil)
+			return []string{room}, []string{EventCreated, EventJoined, EventMoved, EventResigned}
+		}},
+		{"gravity", func(t *testing.T, h http.Handler, host, guest string) ([]string, []string) {
+			room := startTestGame(t, h, host, guest, gin.H{"variant": "gravity", "width": 4, "height": 4, "win_length": 3})
+			for i, x := range []int{0, 1, 0, 1, 0} {
+				token := host
+				if i%2 == 1 {
+					token = guest
+				}
+				postOK(t, h, "/api/games/move", token, gin.H{"room_code": room, "x": x})
+			}
+			return []string{room}, nil
+		}},
+		{"ultimate", func(t *testing.T, h http.Handler, host, guest string) ([]string, []string) {
+			room := startTestGame(t, h, host, guest, gin.H{"variant": "ultimate"})
+			playMoves(t, h, room, host, guest, [][2]int{{4, 4}, {3, 3}, {0, 0}})
+			return []string{room}, nil
+		}},
+		{"quantum collapse", func(t *testing.T, h http.Handler, host, guest string) ([]string, []string) {
+			room := startTestGame(t, h, host, guest, gin.H{"variant": "quantum"})
+			postOK(t, h, "/api/games/move", host, gin.H{"room_code": room, "x": 0, "y": 0, "x2": 1, "y2": 0})
+			postOK(t, h, "/api/games/move", guest, gin.H{"room_code": room, "x": 1, "y": 0, "x2": 2, "y2": 0})
+			postOK(t, h, "/api/games/move", host, gin.H{"room_code": room, "x": 2, "y": 0, "x2": 0, "y2": 0})
+			postOK(t, h, "/api/games/collapse", guest, gin.H{"room_code": room, "x": 0, "y": 0})
+			return []string{room}, []string{EventCreated, EventJoined, EventSpookyMoved, EventSpookyMoved, EventSpookyMoved, EventCollapsed}
+		}},
+	}
+	backends := []struct {
+		name      string
+		newServer func(t *testing.T) (*Server, http.Handler)
+	}{
+		{"memory", newTestServer},
+		{"sqlite", newSQLiteTestServer},
+	}
+	for _, backend := range backends {
+		for _, tt := range tests {
+			t.Run(backend.name+"/"+tt.name, func(t *testing.T) {
+				s, h := backend.newServer(t)
+				_, host := newTestUser(t, s, "host")
+				_, guest := newTestUser(t, s, "guest")
+
+				rooms, wantTypes := tt.play(t, h, host, guest)
+				for i, room := range rooms {
+					events := assertEventLogMatches(t, s, room)
+					if i == 0 && wantTypes != nil && !reflect.DeepEqual(eventTypes(events), wantTypes) {
+						t.Fatalf("events = %v, want %v", eventTypes(events), wantTypes)
+					}
+				}
+
+				// GET /:id/events ส่ง event ชุดเดียวกันเรียงตาม seq
+				w := doRequest(t, h, http.MethodGet, "/api/games/"+rooms[0]+"/events", host, nil)
+				var resp struct {
+					Events []GameEvent `json:"events"`
+				}
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+					t.Fatalf("events: %d %s", w.Code, w.Body.String())
+				}
+				for i, e := range resp.Events {
+					if e.Seq != i+1 {
+						t.Fatalf("event %d has seq %d", i, e.Seq)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestProjectionDiffAndRebuild(t *testing.T) {
+	s, h := newTestServer(t)
+	_, host := newTestUser(t, s, "host")
+	guestID, guest := newTestUser(t, s, "guest")
+	room := startTestGame(t, h, host, guest, nil)
+	playMoves(t, h, room, host, guest, [][2]int{{0, 0}, {1, 1}})
+
+	if err := checkEventLogs(s.Games, []string{room}); err != nil {
+		t.Fatal(err)
+	}
+
+	// แก้ games ตรงๆ โดยไม่มี event (เหมือน projection เพี้ยน)
+	err := s.Games.WithGameLock(room, func(tx GameTx, g *Game) error {
+		g.Board = "XXX-O----"
+		g.Status = "FINISHED"
+		g.WinnerID = &guestID
+		return tx.UpdateGame(g)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, _ := s.Games.ListEvents(room)
+	want, err := ReplayGame(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, _ := s.Games.GetGame(room)
+	diffs := projectionDiff(want, g)
+	if len(diffs) != 3 {
+		t.Fatalf("diffs = %v, want board, status and winner_id", diffs)
+	}
+	if err := checkEventLogs(s.Games, []string{room}); err == nil {
+		t.Fatal("check passed on a tampered projection")
+	}
+
+	if err := rebuildProjections(s.Games, []string{room}); err != nil {
+		t.Fatal(err)
+	}
+	g, _ = s.Games.GetGame(room)
+	if g.Board != "X---O----" || g.Status != "IN_PROGRESS" || g.WinnerID != nil {
+		t.Fatalf("rebuilt game = %s %s %v", g.Board, g.Status, g.WinnerID)
+	}
+	assertEventLogMatches(t, s, room)
+}
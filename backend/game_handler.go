@@ -1,9 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,47 +19,158 @@ func GenerateRoomCode() string {
 	return fmt.Sprintf("%06d", rand.Intn(1000000))
 }
 
-func CreateGameHandler(c *gin.Context) {
-	userIDContext, _ := c.Get("userID")
-	playerID := userIDContext.(int)
+const activeSessionMessage = "You already have an active game session. Please finish or leave it first."
 
-	var activeCount int
-	checkQuery := `
-		SELECT count(*) FROM games 
-		WHERE (player1_id = $1 OR player2_id = $1) 
-		AND status IN ('WAITING', 'IN_PROGRESS')`
+const staleGameMessage = "Game has changed since the version you sent. Refresh and try again."
 
-	err := DB.QueryRow(checkQuery, playerID).Scan(&activeCount)
+// gameETag - ETag ของสถานะเกม (Game.Version เพิ่มทุกครั้งที่ state เปลี่ยน)
+func gameETag(g *Game) string {
+	return fmt.Sprintf(`"%d"`, g.Version)
+}
 
-	if activeCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "You already have an active game session. Please finish or leave it first.",
-		})
-		return
+// expectedGameVersion - version ที่ client เห็นล่าสุด จาก expected_version ใน body หรือ header If-Match
+// If-Match ส่งได้หลาย ETag คั่นด้วย comma (ตรงตัวไหนก็ได้) และรับ weak ETag (W/"3") ด้วย
+// คืน nil ถ้าไม่ได้ระบุ (หรือ If-Match: *) และตอบ 400 เองถ้ารูปแบบผิด (ok = false)
+func expectedGameVersion(c *gin.Context, fromBody *int) (versions []int, ok bool) {
+	ifMatch := strings.TrimSpace(c.GetHeader("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		if fromBody == nil {
+			return nil, true
+		}
+		return []int{*fromBody}, true
 	}
 
-	roomCode := GenerateRoomCode()
+	for _, tag := range strings.Split(ifMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		v, err := strconv.Atoi(strings.Trim(tag, `"`))
+		if err != nil || len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "If-Match must be a list of game ETags such as \"3\""})
+			return nil, false
+		}
+		versions = append(versions, v)
+	}
+	if fromBody != nil {
+		if !versionMatches(versions, *fromBody) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expected_version and If-Match do not match"})
+			return nil, false
+		}
+		return []int{*fromBody}, true
+	}
+	return versions, true
+}
+
+// versionMatches - version ปัจจุบันตรงกับที่ client คาดไว้ไหม (versions ว่าง = ไม่มีเงื่อนไข)
+func versionMatches(versions []int, current int) bool {
+	if versions == nil {
+		return true
+	}
+	for _, v := range versions {
+		if v == current {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) CreateGameHandler(c *gin.Context) {
+	var req struct {
+		// Variant - กติกาของกระดาน (standard, ultimate, gravity, misere, notakto, quantum, qubic ดู variant.go)
+		Variant string `json:"variant" binding:"omitempty,oneof=standard ultimate gravity misere notakto quantum qubic"`
+		// Width / Height / WinLength - ขนาดกระดานและจำนวนที่ต้องเรียง (gravity เท่านั้น ไม่ส่ง = 7x6 เรียง 4)
+		Width     int `json:"width" binding:"omitempty,min=3,max=9"`
+		Height    int `json:"height" binding:"omitempty,min=3,max=9"`
+		WinLength int `json:"win_length" binding:"omitempty,min=3,max=9"`
+		// Boards - จำนวนกระดานของ notakto (ไม่ส่ง = 1)
+		Boards int `json:"boards" binding:"omitempty,min=1,max=3"`
+		// DrawRule - เสมอเมื่อไหร่ (full_board = กระดานเต็ม, dead_position / perfect_play = จบก่อนได้ ดู EarlyDraw)
+		DrawRule string `json:"draw_rule" binding:"omitempty,oneof=full_board dead_position perfect_play"`
+	}
+	userIDContext, _ := c.Get("userID")
+	playerID := userIDContext.(int)
+
+	// body ไม่บังคับ (ไม่ส่งมา = กติกาปกติ)
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Variant == "" {
+		req.Variant = VariantStandard
+	}
+	if req.DrawRule == "" {
+		req.DrawRule = DrawRuleFullBoard
+	}
+	// EarlyDraw ดูเป็นแค่กระดาน 3x3
+	if req.Variant != VariantStandard && req.DrawRule != DrawRuleFullBoard {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "draw_rule is only supported for the standard variant"})
+		return
+	}
+	if req.Variant != VariantGravity && (req.Width != 0 || req.Height != 0 || req.WinLength != 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "width, height and win_length can only be set for the gravity variant"})
+		return
+	}
+	if (req.Width == 0) != (req.Height == 0) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "width and height must be set together"})
+		return
+	}
+	if req.Variant != VariantNotakto && req.Boards != 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "boards can only be set for the notakto variant"})
+		return
+	}
+	if req.Boards != 0 {
+		// กระดานของ notakto วางเรียงกันในแนวนอน
+		req.Width, req.Height = req.Boards*3, 3
+	}
 
-	var gameID int
-	query := `
-		INSERT INTO games (room_code, player1_id, current_turn_id, status, board) 
-		VALUES ($1, $2, $2, 'WAITING', '---------') 
-		RETURNING id`
+	if s.rejectIfSuspended(c, playerID) {
+		return
+	}
 
-	err = DB.QueryRow(query, roomCode, playerID).Scan(&gameID)
+	activeRoom, err := s.Games.ActiveRoomCode(playerID)
 	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check active games"})
+		return
+	}
+	if activeRoom != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": activeSessionMessage})
+		return
+	}
+
+	game := Game{
+		RoomCode:      GenerateRoomCode(),
+		Player1ID:     playerID,
+		CurrentTurnID: playerID,
+		Status:        "WAITING",
+		Variant:       req.Variant,
+		Width:         req.Width,
+		Height:        req.Height,
+		WinLength:     req.WinLength,
+		DrawRule:      req.DrawRule,
+	}
+	setGameDefaults(&game)
+	// แถวที่ยาวที่สุดบนกระดานคือด้านที่ยาวกว่า (แนวนอน = width, แนวตั้ง = height) ถ้า win_length ยาวกว่านั้นจะไม่มีใครชนะได้เลย
+	if game.WinLength > max(game.Width, game.Height) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "win_length does not fit on the board"})
+		return
+	}
+	game.Board = emptyBoard(&game)
+	if err := s.Games.CreateGame(&game); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create game", "details": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":   "Game created successfully",
-		"room_code": roomCode,
+		"room_code": game.RoomCode,
 		"status":    "WAITING",
+		"variant":   game.Variant,
+		"width":     game.Width,
+		"height":    game.Height,
+		"depth":     game.Depth,
+		"draw_rule": game.DrawRule,
 	})
 }
 
-func JoinGameHandler(c *gin.Context) {
+func (s *Server) JoinGameHandler(c *gin.Context) {
 	var req struct {
 		RoomCode string `json:"room_code" binding:"required,len=6"`
 	}
@@ -66,69 +181,41 @@ func JoinGameHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid room code format (must be 6 digits)"})
 		return
 	}
-	//atomic ทั้งก้อน begin - commit
-	tx, err := DB.Begin()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not start transaction"})
-		return
-	}
-
-	// ถ้าเกิดอะไรขึ้นผิดพลาดให้ Rollback เสมอ
-	defer tx.Rollback()
 
-	var activeCount int
-	checkQuery := `
-		SELECT count(*) FROM games 
-		WHERE (player1_id = $1 OR player2_id = $1) 
-		AND status IN ('WAITING', 'IN_PROGRESS')`
-
-	err = DB.QueryRow(checkQuery, playerID).Scan(&activeCount)
-
-	if activeCount > 0 {
-		c.JSON(http.StatusConflict, gin.H{
-			"error": "You already have an active game session. Please finish or leave it first.",
-		})
+	if s.rejectIfSuspended(c, playerID) {
 		return
 	}
 
-	// 1. SELECT ... FOR UPDATE เพื่อ Lock แถวเกมนั้นไว้ก่อน
-	var gameID, p1ID int
-	var p2ID *int
-	var status string
-
-	queryLock := `SELECT id, player1_id, player2_id, status FROM games WHERE room_code = $1 FOR UPDATE`
-	err = tx.QueryRow(queryLock, req.RoomCode).Scan(&gameID, &p1ID, &p2ID, &status)
-
+	activeRoom, err := s.Games.ActiveRoomCode(playerID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Room not found. Check your code!"})
-		return
-	}
-
-	// 2. validation ต่างๆ เช่น เช็คว่าเกมเต็มหรือยัง เช็คว่าเกมอยู่ในสถานะ WAITING หรือเปล่า เช็คว่า player ที่จะเข้ามาไม่ได้เป็น player1 อยู่แล้ว
-	if p1ID == playerID {
-		c.JSON(http.StatusConflict, gin.H{"error": "You are already the host of this room"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check active games"})
 		return
 	}
-	if p2ID != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Room is full"})
-		return
-	}
-	if status != "WAITING" {
-		c.JSON(http.StatusConflict, gin.H{"error": "Game is already in progress"})
+	if activeRoom != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": activeSessionMessage})
 		return
 	}
 
-	// 3. UPDATE เพื่อ set player2_id และเปลี่ยนสถานะเกมเป็น IN_PROGRESS
-	queryUpdate := `UPDATE games SET player2_id = $1, status = 'IN_PROGRESS' WHERE id = $2`
-	_, err = tx.Exec(queryUpdate, playerID, gameID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join game"})
-		return
-	}
+	// 1. Lock แถวเกมนั้นไว้ก่อน (atomic ทั้งก้อน ถ้า validation ไม่ผ่านจะ Rollback)
+	err = s.Games.WithGameLock(req.RoomCode, func(tx GameTx, g *Game) error {
+		// 2. validation ต่างๆ เช่น เช็คว่าเกมเต็มหรือยัง เช็คว่าเกมอยู่ในสถานะ WAITING หรือเปล่า เช็คว่า player ที่จะเข้ามาไม่ได้เป็น player1 อยู่แล้ว
+		if g.Player1ID == playerID {
+			return reject(http.StatusConflict, "You are already the host of this room")
+		}
+		if g.Player2ID != nil {
+			return reject(http.StatusConflict, "Room is full")
+		}
+		if g.Status != "WAITING" {
+			return reject(http.StatusConflict, "Game is already in progress")
+		}
 
-	// 4. Commit Transaction เพื่อยืนยันข้อมูลและปล่อย Lock
-	if err := tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Commit failed"})
+		// 3. set player2_id และเปลี่ยนสถานะเกมเป็น IN_PROGRESS (ผ่าน event joined)
+		if err := applyGameEvent(tx, g, GameEvent{Type: EventJoined, PlayerID: &playerID}); err != nil {
+			return err
+		}
+		return tx.UpdateGame(g)
+	})
+	if respondStoreError(c, err, "Room not found. Check your code!", "Failed to join game") {
 		return
 	}
 
@@ -139,11 +226,19 @@ func JoinGameHandler(c *gin.Context) {
 	})
 }
 
-func MakeMoveHandler(c *gin.Context) {
+func (s *Server) MakeMoveHandler(c *gin.Context) {
 	var req struct {
 		RoomCode string `json:"room_code" binding:"required,len=6"`
-		X        int    `json:"x" binding:"min=0,max=2"`
-		Y        int    `json:"y" binding:"min=0,max=2"`
+		X        int    `json:"x" binding:"min=0"` // ขอบเขตขึ้นกับขนาดกระดานของห้อง
+		// Y - ไม่ต้องส่งถ้าเป็น gravity (server หาแถวที่หมากตกลงไปให้)
+		Y *int `json:"y" binding:"omitempty,min=0"`
+		// Z - ชั้นของกระดาน 3 มิติ (qubic) ไม่ส่ง = 0
+		Z *int `json:"z" binding:"omitempty,min=0"`
+		// X2 / Y2 - ช่องที่สองของ spooky mark (quantum เท่านั้น)
+		X2 *int `json:"x2" binding:"omitempty,min=0"`
+		Y2 *int `json:"y2" binding:"omitempty,min=0"`
+		// ExpectedVersion - ถ้าส่งมา จะเดินได้ก็ต่อเมื่อเกมยังอยู่ที่ version นี้ (ใช้แทน If-Match ได้)
+		ExpectedVersion *int `json:"expected_version" binding:"omitempty,min=0"`
 	}
 	userIDContext, _ := c.Get("userID")
 	playerID := userIDContext.(int)
@@ -152,318 +247,373 @@ func MakeMoveHandler(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	//atomic ทั้งก้อน begin - commit
-	tx, _ := DB.Begin()
-	defer tx.Rollback()
-
-	// 1. lock
-	var gameID int
-	var board, status string
-	var p1ID, p2ID, turnID int
-	query := `SELECT id, board, status, player1_id, player2_id, current_turn_id FROM games WHERE room_code = $1 FOR UPDATE`
-	err := tx.QueryRow(query, req.RoomCode).Scan(&gameID, &board, &status, &p1ID, &p2ID, &turnID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+	expected, ok := expectedGameVersion(c, req.ExpectedVersion)
+	if !ok {
 		return
 	}
 
-	// 2. validation
-	if status != "IN_PROGRESS" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Game is not in progress"})
+	// 1. lock
+	var result, stale *Game
+	var moved gin.H
+	err := s.Games.WithGameLock(req.RoomCode, func(tx GameTx, g *Game) error {
+		// 2. precondition: กระดานต้องยังเป็นอันที่ client เห็น (กัน retry ซ้ำ / แท็บเก่าเดินทับ)
+		if !versionMatches(expected, g.Version) {
+			stale = g
+			return reject(http.StatusConflict, staleGameMessage)
+		}
+
+		// 3. validation
+		if g.Status != "IN_PROGRESS" {
+			return reject(http.StatusBadRequest, "Game is not in progress")
+		}
+		if g.CurrentTurnID != playerID {
+			return reject(http.StatusForbidden, "Not your turn")
+		}
+
+		if g.Variant == VariantQuantum {
+			if req.Y == nil || req.X2 == nil || req.Y2 == nil {
+				return reject(http.StatusBadRequest, errQuantumMove.Error())
+			}
+			if err := CheckSpookyMove(g, req.X, *req.Y, *req.X2, *req.Y2); err != nil {
+				return reject(http.StatusBadRequest, err.Error())
+			}
+			// ช่องเดียวกันมี spooky mark ได้หลายอัน จึงไม่บันทึกลง moves (ประวัติอยู่ใน games.quantum)
+			move := GameEvent{Type: EventSpookyMoved, PlayerID: &playerID, Data: GameEventData{X: &req.X, Y: req.Y, X2: req.X2, Y2: req.Y2}}
+			if err := applyGameEvent(tx, g, move); err != nil {
+				return err
+			}
+			if err := tx.UpdateGame(g); err != nil {
+				return err
+			}
+			moved = gin.H{"x": req.X, "y": *req.Y, "x2": *req.X2, "y2": *req.Y2}
+			result = g
+			return nil
+		}
+
+		variant := variantOf(g)
+		x, z := req.X, 0
+		if req.Z != nil {
+			z = *req.Z
+		}
+		var y int
+		if req.Y != nil {
+			y = *req.Y
+		} else if dropper, ok := variant.(columnDropper); ok {
+			row, err := dropper.DropRow(g, x)
+			if err != nil {
+				return reject(http.StatusBadRequest, err.Error())
+			}
+			y = row
+		} else {
+			return reject(http.StatusBadRequest, "y is required")
+		}
+
+		// ลงช่องนี้ได้ไหม (นอกกระดาน / ช่องไม่ว่าง / ultimate ต้องลงกระดานย่อยที่กำหนด / gravity ต้องเป็นช่องล่างสุดที่ว่าง)
+		if err := variant.CheckMove(g, x, y, z); err != nil {
+			return reject(http.StatusBadRequest, err.Error())
+		}
+
+		//4. update board + check winner (ApplyEvent ของ event moved) บันทึกพิกัดที่ได้จริงลง event / moves
+		move := GameEvent{Type: EventMoved, PlayerID: &playerID, Data: GameEventData{X: &x, Y: &y}}
+		if g.Depth > 1 {
+			move.Data.Z = &z
+		}
+		if err := applyGameEvent(tx, g, move); err != nil {
+			return err
+		}
+
+		if err := tx.UpdateGame(g); err != nil {
+			return err
+		}
+		played := Move{GameID: g.ID, PlayerID: playerID, X: x, Y: y, Z: z}
+		if err := tx.AppendMove(&played); err != nil {
+			return reject(http.StatusInternalServerError, "Failed to record move")
+		}
+		moved = gin.H{"x": x, "y": y, "z": z} // gravity: แถวที่หมากตกลงไปจริง
+		result = g
+		return nil
+	})
+	if stale != nil {
+		// ส่ง state ปัจจุบันกลับไปให้ client อัปเดตกระดานแล้วค่อยตัดสินใจใหม่
+		c.Header("ETag", gameETag(stale))
+		c.JSON(http.StatusConflict, gin.H{"error": staleGameMessage, "game": newGameView(stale)})
 		return
 	}
-	if turnID != playerID {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Not your turn"})
+	if respondStoreError(c, err, "Game not found", "Failed to update game state") {
 		return
 	}
 
-	index := req.Y*3 + req.X
-	if board[index] != '-' {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cell already occupied"})
-		return
-	}
+	c.Header("ETag", gameETag(result))
+	c.JSON(http.StatusOK, moveResponse(result, moved))
+}
 
-	//3. update board
-	char := "X"
-	nextTurn := p2ID
-	if playerID == p2ID {
-		char = "O"
-		nextTurn = p1ID
+// moveResponse - ผลของการเดิน / collapse ที่ส่งกลับให้ client อัปเดตกระดาน
+func moveResponse(g *Game, move gin.H) gin.H {
+	_, winningLine := variantOf(g).Outcome(g)
+	return gin.H{
+		"board":              g.Board,
+		"move":               move,
+		"status":             g.Status,
+		"next_board":         g.NextBoard,
+		"legal_boards":       LegalBoards(g),
+		"quantum":            g.Quantum,
+		"winning_line":       winningLine,
+		"termination_reason": g.TerminationReason,
+		"version":            g.Version,
 	}
+}
 
-	newBoard := board[:index] + char + board[index+1:]
+// CollapseHandler - quantum: เลือกช่องที่ spooky mark ที่ปิด cycle จะยุบลง (คนที่ถึงตา = คนที่ไม่ได้ปิด cycle)
+func (s *Server) CollapseHandler(c *gin.Context) {
+	var req struct {
+		RoomCode string `json:"room_code" binding:"required,len=6"`
+		X        int    `json:"x" binding:"min=0"`
+		Y        int    `json:"y" binding:"min=0"`
+		// ExpectedVersion - เหมือน MakeMoveHandler (ใช้แทน If-Match ได้)
+		ExpectedVersion *int `json:"expected_version" binding:"omitempty,min=0"`
+	}
+	userIDContext, _ := c.Get("userID")
+	playerID := userIDContext.(int)
 
-	//4. check winner
-	winnerSign := CheckWinner(newBoard)
-	newStatus := "IN_PROGRESS"
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	expected, ok := expectedGameVersion(c, req.ExpectedVersion)
+	if !ok {
+		return
+	}
 
-	var winnerID *int
+	var result, stale *Game
+	err := s.Games.WithGameLock(req.RoomCode, func(tx GameTx, g *Game) error {
+		if !versionMatches(expected, g.Version) {
+			stale = g
+			return reject(http.StatusConflict, staleGameMessage)
+		}
+		if g.Status != "IN_PROGRESS" {
+			return reject(http.StatusBadRequest, "Game is not in progress")
+		}
+		if g.CurrentTurnID != playerID {
+			return reject(http.StatusForbidden, "Not your turn")
+		}
+		if err := CheckCollapse(g, req.X, req.Y); err != nil {
+			return reject(http.StatusBadRequest, err.Error())
+		}
 
-	if winnerSign != "" {
-		if winnerSign == "DRAW" {
-			newStatus = "DRAW"
-		} else {
-			newStatus = "FINISHED"
-			winnerID = &playerID
+		chosen := GameEvent{Type: EventCollapsed, PlayerID: &playerID, Data: GameEventData{X: &req.X, Y: &req.Y}}
+		if err := applyGameEvent(tx, g, chosen); err != nil {
+			return err
+		}
+		if err := tx.UpdateGame(g); err != nil {
+			return err
 		}
+		result = g
+		return nil
+	})
+	if stale != nil {
+		c.Header("ETag", gameETag(stale))
+		c.JSON(http.StatusConflict, gin.H{"error": staleGameMessage, "game": newGameView(stale)})
+		return
 	}
-
-	updateQuery := `UPDATE games SET board = $1, current_turn_id = $2, status = $3, winner_id = $4 WHERE id = $5`
-	_, err = tx.Exec(updateQuery, newBoard, nextTurn, newStatus, winnerID, gameID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update game state"})
+	if respondStoreError(c, err, "Game not found", "Failed to update game state") {
 		return
 	}
 
-	_, err = tx.Exec(`INSERT INTO moves (game_id, player_id, x, y, move_order) 
-			 VALUES ($1, $2, $3, $4, (SELECT count(*)+1 FROM moves WHERE game_id=$1))`,
-		gameID, playerID, req.X, req.Y)
+	c.Header("ETag", gameETag(result))
+	c.JSON(http.StatusOK, moveResponse(result, gin.H{"x": req.X, "y": req.Y}))
+}
+
+// gameView - game พร้อมช่องที่เรียงกันชนะ และกระดานย่อยที่ลงได้ (คำนวณจาก board ไม่ได้เก็บไว้ใน games)
+type gameView struct {
+	*Game
+	WinningLine []int `json:"winning_line"`
+	LegalBoards []int `json:"legal_boards"` // ultimate / notakto เท่านั้น (null = variant อื่นหรือเกมไม่ได้เล่นอยู่)
+}
+
+func newGameView(g *Game) gameView {
+	_, line := variantOf(g).Outcome(g)
+	return gameView{Game: g, WinningLine: line, LegalBoards: LegalBoards(g)}
+}
+
+// GetGameHandler - ดูสถานะเกมปัจจุบัน (ใช้สำหรับ Polling)
+func (s *Server) GetGameHandler(c *gin.Context) {
+	game, err := s.Games.GetGame(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record move"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
 		return
 	}
 
-	if err := tx.Commit(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Commit failed"})
+	// polling ที่ส่ง If-None-Match มาได้ 304 ถ้ายังไม่มีอะไรเปลี่ยน
+	etag := gameETag(game)
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"board":  newBoard,
-		"status": newStatus,
-	})
+	c.JSON(http.StatusOK, newGameView(game))
 }
 
-// GetGameHandler - ดูสถานะเกมปัจจุบัน (ใช้สำหรับ Polling)
-func GetGameHandler(c *gin.Context) {
-	roomCode := c.Param("id")
-
-	var game struct {
-		ID            int     `json:"id"`
-		RoomCode      string  `json:"room_code"`
-		Player1ID     int     `json:"player1_id"`
-		Player2ID     *int    `json:"player2_id"`
-		CurrentTurnID int     `json:"current_turn_id"`
-		Board         string  `json:"board"`
-		Status        string  `json:"status"`
-		WinnerID      *int    `json:"winner_id"`
-		NextRoomCode  *string `json:"next_room_code"`
-		RematchP1     bool    `json:"rematch_p1"`
-		RematchP2     bool    `json:"rematch_p2"`
-	}
-
-	query := `SELECT id, room_code, player1_id, player2_id, current_turn_id, board, status, winner_id, next_room_code, rematch_p1, rematch_p2 
-			  FROM games WHERE room_code = $1`
-
-	row := DB.QueryRow(query, roomCode)
-	err := row.Scan(&game.ID, &game.RoomCode, &game.Player1ID, &game.Player2ID, &game.CurrentTurnID, &game.Board, &game.Status, &game.WinnerID, &game.NextRoomCode, &game.RematchP1, &game.RematchP2)
+// GetGameEventsHandler - event log ของห้อง (ใช้ตรวจสอบ / replay ย้อนหลัง)
+func (s *Server) GetGameEventsHandler(c *gin.Context) {
+	events, err := s.Games.ListEvents(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
 		return
 	}
-
-	c.JSON(http.StatusOK, game)
+	c.JSON(http.StatusOK, gin.H{"events": events})
 }
 
 // GetGameMovesHandler - ดูประวัติการเดินของเกม (ใช้สำหรับ Polling)
-func GetGameMovesHandler(c *gin.Context) {
-	roomCode := c.Param("id")
-
-	query := `
-		SELECT m.id, m.game_id, m.player_id, m.x, m.y, m.created_at 
-		FROM moves m
-		JOIN games g ON m.game_id = g.id
-		WHERE g.room_code = $1 
-		ORDER BY m.move_order ASC
-	`
-	rows, err := DB.Query(query, roomCode)
+func (s *Server) GetGameMovesHandler(c *gin.Context) {
+	moves, err := s.Games.ListMoves(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moves"})
 		return
 	}
-
-	defer rows.Close()
-
-	var moves []Move
-	for rows.Next() {
-		var m Move
-		if err := rows.Scan(&m.ID, &m.GameID, &m.PlayerID, &m.X, &m.Y, &m.CreatedAt); err != nil {
-			continue
-		}
-		moves = append(moves, m)
-	}
 	c.JSON(http.StatusOK, gin.H{"moves": moves})
 }
 
-func CancelGameHandler(c *gin.Context) {
+func (s *Server) CancelGameHandler(c *gin.Context) {
 	roomCode := c.Param("id")
 	userIDContext, _ := c.Get("userID")
 	playerID := userIDContext.(int)
 
-	//hard delete
-	query := `DELETE FROM games WHERE room_code = $1 AND player1_id = $2 AND status = 'WAITING'`
-	result, err := DB.Exec(query, roomCode, playerID)
-
+	deleted, err := s.Games.DeleteWaitingGame(roomCode, playerID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel game"})
 		return
 	}
 
-	rowsAffected, _ := result.RowsAffected()
 	//ลบไม่สำเร็จ
-	if rowsAffected == 0 {
+	if !deleted {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot cancel this room. It may have already started or you are not the host."})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Room destroyed successfully"})
 }
 
-func RematchHandler(c *gin.Context) {
+func (s *Server) RematchHandler(c *gin.Context) {
 	roomCode := c.Param("id")
 	userIDContext, _ := c.Get("userID")
 	playerID := userIDContext.(int)
 
-	//Start Transaction
-	tx, err := DB.Begin()
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not start transaction"})
-		return
-	}
-
-	defer tx.Rollback()
-
-	var gameID, p1ID int
-	var p2ID *int
-	var status string
-	var nextRoomCode *string
-	var rematchP1, rematchP2 bool
-
 	//ล็อคแถวไว้ป้องกัน rematch พร้อมกัน
-	queryLock := `SELECT id, player1_id, player2_id, status, next_room_code, rematch_p1, rematch_p2 
-	              FROM games WHERE room_code = $1 FOR UPDATE`
-	err = tx.QueryRow(queryLock, roomCode).Scan(&gameID, &p1ID, &p2ID, &status, &nextRoomCode, &rematchP1, &rematchP2)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
-		return
-	}
+	var newRoomCode string
+	err := s.Games.WithGameLock(roomCode, func(tx GameTx, g *Game) error {
+		//validate เกมจบมั้ย แล้วผู้เล่นห้องนี้กด rematch จริงมั้ย
+		if g.Status != "FINISHED" && g.Status != "DRAW" {
+			return reject(http.StatusBadRequest, "Game is not finished yet")
+		}
+		isP1 := (playerID == g.Player1ID)
+		isP2 := (g.Player2ID != nil && playerID == *g.Player2ID)
 
-	//validate เกมจบมั้ย แล้วผู้เล่นห้องนี้กด rematch จริงมั้ย
-	if status != "FINISHED" && status != "DRAW" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Game is not finished yet"})
-		return
-	}
-	isP1 := (playerID == p1ID)
-	isP2 := (p2ID != nil && playerID == *p2ID)
+		if !isP1 && !isP2 {
+			return reject(http.StatusForbidden, "You are not a player in this game")
+		}
 
-	if !isP1 && !isP2 {
-		c.JSON(http.StatusForbidden, gin.H{"error": "You are not a player in this game"})
-		return
-	}
+		// อัปเดตสถานะว่าคนนี้กด Rematch แล้ว (กดซ้ำไม่ต้องบันทึก event เพิ่ม)
+		alreadyRequested := (isP1 && g.RematchP1) || (isP2 && g.RematchP2)
+		if !alreadyRequested {
+			if err := applyGameEvent(tx, g, GameEvent{Type: EventRematchRequested, PlayerID: &playerID}); err != nil {
+				return err
+			}
+		}
 
-	// อัปเดตสถานะว่าคนนี้กด Rematch แล้ว
-	if isP1 {
-		rematchP1 = true
-	} else if isP2 {
-		rematchP2 = true
-	}
+		if g.RematchP1 && g.RematchP2 && g.NextRoomCode == nil {
+			// ถ้าครบ 2 คนแล้ว ให้สร้างห้องใหม่เลย
+			// สลับฝั่ง P1 กับ P2
+			next := Game{
+				RoomCode:  GenerateRoomCode(),
+				Player1ID: g.Player1ID,
+				Player2ID: g.Player2ID,
+				Status:    "IN_PROGRESS",
+				Variant:   g.Variant, // ห้องใหม่ใช้กติกาเดิม
+				Width:     g.Width,
+				Height:    g.Height,
+				Depth:     g.Depth,
+				WinLength: g.WinLength,
+				Board:     emptyBoard(g),
+				DrawRule:  g.DrawRule,
+			}
+			if g.Player2ID != nil {
+				p1ID := g.Player1ID
+				next.Player1ID = *g.Player2ID
+				next.Player2ID = &p1ID
+			}
+			next.CurrentTurnID = next.Player1ID
+
+			if err := tx.CreateGame(&next); err != nil {
+				return err
+			}
+			// ห้องเก่า ชี้เป้าไปห้องใหม่
+			agreed := GameEvent{Type: EventRematchAgreed, Data: GameEventData{NextRoomCode: next.RoomCode}}
+			if err := applyGameEvent(tx, g, agreed); err != nil {
+				return err
+			}
+			newRoomCode = next.RoomCode
+		}
 
-	//บันทึกลง db
-	updateRematch := `UPDATE games SET rematch_p1 = $1, rematch_p2 = $2 WHERE id = $3`
-	_, err = tx.Exec(updateRematch, rematchP1, rematchP2, gameID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update rematch status"})
+		return tx.UpdateGame(g)
+	})
+	if respondStoreError(c, err, "Game not found", "Failed to update rematch status") {
 		return
 	}
 
-	if rematchP1 && rematchP2 && nextRoomCode == nil {
-		// ถ้าครบ 2 คนแล้ว ให้สร้างห้องใหม่เลย
-		newRoomCode := GenerateRoomCode()
-
-		// สลับฝั่ง P1 กับ P2
-		newP1 := p1ID
-		newP2 := p2ID
-		if p2ID != nil {
-			newP1 = *p2ID
-			newP2 = &p1ID
-		}
-
-		var newGameID int
-		insertQuery := `
-			INSERT INTO games (room_code, player1_id, player2_id, current_turn_id, status, board) 
-			VALUES ($1, $2, $3, $2, 'IN_PROGRESS', '---------') 
-			RETURNING id`
-		err = tx.QueryRow(insertQuery, newRoomCode, newP1, newP2).Scan(&newGameID)
-
-		if err == nil {
-			// อัปเดตห้องเก่า ให้ชี้เป้าไปห้องใหม่
-			tx.Exec(`UPDATE games SET next_room_code = $1 WHERE id = $2`, newRoomCode, gameID)
-
-			// Commit เลย
-			tx.Commit()
-			c.JSON(http.StatusOK, gin.H{
-				"message":   "Both players agreed. Match started!",
-				"status":    "2/2",
-				"room_code": newRoomCode,
-			})
-			return
-		}
+	if newRoomCode != "" {
+		c.JSON(http.StatusOK, gin.H{
+			"message":   "Both players agreed. Match started!",
+			"status":    "2/2",
+			"room_code": newRoomCode,
+		})
+		return
 	}
 	//ถ้ามีแค่ 1 คน
-	tx.Commit()
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Waiting for opponent...",
 		"status":  "1/2",
 	})
-
 }
 
-func LeaveGameHandler(c *gin.Context) {
+func (s *Server) LeaveGameHandler(c *gin.Context) {
 	roomCode := c.Param("id")
 	userIDContext, _ := c.Get("userID")
 	playerID := userIDContext.(int)
 
-	var gameID, p1ID int
-	var p2ID *int
-	var status string
-
-	err := DB.QueryRow(`SELECT id, player1_id, player2_id, status FROM games WHERE room_code = $1`, roomCode).Scan(&gameID, &p1ID, &p2ID, &status)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
-		return
-	}
-
-	if status == "IN_PROGRESS" {
-		// ถ้ากดออกกลางเกม = ยอมแพ้ (Surrender) ให้อีกฝั่งชนะทันที
-		var winnerID int
-		if playerID == p1ID && p2ID != nil {
-			winnerID = *p2ID
+	err := s.Games.WithGameLock(roomCode, func(tx GameTx, g *Game) error {
+		if playerID != g.Player1ID && (g.Player2ID == nil || playerID != *g.Player2ID) {
+			return reject(http.StatusForbidden, "You are not a player in this game")
+		}
+		var event string
+		if g.Status == "IN_PROGRESS" {
+			// ถ้ากดออกกลางเกม = ยอมแพ้ (Surrender) ให้อีกฝั่งชนะทันที
+			event = EventResigned
+		} else if g.Status == "FINISHED" || g.Status == "DRAW" {
+			// ถ้ากดออกตอนเกมจบแล้ว (ทิ้งหน้าจอ Rematch) -> เปลี่ยนเป็น ABANDONED
+			event = EventLeft
 		} else {
-			winnerID = p1ID
+			return nil
 		}
-		DB.Exec(`UPDATE games SET status = 'ABANDONED', winner_id = $1 WHERE id = $2`, winnerID, gameID)
-
-	} else if status == "FINISHED" || status == "DRAW" {
-		// ถ้ากดออกตอนเกมจบแล้ว (ทิ้งหน้าจอ Rematch) -> เปลี่ยนเป็น ABANDONED
-		DB.Exec(`UPDATE games SET status = 'ABANDONED' WHERE id = $1`, gameID)
+		if err := applyGameEvent(tx, g, GameEvent{Type: event, PlayerID: &playerID}); err != nil {
+			return err
+		}
+		return tx.UpdateGame(g)
+	})
+	if respondStoreError(c, err, "Game not found", "Failed to leave game") {
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Left the arena"})
 }
 
-
-func GetMyActiveGameHandler(c *gin.Context) {
+func (s *Server) GetMyActiveGameHandler(c *gin.Context) {
 	userIDContext, _ := c.Get("userID")
 	playerID := userIDContext.(int)
 
-	var roomCode string
-	query := `SELECT room_code FROM games WHERE (player1_id = $1 OR player2_id = $1) AND status IN ('WAITING', 'IN_PROGRESS') LIMIT 1`
-	err := DB.QueryRow(query, playerID).Scan(&roomCode)
-
-	if err != nil { // ถ้าหาไม่เจอ (ไม่มีห้องค้าง)
+	roomCode, err := s.Games.ActiveRoomCode(playerID)
+	if err != nil || roomCode == "" { // ถ้าหาไม่เจอ (ไม่มีห้องค้าง)
 		c.JSON(http.StatusOK, gin.H{"has_active_game": false})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"has_active_game": true, "room_code": roomCode})
-}
\ No newline at end of file
+}
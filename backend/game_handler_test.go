@@ -0,0 +1,493 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ---------- helpers (ใช้ร่วมกันทุกไฟล์ test) ----------
+
+func newTestServer(t *testing.T) (*Server, http.Handler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	s := NewMemoryServer()
+	return s, s.Router()
+}
+
+// newSQLiteTestServer - Server บน SQLite ไฟล์ชั่วคราว (migrate แล้ว) สำหรับ test ที่ต้องใช้ SQL จริง
+func newSQLiteTestServer(t *testing.T) (*Server, http.Handler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	db, err := sql.Open(sqliteDriverName, sqliteDSN(filepath.Join(t.TempDir(), "test.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+	s := NewSQLServer(db)
+	return s, s.Router()
+}
+
+// newTestUser - สร้างผู้ใช้ตรงๆ ใน store (ข้าม bcrypt ที่ช้า) แล้วคืน id กับ token ที่ใช้ได้
+func newTestUser(t *testing.T, s *Server, username string) (int, string) {
+	t.Helper()
+	userID, err := s.Users.CreateUser(username, "")
+	if err != nil {
+		t.Fatalf("create user %s: %v", username, err)
+	}
+	return userID, testToken(t, s, userID)
+}
+
+// testToken - token ของ state ปัจจุบันของผู้ใช้ (token_version / role ล่าสุด)
+func testToken(t *testing.T, s *Server, userID int) string {
+	t.Helper()
+	user, err := s.Users.UserByID(userID)
+	if err != nil {
+		t.Fatalf("user %d: %v", userID, err)
+	}
+	token, err := GenerateToken(*user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// doRequest - ยิง request เข้า router (body nil = ไม่มี body, headers เป็นคู่ key, value)
+func doRequest(t *testing.T, h http.Handler, method, path, token string, body interface{}, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var out map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("response is not JSON (%d): %s", w.Code, w.Body.String())
+	}
+	return out
+}
+
+// startTestGame - host สร้างห้อง (body = ตัวเลือกของห้อง) แล้ว guest เข้าร่วม คืน room code
+func startTestGame(t *testing.T, h http.Handler, hostToken, guestToken string, body gin.H) string {
+	t.Helper()
+	w := doRequest(t, h, http.MethodPost, "/api/games", hostToken, body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create game: %d %s", w.Code, w.Body.String())
+	}
+	roomCode := decodeBody(t, w)["room_code"].(string)
+	if w := doRequest(t, h, http.MethodPost, "/api/games/join", guestToken, gin.H{"room_code": roomCode}); w.Code != http.StatusOK {
+		t.Fatalf("join game: %d %s", w.Code, w.Body.String())
+	}
+	return roomCode
+}
+
+// playMoves - เดินตามลำดับ สลับกันระหว่าง host (ตาแรก) กับ guest
+func playMoves(t *testing.T, h http.Handler, roomCode, hostToken, guestToken string, moves [][2]int) *httptest.ResponseRecorder {
+	t.Helper()
+	var w *httptest.ResponseRecorder
+	for i, m := range moves {
+		token := hostToken
+		if i%2 == 1 {
+			token = guestToken
+		}
+		w = doRequest(t, h, http.MethodPost, "/api/games/move", token, gin.H{"room_code": roomCode, "x": m[0], "y": m[1]})
+		if w.Code != http.StatusOK {
+			t.Fatalf("move %d (%d, %d): %d %s", i+1, m[0], m[1], w.Code, w.Body.String())
+		}
+	}
+	return w
+}
+
+// ---------- tests ----------
+
+func TestCreateGameHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   gin.H
+		status int
+	}{
+		{"default standard", nil, http.StatusCreated},
+		{"gravity default size", gin.H{"variant": "gravity"}, http.StatusCreated},
+		{"gravity custom size", gin.H{"variant": "gravity", "width": 5, "height": 4, "win_length": 4}, http.StatusCreated},
+		{"unknown variant", gin.H{"variant": "chess"}, http.StatusBadRequest},
+		{"size on standard", gin.H{"width": 4}, http.StatusBadRequest},
+		{"boards on standard", gin.H{"boards": 2}, http.StatusBadRequest},
+		{"draw rule on gravity", gin.H{"variant": "gravity", "draw_rule": "dead_position"}, http.StatusBadRequest},
+		{"board too large", gin.H{"variant": "gravity", "width": 10, "height": 6}, http.StatusBadRequest},
+		{"width without height", gin.H{"variant": "gravity", "width": 5}, http.StatusBadRequest},
+		{"height without width", gin.H{"variant": "gravity", "height": 5}, http.StatusBadRequest},
+		{"win_length longer than both sides", gin.H{"variant": "gravity", "width": 4, "height": 3, "win_length": 5}, http.StatusBadRequest},
+		{"win_length fits one side", gin.H{"variant": "gravity", "width": 5, "height": 3, "win_length": 5}, http.StatusCreated},
+		{"notakto boards", gin.H{"variant": "notakto", "boards": 3}, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, h := newTestServer(t)
+			_, token := newTestUser(t, s, "host")
+			w := doRequest(t, h, http.MethodPost, "/api/games", token, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateGameRejectsSecondActiveRoom(t *testing.T) {
+	s, h := newTestServer(t)
+	_, token := newTestUser(t, s, "host")
+	if w := doRequest(t, h, http.MethodPost, "/api/games", token, nil); w.Code != http.StatusCreated {
+		t.Fatalf("first room: %d", w.Code)
+	}
+	if w := doRequest(t, h, http.MethodPost, "/api/games", token, nil); w.Code != http.StatusConflict {
+		t.Fatalf("second room: %d, want 409", w.Code)
+	}
+}
+
+func TestJoinGameHandler(t *testing.T) {
+	s, h := newTestServer(t)
+	_, hostToken := newTestUser(t, s, "host")
+	_, guestToken := newTestUser(t, s, "guest")
+	_, lateToken := newTestUser(t, s, "late")
+
+	w := doRequest(t, h, http.MethodPost, "/api/games", hostToken, nil)
+	roomCode := decodeBody(t, w)["room_code"].(string)
+
+	tests := []struct {
+		name     string
+		token    string
+		roomCode string
+		status   int
+	}{
+		{"bad format", guestToken, "12", http.StatusBadRequest},
+		{"unknown room", guestToken, "000000", http.StatusNotFound},
+		{"host joins own room", hostToken, roomCode, http.StatusConflict},
+		{"guest joins", guestToken, roomCode, http.StatusOK},
+		{"room is full", lateToken, roomCode, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(t, h, http.MethodPost, "/api/games/join", tt.token, gin.H{"room_code": tt.roomCode})
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestMakeMoveHandler(t *testing.T) {
+	s, h := newTestServer(t)
+	_, hostToken := newTestUser(t, s, "host")
+	_, guestToken := newTestUser(t, s, "guest")
+	roomCode := startTestGame(t, h, hostToken, guestToken, nil)
+
+	// แต่ละ case ทำต่อจาก case ก่อนหน้า
+	tests := []struct {
+		name   string
+		token  string
+		x, y   int
+		status int
+	}{
+		{"guest moves first", guestToken, 0, 0, http.StatusForbidden},
+		{"outside the board", hostToken, 3, 0, http.StatusBadRequest},
+		{"host moves", hostToken, 1, 1, http.StatusOK},
+		{"host moves twice", hostToken, 0, 0, http.StatusForbidden},
+		{"occupied cell", guestToken, 1, 1, http.StatusBadRequest},
+		{"guest moves", guestToken, 0, 0, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(t, h, http.MethodPost, "/api/games/move", tt.token, gin.H{"room_code": roomCode, "x": tt.x, "y": tt.y})
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+		})
+	}
+
+	g, _ := s.Games.GetGame(roomCode)
+	if g.Board != "O---X----" {
+		t.Fatalf("board = %q", g.Board)
+	}
+}
+
+func TestMakeMoveWinsGame(t *testing.T) {
+	s, h := newTestServer(t)
+	hostID, hostToken := newTestUser(t, s, "host")
+	_, guestToken := newTestUser(t, s, "guest")
+	roomCode := startTestGame(t, h, hostToken, guestToken, nil)
+
+	w := playMoves(t, h, roomCode, hostToken, guestToken, [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}, {2, 0}})
+	body := decodeBody(t, w)
+	if body["status"] != "FINISHED" {
+		t.Fatalf("response = %v", body)
+	}
+	if g, _ := s.Games.GetGame(roomCode); g.WinnerID == nil || *g.WinnerID != hostID {
+		t.Fatalf("winner = %v, want %d", g.WinnerID, hostID)
+	}
+	if w := doRequest(t, h, http.MethodPost, "/api/games/move", guestToken, gin.H{"room_code": roomCode, "x": 2, "y": 2}); w.Code != http.StatusBadRequest {
+		t.Fatalf("move after the game ended: %d", w.Code)
+	}
+}
+
+// TestConcurrentMovesSameCell - ยิง move ช่องเดียวกันพร้อมกัน ต้องสำเร็จแค่ครั้งเดียว และมีแถวใน moves แค่แถวเดียว
+func TestConcurrentMovesSameCell(t *testing.T) {
+	t.Run("memory", func(t *testing.T) { concurrentMovesSameCell(t, newTestServer) })
+	t.Run("sqlite", func(t *testing.T) { concurrentMovesSameCell(t, newSQLiteTestServer) })
+}
+
+func concurrentMovesSameCell(t *testing.T, newServer func(t *testing.T) (*Server, http.Handler)) {
+	s, h := newServer(t)
+	_, hostToken := newTestUser(t, s, "host")
+	_, guestToken := newTestUser(t, s, "guest")
+	roomCode := startTestGame(t, h, hostToken, guestToken, nil)
+
+	const n = 20
+	codes := make([]int, n)
+	var start, done sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < n; i++ {
+		done.Add(1)
+		go func(i int) {
+			defer done.Done()
+			start.Wait()
+			req := httptest.NewRequest(http.MethodPost, "/api/games/move", bytes.NewBufferString(`{"room_code":"`+roomCode+`","x":1,"y":1}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+hostToken)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, req)
+			codes[i] = w.Code
+		}(i)
+	}
+	start.Done()
+	done.Wait()
+
+	ok := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			ok++
+		case http.StatusForbidden, http.StatusBadRequest:
+		default:
+			t.Fatalf("unexpected status %d", code)
+		}
+	}
+	if ok != 1 {
+		t.Fatalf("%d requests succeeded, want exactly 1 (%v)", ok, codes)
+	}
+	moves, err := s.Games.ListMoves(roomCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) != 1 {
+		t.Fatalf("%d moves recorded, want 1", len(moves))
+	}
+}
+
+func TestLeaveGameHandlerResigns(t *testing.T) {
+	s, h := newTestServer(t)
+	_, hostToken := newTestUser(t, s, "host")
+	guestID, guestToken := newTestUser(t, s, "guest")
+	roomCode := startTestGame(t, h, hostToken, guestToken, nil)
+
+	if w := doRequest(t, h, http.MethodPost, "/api/games/"+roomCode+"/leave", hostToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("leave: %d %s", w.Code, w.Body.String())
+	}
+	g, _ := s.Games.GetGame(roomCode)
+	if g.Status != "ABANDONED" || g.WinnerID == nil || *g.WinnerID != guestID {
+		t.Fatalf("status = %s, winner = %v", g.Status, g.WinnerID)
+	}
+}
+
+func TestLeaveGameHandlerRejectsNonPlayers(t *testing.T) {
+	s, h := newTestServer(t)
+	_, hostToken := newTestUser(t, s, "host")
+	_, guestToken := newTestUser(t, s, "guest")
+	_, strangerToken := newTestUser(t, s, "stranger")
+	roomCode := startTestGame(t, h, hostToken, guestToken, nil)
+
+	if w := doRequest(t, h, http.MethodPost, "/api/games/"+roomCode+"/leave", strangerToken, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("leave by a non-player: %d, want 403", w.Code)
+	}
+	if g, _ := s.Games.GetGame(roomCode); g.Status != "IN_PROGRESS" {
+		t.Fatalf("status = %s, want IN_PROGRESS", g.Status)
+	}
+}
+
+func TestMoveIfMatchPreconditions(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch func(version int) string
+		body    gin.H // เพิ่มเข้าไปใน body ของ move
+		status  int
+	}{
+		{"no precondition", nil, nil, http.StatusOK},
+		{"strong match", func(v int) string { return fmt.Sprintf(`"%d"`, v) }, nil, http.StatusOK},
+		{"weak match", func(v int) string { return fmt.Sprintf(`W/"%d"`, v) }, nil, http.StatusOK},
+		{"list with a match", func(v int) string { return fmt.Sprintf(`"%d", W/"%d"`, v+5, v) }, nil, http.StatusOK},
+		{"wildcard", func(int) string { return "*" }, nil, http.StatusOK},
+		{"mismatch", func(v int) string { return fmt.Sprintf(`"%d"`, v-1) }, nil, http.StatusConflict},
+		{"list without a match", func(v int) string { return fmt.Sprintf(`"%d", "%d"`, v-1, v+1) }, nil, http.StatusConflict},
+		{"not an etag", func(v int) string { return fmt.Sprint(v) }, nil, http.StatusBadRequest},
+		{"body disagrees with header", func(v int) string { return fmt.Sprintf(`"%d"`, v) }, gin.H{"expected_version": 99}, http.StatusBadRequest},
+		{"body in header list", func(v int) string { return fmt.Sprintf(`"99", "%d"`, v) }, gin.H{"expected_version": 99}, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, h := newTestServer(t)
+			_, hostToken := newTestUser(t, s, "host")
+			_, guestToken := newTestUser(t, s, "guest")
+			roomCode := startTestGame(t, h, hostToken, guestToken, nil)
+			g, _ := s.Games.GetGame(roomCode)
+
+			body := gin.H{"room_code": roomCode, "x": 1, "y": 1}
+			for k, v := range tt.body {
+				body[k] = v
+			}
+			var headers []string
+			if tt.ifMatch != nil {
+				headers = []string{"If-Match", tt.ifMatch(g.Version)}
+			}
+			w := doRequest(t, h, http.MethodPost, "/api/games/move", hostToken, body, headers...)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+
+			after, _ := s.Games.GetGame(roomCode)
+			switch tt.status {
+			case http.StatusOK:
+				if w.Header().Get("ETag") != gameETag(after) {
+					t.Fatalf("ETag = %s, want %s", w.Header().Get("ETag"), gameETag(after))
+				}
+			case http.StatusConflict:
+				// 409 ส่งสถานะล่าสุดกลับมาให้ client ไม่ต้องดึงใหม่
+				resp := decodeBody(t, w)
+				game, _ := resp["game"].(map[string]interface{})
+				if resp["error"] != staleGameMessage || game == nil || game["version"] != float64(g.Version) {
+					t.Fatalf("409 body = %v", resp)
+				}
+				if w.Header().Get("ETag") != gameETag(g) {
+					t.Fatalf("ETag = %s, want %s", w.Header().Get("ETag"), gameETag(g))
+				}
+				fallthrough
+			default:
+				if after.Version != g.Version || after.Board != g.Board {
+					t.Fatalf("game changed after a rejected move")
+				}
+			}
+		})
+	}
+}
+
+func TestGetGameIfNoneMatch(t *testing.T) {
+	s, h := newTestServer(t)
+	_, hostToken := newTestUser(t, s, "host")
+	_, guestToken := newTestUser(t, s, "guest")
+	roomCode := startTestGame(t, h, hostToken, guestToken, nil)
+
+	w := doRequest(t, h, http.MethodGet, "/api/games/"+roomCode, hostToken, nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag == "" {
+		t.Fatalf("status = %d, ETag = %q", w.Code, etag)
+	}
+	if w := doRequest(t, h, http.MethodGet, "/api/games/"+roomCode, hostToken, nil, "If-None-Match", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("unchanged game: %d %s, want 304", w.Code, w.Body.String())
+	}
+
+	playMoves(t, h, roomCode, hostToken, guestToken, [][2]int{{1, 1}})
+	w = doRequest(t, h, http.MethodGet, "/api/games/"+roomCode, hostToken, nil, "If-None-Match", etag)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Fatalf("changed game: %d, ETag = %s", w.Code, w.Header().Get("ETag"))
+	}
+}
+
+// TestGameTermination - winning_line / termination_reason ทั้งใน response ของ move และ GET /api/games/:id
+func TestGameTermination(t *testing.T) {
+	topRow := [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}, {2, 0}}
+	// X กลาง, O มุม ... กระดาน OXO/XXO/-OX ไม่มีใครเรียงได้อีกหลังตาที่ 8
+	deadAfter8 := [][2]int{{1, 1}, {0, 0}, {2, 2}, {2, 0}, {1, 0}, {1, 2}, {0, 1}, {2, 1}}
+	tests := []struct {
+		name   string
+		body   gin.H
+		moves  [][2]int
+		status string
+		winner string // host, guest หรือ "" = ไม่มี
+		reason string
+		line   string // winning_line เป็น JSON
+	}{
+		{"line", nil, topRow, "FINISHED", "host", ReasonLine, "[0,1,2]"},
+		{"misere line loses", gin.H{"variant": "misere"}, topRow, "FINISHED", "guest", ReasonLine, "[0,1,2]"},
+		{"full board", nil, append(deadAfter8, [2]int{0, 2}), "DRAW", "", ReasonDraw, "null"},
+		{"dead position", gin.H{"draw_rule": "dead_position"}, deadAfter8, "DRAW", "", ReasonDeadPosition, "null"},
+		{"forced draw", gin.H{"draw_rule": "perfect_play"}, deadAfter8[:2], "DRAW", "", ReasonForcedDraw, "null"},
+		{"still playing", nil, topRow[:4], "IN_PROGRESS", "", "", "null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, h := newTestServer(t)
+			hostID, hostToken := newTestUser(t, s, "host")
+			guestID, guestToken := newTestUser(t, s, "guest")
+			room := startTestGame(t, h, hostToken, guestToken, tt.body)
+			moved := decodeBody(t, playMoves(t, h, room, hostToken, guestToken, tt.moves))
+			shown := decodeBody(t, doRequest(t, h, http.MethodGet, "/api/games/"+room, hostToken, nil))
+
+			var reason interface{}
+			if tt.reason != "" {
+				reason = tt.reason
+			}
+			for source, body := range map[string]map[string]interface{}{"move": moved, "get": shown} {
+				line, _ := json.Marshal(body["winning_line"])
+				if body["status"] != tt.status || body["termination_reason"] != reason || string(line) != tt.line {
+					t.Fatalf("%s: status = %v, termination_reason = %v, winning_line = %s", source, body["status"], body["termination_reason"], line)
+				}
+			}
+
+			wantWinner := map[string]interface{}{"host": float64(hostID), "guest": float64(guestID)}[tt.winner]
+			if shown["winner_id"] != wantWinner {
+				t.Fatalf("winner_id = %v, want %v", shown["winner_id"], wantWinner)
+			}
+		})
+	}
+}
+
+func TestGameTerminationResignation(t *testing.T) {
+	s, h := newTestServer(t)
+	_, hostToken := newTestUser(t, s, "host")
+	guestID, guestToken := newTestUser(t, s, "guest")
+	room := startTestGame(t, h, hostToken, guestToken, nil)
+	playMoves(t, h, room, hostToken, guestToken, [][2]int{{0, 0}, {1, 1}})
+
+	if w := doRequest(t, h, http.MethodPost, "/api/games/"+room+"/leave", hostToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("leave: %d %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, doRequest(t, h, http.MethodGet, "/api/games/"+room, guestToken, nil))
+	if body["termination_reason"] != ReasonResignation || body["winning_line"] != nil || body["winner_id"] != float64(guestID) {
+		t.Fatalf("termination_reason = %v, winning_line = %v, winner_id = %v", body["termination_reason"], body["winning_line"], body["winner_id"])
+	}
+}
@@ -0,0 +1,46 @@
+// backend/gravity.go
+package main
+
+import "fmt"
+
+// Gravity (Connect Four): ผู้เล่นเลือกแค่คอลัมน์ หมากตกลงไปช่องว่างที่ต่ำที่สุดของคอลัมน์นั้น
+// ค่าเริ่มต้น 7 คอลัมน์ x 6 แถว เรียง 4 ตัวชนะ (ตั้งเองได้ตอนสร้างห้อง ผ่าน width / height / win_length)
+// ช่องล่างสุดคือ y = height-1 (y นับจากบนลงล่างเหมือนกระดานอื่น) moves เก็บพิกัดที่หมากตกลงไปจริง
+type gravityVariant struct{}
+
+func (gravityVariant) Defaults() (int, int, int, int) { return 7, 6, 1, 4 }
+
+// DropRow - แถวที่หมากจะตกลงไปถ้าลงคอลัมน์ x
+func (gravityVariant) DropRow(g *Game, x int) (int, error) {
+	if x < 0 || x >= g.Width {
+		return 0, fmt.Errorf("Column must be between 0 and %d", g.Width-1)
+	}
+	for y := g.Height - 1; y >= 0; y-- {
+		if g.Board[y*g.Width+x] == '-' {
+			return y, nil
+		}
+	}
+	return 0, fmt.Errorf("Column %d is full", x)
+}
+
+func (v gravityVariant) CheckMove(g *Game, x, y, z int) error {
+	if err := checkInside(g, x, y, z); err != nil {
+		return err
+	}
+	row, err := v.DropRow(g, x)
+	if err != nil {
+		return err
+	}
+	if y != row {
+		return fmt.Errorf("A piece in column %d lands on row %d", x, row)
+	}
+	return nil
+}
+
+func (gravityVariant) Play(g *Game, x, y, z int, mark byte) {
+	placeMark(g, x, y, z, mark)
+}
+
+func (gravityVariant) Outcome(g *Game) (string, []int) {
+	return CheckLine(g.Board, boardDims(g), g.WinLength)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func gravityGame(t *testing.T, width, height, winLength int) *Game {
+	t.Helper()
+	g := &Game{Variant: VariantGravity, Width: width, Height: height, WinLength: winLength}
+	setGameDefaults(g)
+	g.Board = emptyBoard(g)
+	return g
+}
+
+// dropAll - ลงหมากตามคอลัมน์ที่ให้ สลับ X / O เริ่มจาก X
+func dropAll(t *testing.T, g *Game, columns ...int) {
+	t.Helper()
+	v := gravityVariant{}
+	for i, x := range columns {
+		y, err := v.DropRow(g, x)
+		if err != nil {
+			t.Fatalf("drop %d in column %d: %v", i+1, x, err)
+		}
+		mark := byte('X')
+		if i%2 == 1 {
+			mark = 'O'
+		}
+		v.Play(g, x, y, 0, mark)
+	}
+}
+
+func TestGravityDropRow(t *testing.T) {
+	g := gravityGame(t, 0, 0, 0)
+	v := gravityVariant{}
+	if g.Width != 7 || g.Height != 6 || g.WinLength != 4 {
+		t.Fatalf("defaults = %dx%d win %d", g.Width, g.Height, g.WinLength)
+	}
+
+	for want := 5; want >= 0; want-- {
+		row, err := v.DropRow(g, 3)
+		if err != nil || row != want {
+			t.Fatalf("DropRow = %d, %v, want %d", row, err, want)
+		}
+		if err := v.CheckMove(g, 3, want-1, 0); err == nil && want > 0 {
+			t.Fatalf("floating piece at row %d accepted", want-1)
+		}
+		if err := v.CheckMove(g, 3, want, 0); err != nil {
+			t.Fatalf("CheckMove row %d: %v", want, err)
+		}
+		v.Play(g, 3, want, 0, 'X')
+	}
+	if _, err := v.DropRow(g, 3); err == nil {
+		t.Fatal("full column accepted")
+	}
+	for _, x := range []int{-1, 7} {
+		if _, err := v.DropRow(g, x); err == nil {
+			t.Fatalf("column %d accepted", x)
+		}
+	}
+}
+
+func TestGravityOutcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		columns []int
+		winner  string
+		line    []int // index = y*7 + x
+	}{
+		// X: (0,5) (1,4) (2,3) (3,2) ทแยงขึ้นไปทางขวา
+		{"rising diagonal", []int{0, 1, 1, 2, 2, 3, 2, 3, 3, 6, 3}, "X", []int{17, 23, 29, 35}},
+		// X: (6,5) (5,4) (4,3) (3,2) ทแยงขึ้นไปทางซ้าย
+		{"falling diagonal", []int{6, 5, 5, 4, 4, 3, 4, 3, 3, 0, 3}, "X", []int{17, 25, 33, 41}},
+		{"horizontal", []int{0, 0, 1, 1, 2, 2, 3}, "X", []int{35, 36, 37, 38}},
+		{"vertical", []int{0, 1, 0, 1, 0, 1, 0}, "X", []int{14, 21, 28, 35}},
+		{"three in a row is not enough", []int{0, 1, 1, 2, 2, 3, 2}, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := gravityGame(t, 0, 0, 0)
+			dropAll(t, g, tt.columns...)
+			winner, line := (gravityVariant{}).Outcome(g)
+			sort.Ints(line)
+			if winner != tt.winner || !reflect.DeepEqual(line, tt.line) {
+				t.Fatalf("outcome = %q %v, want %q %v", winner, line, tt.winner, tt.line)
+			}
+		})
+	}
+}
+
+func TestGravityCustomBoard(t *testing.T) {
+	// 5x3 เรียง 5: ชนะได้แค่แนวนอนแถวใดแถวหนึ่ง
+	g := gravityGame(t, 5, 3, 5)
+	dropAll(t, g, 0, 0, 1, 1, 2, 2, 3, 3)
+	if winner, _ := (gravityVariant{}).Outcome(g); winner != "" {
+		t.Fatalf("winner = %q before the row is complete", winner)
+	}
+	dropAll(t, g, 4)
+	if winner, line := (gravityVariant{}).Outcome(g); winner != "X" || !reflect.DeepEqual(line, []int{10, 11, 12, 13, 14}) {
+		t.Fatalf("outcome = %q %v", winner, line)
+	}
+}
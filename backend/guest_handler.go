@@ -0,0 +1,132 @@
+// backend/guest_handler.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// guest ที่ไม่ได้สร้าง/เข้าเกมเลยเกินช่วงนี้ จะถูกเก็บกวาด (ตั้งค่าได้ด้วย GUEST_TTL เช่น "72h")
+const defaultGuestTTL = 7 * 24 * time.Hour
+
+// CreateGuestHandler - เล่นได้ทันทีโดยไม่ต้องสมัคร: สร้างผู้ใช้ชั่วคราว (ไม่มีรหัสผ่าน) แล้วออก JWT ให้เลย
+func (s *Server) CreateGuestHandler(c *gin.Context) {
+	// ชื่อมี "_" ซึ่ง RegisterHandler ไม่อนุญาต จึงไม่ชนกับผู้ใช้จริง แต่ guest ด้วยกันอาจสุ่มชนได้ -> ลองใหม่
+	var user *User
+	var err error
+	for attempt := 0; attempt < 5; attempt++ {
+		user, err = s.Users.CreateGuest(fmt.Sprintf("guest_%06d", rand.Intn(1000000)))
+		if errors.Is(err, ErrDuplicate) {
+			continue
+		}
+		break
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create guest"})
+		return
+	}
+
+	tokenString, err := GenerateToken(*user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Guest session created",
+		"token":    tokenString,
+		"user_id":  user.ID,
+		"username": user.Username,
+		"is_guest": true,
+	})
+}
+
+// UpgradeGuestHandler - เปลี่ยน guest เป็นบัญชีจริง (ตั้ง username/password) โดยใช้ id เดิม ประวัติเกมจึงตามมาครบ
+func (s *Server) UpgradeGuestHandler(c *gin.Context) {
+	var req struct {
+		Username string `json:"username" binding:"required,alphanum,min=3,max=20"`
+		Password string `json:"password" binding:"required,min=6"`
+	}
+	userIDContext, _ := c.Get("userID")
+	userID := userIDContext.(int)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !c.GetBool("isGuest") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This account is not a guest account"})
+		return
+	}
+
+	hashedPassword, err := HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	// token_version + 1 -> token guest เดิมใช้ไม่ได้ ได้ token ใหม่ที่ไม่ใช่ guest แทน
+	var user User
+	err = s.Users.WithUserLock(userID, func(tx UserTx, u *User) error {
+		if !u.IsGuest {
+			return reject(http.StatusBadRequest, "Failed to upgrade guest account")
+		}
+		u.Username, u.PasswordHash, u.IsGuest = req.Username, hashedPassword, false
+		u.TokenVersion++
+		user = *u
+		return tx.UpdateUser(u)
+	})
+	if errors.Is(err, ErrDuplicate) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Username already exists"})
+		return
+	}
+	if respondStoreError(c, err, "Failed to upgrade guest account", "Failed to upgrade guest account") {
+		return
+	}
+
+	tokenString, err := GenerateToken(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Guest account upgraded successfully",
+		"token":    tokenString,
+		"user_id":  user.ID,
+		"username": user.Username,
+	})
+}
+
+// StartGuestCleanup - รัน CleanupStaleGuests เป็นระยะใน background
+func (s *Server) StartGuestCleanup(interval time.Duration) {
+	ttl := defaultGuestTTL
+	if v := os.Getenv("GUEST_TTL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			ttl = parsed
+		}
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			deleted, anonymized, err := s.Users.CleanupStaleGuests(time.Now().Add(-ttl))
+			if err != nil {
+				log.Println("guest cleanup failed:", err)
+				continue
+			}
+			if deleted > 0 || anonymized > 0 {
+				log.Printf("guest cleanup: deleted %d, anonymized %d\n", deleted, anonymized)
+			}
+		}
+	}()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestGuest - สร้าง guest ผ่าน POST /api/guest คืน id กับ token
+func newTestGuest(t *testing.T, h http.Handler) (int, string) {
+	t.Helper()
+	w := doRequest(t, h, http.MethodPost, "/api/guest", "", nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create guest: %d %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	return int(body["user_id"].(float64)), body["token"].(string)
+}
+
+func TestCreateGuestHandler(t *testing.T) {
+	s, h := newSQLiteTestServer(t)
+
+	w := doRequest(t, h, http.MethodPost, "/api/guest", "", nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create guest: %d %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	username, _ := body["username"].(string)
+	if !regexp.MustCompile(`^guest_\d{6}$`).MatchString(username) || body["is_guest"] != true {
+		t.Fatalf("guest = %v, want guest_NNNNNN", body)
+	}
+
+	user, err := s.Users.UserByID(int(body["user_id"].(float64)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.IsGuest || user.Username != username || user.PasswordHash != "" {
+		t.Fatalf("stored guest = %+v", user)
+	}
+	token := body["token"].(string)
+	if w := doRequest(t, h, http.MethodGet, "/api/games/me/active", token, nil); w.Code != http.StatusOK {
+		t.Fatalf("guest token rejected: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// HashPassword ใช้ bcrypt cost 14 (~1 วินาที) test นี้จึง upgrade แค่สองครั้ง
+func TestUpgradeGuestHandler(t *testing.T) {
+	s, h := newSQLiteTestServer(t)
+	newTestUser(t, s, "taken")
+	guestID, guestToken := newTestGuest(t, h)
+
+	w := doRequest(t, h, http.MethodPost, "/api/guest/upgrade", guestToken, gin.H{"username": "taken", "password": "secret123"})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("duplicate username: %d %s, want 409", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, h, http.MethodGet, "/api/games/me/active", guestToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("failed upgrade revoked the guest token: %d", w.Code)
+	}
+
+	w = doRequest(t, h, http.MethodPost, "/api/guest/upgrade", guestToken, gin.H{"username": "alice", "password": "secret123"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("upgrade: %d %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	if int(body["user_id"].(float64)) != guestID {
+		t.Fatalf("upgraded user id = %v, want %d", body["user_id"], guestID)
+	}
+
+	w = doRequest(t, h, http.MethodGet, "/api/games/me/active", guestToken, nil)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("old guest token: %d, want 401", w.Code)
+	}
+	newToken := body["token"].(string)
+	if w := doRequest(t, h, http.MethodGet, "/api/games/me/active", newToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("new token: %d %s", w.Code, w.Body.String())
+	}
+	user, err := s.Users.UserByID(guestID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.IsGuest || user.Username != "alice" || user.PasswordHash == "" {
+		t.Fatalf("upgraded user = %+v", user)
+	}
+
+	// บัญชีจริงแล้ว upgrade ซ้ำไม่ได้ (ไม่ต้องถึงขั้น hash รหัสผ่าน)
+	if w := doRequest(t, h, http.MethodPost, "/api/guest/upgrade", newToken, gin.H{"username": "alice2", "password": "secret123"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("upgrade a full account: %d, want 400", w.Code)
+	}
+}
+
+func TestCleanupStaleGuests(t *testing.T) {
+	s, h := newSQLiteTestServer(t)
+
+	idle, _ := newTestGuest(t, h)
+	finished, finishedToken := newTestGuest(t, h)
+	active, activeToken := newTestGuest(t, h)
+	_, opponent1 := newTestUser(t, s, "opponent1")
+	_, opponent2 := newTestUser(t, s, "opponent2")
+	member, _ := newTestUser(t, s, "member")
+
+	room := startTestGame(t, h, finishedToken, opponent1, nil)
+	playMoves(t, h, room, finishedToken, opponent1, [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0, 2}})
+	startTestGame(t, h, activeToken, opponent2, nil)
+
+	// cutoff อยู่ในอนาคต -> guest ทุกคนและเกมที่จบแล้วนับว่าเก่าเกิน TTL
+	deleted, anonymized, err := s.Users.CleanupStaleGuests(time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 || anonymized != 1 {
+		t.Fatalf("deleted %d, anonymized %d; want 1 and 1", deleted, anonymized)
+	}
+
+	if _, err := s.Users.UserByID(idle); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("guest without games: err = %v, want ErrNotFound", err)
+	}
+	if u, err := s.Users.UserByID(finished); err != nil || u.DeletedAt == nil {
+		t.Fatalf("guest with a finished game: %+v, %v; want anonymized", u, err)
+	}
+	if w := doRequest(t, h, http.MethodGet, "/api/games/"+room, opponent1, nil); w.Code != http.StatusOK {
+		t.Fatalf("finished game lost after cleanup: %d", w.Code)
+	}
+	for _, id := range []int{active, member} {
+		if u, err := s.Users.UserByID(id); err != nil || u.DeletedAt != nil {
+			t.Fatalf("user %d: %+v, %v; want untouched", id, u, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// backend/idempotency.go
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Idempotency-Key: client ที่ retry หลัง timeout ส่งคีย์เดิมมา จะได้ผลลัพธ์ของครั้งแรกกลับไป
+// แทนการทำซ้ำ (ไม่งั้นจะเจอ "Not your turn" / "Cell already occupied" ทั้งที่เดินสำเร็จไปแล้ว)
+// คีย์แยกตามผู้ใช้ และเก็บไว้ IDEMPOTENCY_TTL (ค่าเริ่มต้น 24 ชั่วโมง)
+const (
+	idempotencyHeader       = "Idempotency-Key"
+	idempotencyMaxKeyLength = 255
+	defaultIdempotencyTTL   = 24 * time.Hour
+)
+
+// header ของผลลัพธ์ที่ต้องตอบซ้ำด้วย (ETag ใช้ต่อกับ If-Match ของตาถัดไป)
+var idempotentReplayHeaders = []string{"Content-Type", "ETag"}
+
+// IdempotentResponse - ผลลัพธ์ที่เก็บไว้ของคีย์หนึ่ง
+type IdempotentResponse struct {
+	Fingerprint string
+	Status      int // 0 = request แรกยังทำงานไม่เสร็จ
+	Header      map[string]string
+	Body        []byte
+}
+
+func idempotencyTTL() time.Duration {
+	if v := os.Getenv("IDEMPOTENCY_TTL"); v != "" {
+		if parsed, err := time.ParseDuration(v); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultIdempotencyTTL
+}
+
+// requestFingerprint - ใช้คีย์เดิมกับ request ที่ต่างไปจากครั้งแรกไม่ได้
+func requestFingerprint(method, path string, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, method+" "+path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder - เก็บ body ที่ handler เขียนไว้ด้วย เพื่อบันทึกเป็นผลลัพธ์ของคีย์
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware - รองรับ header Idempotency-Key บน POST/PUT/PATCH/DELETE (ต้องวางหลัง AuthMiddleware)
+// request ที่ไม่ได้ส่งคีย์มาทำงานตามปกติ
+func (s *Server) IdempotencyMiddleware() gin.HandlerFunc {
+	ttl := idempotencyTTL()
+
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyHeader)
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			key = ""
+		}
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > idempotencyMaxKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long (max 255 characters)"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		fingerprint := requestFingerprint(c.Request.Method, c.Request.URL.Path, body)
+
+		userIDContext, _ := c.Get("userID")
+		userID, _ := userIDContext.(int)
+
+		existing, reserved, err := s.Idempotency.Reserve(userID, key, fingerprint, time.Now().Add(-ttl))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+			c.Abort()
+			return
+		}
+		if !reserved {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+			case existing.Status == 0:
+				c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+			default:
+				// ตอบผลลัพธ์เดิมซ้ำ (รวมถึง 4xx) โดยไม่เรียก handler อีก
+				for name, value := range existing.Header {
+					c.Header(name, value)
+				}
+				contentType := existing.Header["Content-Type"]
+				if contentType == "" {
+					contentType = "application/json; charset=utf-8" // คีย์ที่บันทึกไว้ก่อนมี response_headers
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(existing.Status, contentType, existing.Body)
+			}
+			c.Abort()
+			return
+		}
+
+		// ถ้า handler panic หรือ error 5xx ไม่รู้ว่าทำไปถึงไหนแล้ว ปล่อยคีย์ให้ retry ได้
+		completed := false
+		defer func() {
+			if !completed {
+				s.Idempotency.Release(userID, key)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		header := map[string]string{}
+		for _, name := range idempotentReplayHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				header[name] = value
+			}
+		}
+		if err := s.Idempotency.Complete(userID, key, recorder.Status(), header, recorder.body.Bytes()); err != nil {
+			log.Println("failed to store idempotent response:", err)
+			return
+		}
+		completed = true
+	}
+}
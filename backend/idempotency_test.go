@@ -0,0 +1,157 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestIdempotentMoveReplay(t *testing.T) {
+	s, h := newTestServer(t)
+	hostID, hostToken := newTestUser(t, s, "host")
+	_, guestToken := newTestUser(t, s, "guest")
+	room := startTestGame(t, h, hostToken, guestToken, nil)
+	move := gin.H{"room_code": room, "x": 1, "y": 1}
+
+	first := doRequest(t, h, http.MethodPost, "/api/games/move", hostToken, move, "Idempotency-Key", "move-1")
+	if first.Code != http.StatusOK || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("first: %d %s", first.Code, first.Body.String())
+	}
+
+	// retry หลัง timeout: ได้ผลเดิม ไม่ได้ "Not your turn"
+	retry := doRequest(t, h, http.MethodPost, "/api/games/move", hostToken, move, "Idempotency-Key", "move-1")
+	if retry.Code != http.StatusOK || retry.Header().Get("Idempotent-Replayed") != "true" || retry.Body.String() != first.Body.String() {
+		t.Fatalf("retry: %d %s (replayed = %q)", retry.Code, retry.Body.String(), retry.Header().Get("Idempotent-Replayed"))
+	}
+	if moves, _ := s.Games.ListMoves(room); len(moves) != 1 {
+		t.Fatalf("%d moves recorded, want 1", len(moves))
+	}
+
+	// ไม่มีคีย์ = ทำงานปกติ
+	if w := doRequest(t, h, http.MethodPost, "/api/games/move", hostToken, move); w.Code != http.StatusForbidden {
+		t.Fatalf("move without a key: %d, want 403", w.Code)
+	}
+
+	// คีย์เดิมกับ request อื่น
+	other := gin.H{"room_code": room, "x": 0, "y": 0}
+	if w := doRequest(t, h, http.MethodPost, "/api/games/move", hostToken, other, "Idempotency-Key", "move-1"); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key: %d, want 422", w.Code)
+	}
+
+	// คีย์แยกตามผู้ใช้
+	if w := doRequest(t, h, http.MethodPost, "/api/games/move", guestToken, other, "Idempotency-Key", "move-1"); w.Code != http.StatusOK {
+		t.Fatalf("same key from another user: %d %s", w.Code, w.Body.String())
+	}
+
+	// request แรกของคีย์ยังไม่เสร็จ
+	if _, reserved, err := s.Idempotency.Reserve(hostID, "busy", requestFingerprint(http.MethodPost, "/api/games/move", nil), time.Now().Add(-time.Hour)); err != nil || !reserved {
+		t.Fatalf("reserve: %v %v", reserved, err)
+	}
+	if w := doRequest(t, h, http.MethodPost, "/api/games/move", hostToken, nil, "Idempotency-Key", "busy"); w.Code != http.StatusConflict {
+		t.Fatalf("key still in flight: %d, want 409", w.Code)
+	}
+}
+
+func TestIdempotencyReplaysClientErrors(t *testing.T) {
+	s, h := newTestServer(t)
+	_, hostToken := newTestUser(t, s, "host")
+	_, guestToken := newTestUser(t, s, "guest")
+	room := startTestGame(t, h, hostToken, guestToken, nil)
+
+	// 4xx ก็เก็บไว้ตอบซ้ำ (ถึงตอนนี้จะเดินได้แล้วก็ตาม)
+	move := gin.H{"room_code": room, "x": 1, "y": 1}
+	if w := doRequest(t, h, http.MethodPost, "/api/games/move", guestToken, move, "Idempotency-Key", "early"); w.Code != http.StatusForbidden {
+		t.Fatalf("first: %d", w.Code)
+	}
+	playMoves(t, h, room, hostToken, guestToken, [][2]int{{0, 0}})
+	w := doRequest(t, h, http.MethodPost, "/api/games/move", guestToken, move, "Idempotency-Key", "early")
+	if w.Code != http.StatusForbidden || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("retry: %d replayed = %q", w.Code, w.Header().Get("Idempotent-Replayed"))
+	}
+}
+
+func TestIdempotencyReleasesKeyOn5xx(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := NewMemoryServer()
+
+	calls := 0
+	r := gin.New()
+	r.POST("/flaky", func(c *gin.Context) { c.Set("userID", 1) }, s.IdempotencyMiddleware(), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "database is down"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"calls": calls})
+	})
+
+	tests := []struct {
+		status, calls int
+		replayed      string
+	}{
+		{http.StatusInternalServerError, 1, ""}, // ไม่เก็บผล 5xx
+		{http.StatusOK, 2, ""},                  // retry ได้ทำงานจริง
+		{http.StatusOK, 2, "true"},              // ครั้งต่อไปได้ผลที่เก็บไว้
+	}
+	for i, tt := range tests {
+		w := doRequest(t, r, http.MethodPost, "/flaky", "", gin.H{"n": 1}, "Idempotency-Key", "flaky")
+		if w.Code != tt.status || calls != tt.calls || w.Header().Get("Idempotent-Replayed") != tt.replayed {
+			t.Fatalf("request %d: status %d, calls %d, replayed %q", i+1, w.Code, calls, w.Header().Get("Idempotent-Replayed"))
+		}
+	}
+}
+
+// ETag ของผลลัพธ์ที่ตอบซ้ำต้องเป็นของครั้งแรก (client ใช้ต่อกับ If-Match ของตาถัดไป)
+func TestIdempotencyReplaysHeaders(t *testing.T) {
+	for _, st := range []struct {
+		name      string
+		newServer func(t *testing.T) (*Server, http.Handler)
+	}{{"memory", newTestServer}, {"sqlite", newSQLiteTestServer}} {
+		t.Run(st.name, func(t *testing.T) {
+			s, h := st.newServer(t)
+			_, hostToken := newTestUser(t, s, "host")
+			_, guestToken := newTestUser(t, s, "guest")
+			room := startTestGame(t, h, hostToken, guestToken, nil)
+			move := gin.H{"room_code": room, "x": 1, "y": 1}
+
+			first := doRequest(t, h, http.MethodPost, "/api/games/move", hostToken, move, "Idempotency-Key", "move-1")
+			etag := first.Header().Get("ETag")
+			if first.Code != http.StatusOK || etag == "" {
+				t.Fatalf("first: %d (ETag %q)", first.Code, etag)
+			}
+			playMoves(t, h, room, guestToken, hostToken, [][2]int{{0, 0}}) // version เปลี่ยนไปแล้ว
+
+			retry := doRequest(t, h, http.MethodPost, "/api/games/move", hostToken, move, "Idempotency-Key", "move-1")
+			if retry.Header().Get("Idempotent-Replayed") != "true" || retry.Header().Get("ETag") != etag {
+				t.Fatalf("retry ETag = %q, want %q (replayed = %q)", retry.Header().Get("ETag"), etag, retry.Header().Get("Idempotent-Replayed"))
+			}
+			if got, want := retry.Header().Get("Content-Type"), first.Header().Get("Content-Type"); got != want {
+				t.Fatalf("retry Content-Type = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestMemoryIdempotencyPurgesExpiredKeys(t *testing.T) {
+	store := NewMemoryStore()
+	start := time.Now()
+	for _, key := range []string{"a", "b"} {
+		if _, reserved, err := store.Reserve(1, key, "fp", start.Add(-time.Hour)); err != nil || !reserved {
+			t.Fatalf("reserve %s: %v %v", key, reserved, err)
+		}
+		store.Complete(1, key, http.StatusOK, nil, []byte(`{}`))
+	}
+	if _, reserved, _ := store.Reserve(2, "a", "fp", start.Add(-time.Hour)); !reserved {
+		t.Fatal("key from another user was not reserved")
+	}
+
+	// ทุกคีย์เก่ากว่า since แล้ว -> ถูกลบทิ้งทั้งหมด (ของผู้ใช้อื่นด้วย) เหลือแค่คีย์ที่เพิ่งจอง
+	if _, reserved, _ := store.Reserve(1, "a", "other", time.Now().Add(time.Second)); !reserved {
+		t.Fatal("expired key was not reusable")
+	}
+	if len(store.idempotency) != 1 {
+		t.Fatalf("%d keys left after purge, want 1", len(store.idempotency))
+	}
+}
@@ -0,0 +1,117 @@
+// backend/image_handler.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// boardSceneFor - อ่านห้องเกม + ?move=N (ตำแหน่งหลังการเดินครั้งที่ N, 0 = กระดานเปล่า) แล้วสร้าง scene
+// ตอบ error ไปเองถ้าไม่สำเร็จ (ok = false)
+func (s *Server) boardSceneFor(c *gin.Context) (*boardScene, bool) {
+	game, err := s.Games.GetGame(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		return nil, false
+	}
+	if !drawsAsImage(c, game) {
+		return nil, false
+	}
+	moves, err := s.Games.ListMoves(game.RoomCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moves"})
+		return nil, false
+	}
+
+	upTo := -1
+	if v := c.Query("move"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > len(moves) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("move must be between 0 and %d", len(moves))})
+			return nil, false
+		}
+		upTo = n
+	}
+	return newBoardScene(game, moves, s.playerNames(game), upTo), true
+}
+
+// drawsAsImage - board_image.go วาดได้แค่กระดาน 3x3 ตอบ 422 เองถ้าเป็น variant อื่น
+func drawsAsImage(c *gin.Context, g *Game) bool {
+	if _, standard := variantOf(g).(standardVariant); !standard {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Board images are only available for the standard variant"})
+		return false
+	}
+	return true
+}
+
+// GetBoardSVGHandler - ภาพกระดานแบบ SVG
+func (s *Server) GetBoardSVGHandler(c *gin.Context) {
+	scene, ok := s.boardSceneFor(c)
+	if !ok {
+		return
+	}
+	c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(scene.SVG()))
+}
+
+// GetBoardPNGHandler - ภาพกระดานแบบ PNG
+func (s *Server) GetBoardPNGHandler(c *gin.Context) {
+	scene, ok := s.boardSceneFor(c)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scene.Image()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render board"})
+		return
+	}
+	c.Data(http.StatusOK, "image/png", buf.Bytes())
+}
+
+// GetReplayGIFHandler - replay ทั้งเกมเป็น GIF เคลื่อนไหว (?delay= มิลลิวินาทีต่อเฟรม)
+// เกมที่จบแล้วจะ cache ไว้ ขอซ้ำไม่ต้อง render ใหม่
+func (s *Server) GetReplayGIFHandler(c *gin.Context) {
+	delay := defaultReplayDelayMS
+	if v := c.Query("delay"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < minReplayDelayMS || n > maxReplayDelayMS {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("delay must be between %d and %d milliseconds", minReplayDelayMS, maxReplayDelayMS)})
+			return
+		}
+		delay = n
+	}
+
+	game, err := s.Games.GetGame(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		return
+	}
+	if !drawsAsImage(c, game) {
+		return
+	}
+
+	key := replayCacheKey(game, delay)
+	if data, ok := s.Replays.Get(key); ok {
+		c.Data(http.StatusOK, "image/gif", data)
+		return
+	}
+
+	moves, err := s.Games.ListMoves(game.RoomCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moves"})
+		return
+	}
+	data, err := encodeReplayGIF(game, moves, s.playerNames(game), delay)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render replay"})
+		return
+	}
+	if gameOver(game) {
+		s.Replays.Put(key, data)
+	}
+	c.Data(http.StatusOK, "image/gif", data)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestBoardImageHandlers(t *testing.T) {
+	s, h := newTestServer(t)
+	_, hostToken := newTestUser(t, s, "host")
+	_, guestToken := newTestUser(t, s, "guest")
+	room := startTestGame(t, h, hostToken, guestToken, nil)
+	playMoves(t, h, room, hostToken, guestToken, [][2]int{{0, 0}, {0, 1}, {1, 0}, {1, 1}, {2, 0}})
+
+	highlight := fmt.Sprintf(`fill="%s"`, hexColor(colorHighlight))
+	tests := []struct {
+		query               string
+		xs, os, highlighted int
+	}{
+		{"", 3, 2, 3},
+		{"?move=5", 3, 2, 3},
+		{"?move=2", 1, 1, 0},
+		{"?move=0", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run("svg"+tt.query, func(t *testing.T) {
+			w := doRequest(t, h, http.MethodGet, "/api/games/"+room+"/board.svg"+tt.query, hostToken, nil)
+			if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "image/svg+xml") {
+				t.Fatalf("status = %d, type = %s", w.Code, w.Header().Get("Content-Type"))
+			}
+			svg := w.Body.String()
+			if !strings.Contains(svg, "X: host") || !strings.Contains(svg, "O: guest") {
+				t.Fatal("player names missing")
+			}
+			if got := strings.Count(svg, "<path"); got != tt.xs {
+				t.Fatalf("%d X marks, want %d", got, tt.xs)
+			}
+			if got := strings.Count(svg, "<circle"); got != tt.os {
+				t.Fatalf("%d O marks, want %d", got, tt.os)
+			}
+			if got := strings.Count(svg, highlight); got != tt.highlighted {
+				t.Fatalf("%d highlighted cells, want %d", got, tt.highlighted)
+			}
+		})
+	}
+
+	t.Run("png", func(t *testing.T) {
+		w := doRequest(t, h, http.MethodGet, "/api/games/"+room+"/board.png?move=3", hostToken, nil)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+			t.Fatalf("status = %d, type = %s", w.Code, w.Header().Get("Content-Type"))
+		}
+		img, err := png.Decode(bytes.NewReader(w.Body.Bytes()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b := img.Bounds(); b.Dx() != imageWidth || b.Dy() != imageHeight {
+			t.Fatalf("size = %v", b)
+		}
+	})
+
+	failures := []struct {
+		path   string
+		status int
+	}{
+		{"/api/games/" + room + "/board.svg?move=6", http.StatusBadRequest},
+		{"/api/games/" + room + "/board.png?move=-1", http.StatusBadRequest},
+		{"/api/games/" + room + "/board.svg?move=abc", http.StatusBadRequest},
+		{"/api/games/000000/board.svg", http.StatusNotFound},
+		{"/api/games/000000/board.png", http.StatusNotFound},
+	}
+	for _, tt := range failures {
+		t.Run(tt.path, func(t *testing.T) {
+			if w := doRequest(t, h, http.MethodGet, tt.path, hostToken, nil); w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestBoardImageRejectsOtherVariants(t *testing.T) {
+	for _, variant := range []string{"ultimate", "gravity", "misere", "notakto", "qubic"} {
+		t.Run(variant, func(t *testing.T) {
+			s, h := newTestServer(t)
+			_, hostToken := newTestUser(t, s, "host")
+			_, guestToken := newTestUser(t, s, "guest")
+			room := startTestGame(t, h, hostToken, guestToken, gin.H{"variant": variant})
+			for _, image := range []string{"board.svg", "board.png"} {
+				if w := doRequest(t, h, http.MethodGet, "/api/games/"+room+"/"+image, hostToken, nil); w.Code != http.StatusUnprocessableEntity {
+					t.Fatalf("%s: %d, want 422", image, w.Code)
+				}
+			}
+		})
+	}
+}
@@ -2,37 +2,228 @@
 
 package main
 
-import ()
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
 
-//check winner ตรวจสอบผู้ชนะ
+//check winner ตรวจสอบผู้ชนะบนกระดาน 3x3
 //board string 9 ตัวแทนตำแหน่งบนกระดาน เช่น "XOX-O-X--"
-func CheckWinner(b string) (string) {
-	//ชนะแนวนอน 3 แถว แนวตั้ง 3 แถว แนวทแยง 2 แถว รวมทั้งหมด 8 แบบ
-	winLines := [][]int{
-		{0, 1, 2}, {3, 4, 5}, {6, 7, 8}, // แนวนอน
-		{0, 3, 6}, {1, 4, 7}, {2, 5, 8}, // แนวตั้ง
-		{0, 4, 8}, {2, 4, 6}, // แนวทแยง
+//คืน index ของ 3 ช่องที่เรียงกันชนะมาด้วย (nil ถ้ายังไม่มีใครชนะ) ให้ UI ไฮไลต์ได้
+//ชนะแนวนอน 3 แถว แนวตั้ง 3 แถว แนวทแยง 2 แถว รวมทั้งหมด 8 แบบ (จาก lineTable)
+func CheckWinner(b string) (string, []int) {
+	return CheckLine(b, []int{3, 3}, 3)
+}
+
+// กติกาการตัดสินเสมอของห้อง (games.draw_rule) เลือกตอนสร้างห้อง
+const (
+	DrawRuleFullBoard    = "full_board"    // เสมอเมื่อกระดานเต็มเท่านั้น (ค่าเริ่มต้น)
+	DrawRuleDeadPosition = "dead_position" // เสมอทันทีที่ไม่มีใครเรียงครบแถวได้อีกแล้ว
+	DrawRulePerfectPlay  = "perfect_play"  // เสมอทันทีที่ถ้าทั้งสองฝั่งเล่นดีที่สุดยังไงก็เสมอ
+)
+
+//EarlyDraw ตรวจว่ากระดานที่ยังไม่จบควรตัดสินเสมอเลยหรือไม่ตาม rule ของห้อง
+//คืนสาเหตุ (ReasonDeadPosition / ReasonForcedDraw) หรือ "" ถ้ายังเล่นต่อ
+//ใช้กับกระดานที่ CheckWinner ยังคืน "" เท่านั้น
+func EarlyDraw(b string, rule string) string {
+	switch rule {
+	case DrawRuleDeadPosition, DrawRulePerfectPlay:
+	default:
+		return ""
+	}
+	if isDeadPosition(b) {
+		return ReasonDeadPosition
+	}
+	if rule == DrawRulePerfectPlay && isForcedDraw([]byte(b)) {
+		return ReasonForcedDraw
+	}
+	return ""
+}
+
+//isForcedDraw ไม่ว่าฝั่งที่ถึงตาจะลงช่องไหน ถ้าหลังจากนั้นเล่นดีที่สุดก็เสมอแน่นอน
+//(แค่ดูว่า perfectPlayOutcome เป็น 0 ไม่พอ เพราะกระดาน 3x3 เสมอตั้งแต่ตาแรกถ้าเล่นไม่พลาด
+//จึงต้องไม่เหลือช่องที่ฝั่งที่ถึงตาลงแล้วแพ้ด้วย)
+func isForcedDraw(b []byte) bool {
+	toMove := sideToMove(string(b))
+	for i := range b {
+		if b[i] != '-' {
+			continue
+		}
+		b[i] = toMove
+		outcome := perfectPlayOutcome(b)
+		b[i] = '-'
+		if outcome != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+//sideToMove X เดินก่อนเสมอ ถ้าจำนวน X เท่ากับ O แปลว่าตา X
+func sideToMove(b string) byte {
+	xs, os := 0, 0
+	for i := 0; i < len(b); i++ {
+		switch b[i] {
+		case 'X':
+			xs++
+		case 'O':
+			os++
+		}
+	}
+	if xs == os {
+		return 'X'
+	}
+	return 'O'
+}
+
+//isDeadPosition ไม่มีแถวไหนที่ฝั่งใดยังเรียงครบได้ทันก่อนกระดานเต็ม
+//แถวที่ยังมีหวังต้องมีหมากฝั่งเดียว และช่องที่ขาดต้องไม่เกินจำนวนตาที่ฝั่งนั้นยังเหลือ
+func isDeadPosition(b string) bool {
+	empty := strings.Count(b, "-")
+	toMove := sideToMove(b)
+	movesLeft := map[byte]int{
+		'X': empty / 2,
+		'O': empty / 2,
+	}
+	movesLeft[toMove] = (empty + 1) / 2
+
+	for _, line := range lineTable([]int{3, 3}, 3) {
+		for _, sign := range []byte{'X', 'O'} {
+			missing, blocked := 0, false
+			for _, i := range line {
+				switch b[i] {
+				case '-':
+					missing++
+				case sign:
+				default:
+					blocked = true
+				}
+			}
+			if !blocked && missing <= movesLeft[sign] {
+				return false
+			}
+		}
 	}
+	return true
+}
 
-	// ตรวจสอบแต่ละแบบว่ามีผู้ชนะหรือไม่
-	for _, line := range winLines {
-		if b[line[0]] != '-' && b[line[0]] == b[line[1]] && b[line[1]] == b[line[2]] {
-			return string(b[line[0]]) // คืนค่า "X" หรือ "O"
+//perfectPlayOutcome ผลของกระดานถ้าทั้งสองฝั่งเล่นดีที่สุด (minimax)
+//+1 = ฝั่งที่ถึงตาเดินชนะ, 0 = เสมอ, -1 = ฝั่งที่ถึงตาเดินแพ้
+func perfectPlayOutcome(b []byte) int {
+	if winner, _ := CheckWinner(string(b)); winner != "" {
+		if winner == "DRAW" {
+			return 0
 		}
+		return -1 // คนที่เพิ่งเดินเรียงครบแล้ว
 	}
-	// ถ้าไม่มีผู้ชนะและกระดานเต็มแล้วถือว่าเสมอ
-	isFull := true
-	for i := 0; i < 9; i++ {
-		if b[i] == '-' {
-			isFull = false
-			break
+
+	toMove := sideToMove(string(b))
+	best := -1
+	for i := range b {
+		if b[i] != '-' {
+			continue
+		}
+		b[i] = toMove
+		score := -perfectPlayOutcome(b)
+		b[i] = '-'
+		if score > best {
+			best = score
+			if best == 1 {
+				break
+			}
 		}
 	}
+	return best
+}
+
+//lineTables - แถวที่ชนะได้ของกระดานแต่ละขนาด คำนวณครั้งแรกที่ใช้แล้วเก็บไว้ (key = ขนาดแต่ละแกน + จำนวนที่ต้องเรียง)
+var lineTables sync.Map
 
-	if isFull{
-		return "DRAW"
+//lineTable คืนทุกแถวที่เรียงกัน n ช่องบนกระดานกี่มิติก็ได้ dims = ขนาดของแต่ละแกน (แกนแรกเปลี่ยนเร็วสุด)
+//เช่น []int{width, height} -> index = y*width + x, []int{4, 4, 4} -> index = (z*4 + y)*4 + x (Qubic มี 76 แถว)
+func lineTable(dims []int, n int) [][]int {
+	key := fmt.Sprint(dims, n)
+	if lines, ok := lineTables.Load(key); ok {
+		return lines.([][]int)
 	}
 
-	return "" //เกมยังไม่จบ 
+	//ทิศทาง = ทุก vector ที่แต่ละแกนเป็น -1, 0 หรือ 1 (ไม่นับ vector ศูนย์)
+	//เก็บแค่ทิศที่แกนแรกที่ไม่เป็นศูนย์เป็นบวก เพราะทิศตรงข้ามได้แถวเดิม
+	var directions [][]int
+	combos := 1
+	for range dims {
+		combos *= 3
+	}
+	for code := 0; code < combos; code++ {
+		d := make([]int, len(dims))
+		first := 0
+		for axis, c := 0, code; axis < len(dims); axis, c = axis+1, c/3 {
+			d[axis] = c%3 - 1
+			if first == 0 {
+				first = d[axis]
+			}
+		}
+		if first == 1 {
+			directions = append(directions, d)
+		}
+	}
+
+	cells := 1
+	for _, size := range dims {
+		cells *= size
+	}
+	var lines [][]int
+	pos := make([]int, len(dims))
+	for start := 0; start < cells; start++ {
+		for axis, rest := 0, start; axis < len(dims); axis, rest = axis+1, rest/dims[axis] {
+			pos[axis] = rest % dims[axis]
+		}
+	next:
+		for _, d := range directions {
+			for axis := range dims {
+				if end := pos[axis] + d[axis]*(n-1); end < 0 || end >= dims[axis] {
+					continue next
+				}
+			}
+			line := make([]int, n)
+			for i := range line {
+				index, stride := 0, 1
+				for axis := range dims {
+					index += (pos[axis] + d[axis]*i) * stride
+					stride *= dims[axis]
+				}
+				line[i] = index
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	lineTables.Store(key, lines)
+	return lines
 }
 
+//CheckLine ตรวจผู้ชนะบนกระดานขนาด dims (2 มิติหรือ 3 มิติ) ที่ต้องเรียงกัน n ช่อง จากตารางแถวของ lineTable
+//คืนค่าแบบเดียวกับ CheckWinner
+func CheckLine(b string, dims []int, n int) (string, []int) {
+	for _, line := range lineTable(dims, n) {
+		mark := b[line[0]]
+		if mark == '-' {
+			continue
+		}
+		won := true
+		for _, index := range line[1:] {
+			if b[index] != mark {
+				won = false
+				break
+			}
+		}
+		if won {
+			return string(mark), append([]int(nil), line...)
+		}
+	}
+	if !strings.Contains(b, "-") {
+		return "DRAW", nil
+	}
+	return "", nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestLineTableCounts(t *testing.T) {
+	tests := []struct {
+		dims []int
+		n    int
+		want int
+	}{
+		{[]int{3, 3}, 3, 8},
+		{[]int{4, 4}, 4, 10},
+		{[]int{4, 4}, 3, 24},
+		{[]int{7, 6}, 4, 69}, // Connect Four
+		{[]int{5, 3}, 5, 3},  // เรียงได้แค่แนวนอน
+		{[]int{3, 3, 3}, 3, 49},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.dims, tt.n), func(t *testing.T) {
+			lines := lineTable(tt.dims, tt.n)
+			if len(lines) != tt.want {
+				t.Fatalf("%d lines, want %d", len(lines), tt.want)
+			}
+			cells := 1
+			for _, size := range tt.dims {
+				cells *= size
+			}
+			seen := map[string]bool{}
+			for _, line := range lines {
+				if len(line) != tt.n {
+					t.Fatalf("line %v has %d cells", line, len(line))
+				}
+				sorted := append([]int(nil), line...)
+				sort.Ints(sorted)
+				for i, cell := range sorted {
+					if cell < 0 || cell >= cells || (i > 0 && cell == sorted[i-1]) {
+						t.Fatalf("bad line %v", line)
+					}
+				}
+				key := fmt.Sprint(sorted)
+				if seen[key] {
+					t.Fatalf("line %v listed twice", line)
+				}
+				seen[key] = true
+			}
+		})
+	}
+}
+
+func TestLineTableStandardBoard(t *testing.T) {
+	want := map[string]bool{}
+	for _, line := range [][]int{
+		{0, 1, 2}, {3, 4, 5}, {6, 7, 8},
+		{0, 3, 6}, {1, 4, 7}, {2, 5, 8},
+		{0, 4, 8}, {2, 4, 6},
+	} {
+		want[fmt.Sprint(line)] = true
+	}
+	got := map[string]bool{}
+	for _, line := range lineTable([]int{3, 3}, 3) {
+		sorted := append([]int(nil), line...)
+		sort.Ints(sorted)
+		got[fmt.Sprint(sorted)] = true
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("lines = %v", got)
+	}
+}
+
+func TestCheckWinner(t *testing.T) {
+	tests := []struct {
+		board  string
+		winner string
+		line   []int
+	}{
+		{"---------", "", nil},
+		{"XXX-OO---", "X", []int{0, 1, 2}},
+		{"XO-XO-X--", "X", []int{0, 3, 6}},
+		{"XXO-OXO--", "O", []int{2, 4, 6}},
+		{"XOXXOOOXX", "DRAW", nil},
+		{"XOXXOOOX-", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.board, func(t *testing.T) {
+			winner, line := CheckWinner(tt.board)
+			if winner != tt.winner {
+				t.Fatalf("winner = %q, want %q", winner, tt.winner)
+			}
+			sort.Ints(line)
+			if !reflect.DeepEqual(line, tt.line) {
+				t.Fatalf("line = %v, want %v", line, tt.line)
+			}
+		})
+	}
+}
+
+func TestEarlyDraw(t *testing.T) {
+	tests := []struct {
+		name  string
+		board string
+		rule  string
+		want  string
+	}{
+		// X O X / X O O / O X - : ทุกแถวมีทั้ง X และ O แล้ว
+		{"dead position", "XOXXOOOX-", DrawRuleDeadPosition, ReasonDeadPosition},
+		{"dead position under perfect play", "XOXXOOOX-", DrawRulePerfectPlay, ReasonDeadPosition},
+		{"full board rule never ends early", "XOXXOOOX-", DrawRuleFullBoard, ""},
+		{"empty rule is full board", "XOXXOOOX-", "", ""},
+		// X O X / - O - / - X - : ยังมีแถวว่าง แต่ O ลงตรงไหนก็เสมอถ้าเล่นดีที่สุด
+		{"forced draw is not dead", "XOX-O--X-", DrawRuleDeadPosition, ""},
+		{"forced draw", "XOX-O--X-", DrawRulePerfectPlay, ReasonForcedDraw},
+		// O ลงขอบแล้วแพ้ได้ จึงยังตัดสินเสมอไม่ได้
+		{"side to move can still lose", "X--------", DrawRulePerfectPlay, ""},
+		// X ลงครบแถวได้ทันก่อนกระดานเต็ม
+		{"line still reachable", "XO-------", DrawRuleDeadPosition, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EarlyDraw(tt.board, tt.rule); got != tt.want {
+				t.Fatalf("EarlyDraw(%q, %q) = %q, want %q", tt.board, tt.rule, got, tt.want)
+			}
+		})
+	}
+}
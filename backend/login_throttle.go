@@ -0,0 +1,39 @@
+// backend/login_throttle.go
+package main
+
+import (
+	"time"
+)
+
+// นโยบายกันเดารหัสผ่าน (brute-force)
+// นับจำนวนครั้งที่ผิดแยกตาม username และตาม IP เกินโควต้าฟรีเมื่อไหร่จะโดนล็อก
+// ระยะเวลาล็อกเพิ่มเป็นเท่าตัวทุกครั้งที่ผิดซ้ำ (30s, 1m, 2m, ... สูงสุด 1 ชั่วโมง)
+// ตัวนับจริงอยู่ใน LoginThrottle ของ store แต่ละแบบ
+const (
+	loginFreeAttemptsPerUser = 5
+	loginFreeAttemptsPerIP   = 20
+	loginLockoutBase         = 30 * time.Second
+	loginLockoutMax          = time.Hour
+	loginFailureWindow       = 24 * time.Hour // ผิดครั้งล่าสุดเกินช่วงนี้ไปแล้ว เริ่มนับใหม่
+)
+
+// throttleClock - เวลาปัจจุบันที่ตัวนับใช้ (test เปลี่ยนเป็นนาฬิกาปลอมเพื่อเลื่อนเวลาได้โดยไม่ต้อง sleep)
+var throttleClock = time.Now
+
+func userThrottleKey(username string) string { return "user:" + username }
+func ipThrottleKey(ip string) string         { return "ip:" + ip }
+
+// lockoutDuration - ระยะเวลาล็อกเมื่อผิดไปแล้ว failures ครั้ง (0 = ยังไม่ล็อก)
+func lockoutDuration(failures, freeAttempts int) time.Duration {
+	if failures < freeAttempts {
+		return 0
+	}
+	lock := loginLockoutBase
+	for i := freeAttempts; i < failures && lock < loginLockoutMax; i++ {
+		lock *= 2
+	}
+	if lock > loginLockoutMax {
+		lock = loginLockoutMax
+	}
+	return lock
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeThrottleClock - แทน throttleClock ด้วยเวลาที่เลื่อนเองได้ (คืนค่าเดิมตอนจบ test)
+func fakeThrottleClock(t *testing.T) func(time.Duration) {
+	t.Helper()
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttleClock = func() time.Time { return now }
+	t.Cleanup(func() { throttleClock = time.Now })
+	return func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestLockoutDuration(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{loginFreeAttemptsPerUser - 1, 0},
+		{loginFreeAttemptsPerUser, 30 * time.Second},
+		{loginFreeAttemptsPerUser + 1, time.Minute},
+		{loginFreeAttemptsPerUser + 2, 2 * time.Minute},
+		{loginFreeAttemptsPerUser + 6, 32 * time.Minute},
+		{loginFreeAttemptsPerUser + 7, time.Hour},
+		{loginFreeAttemptsPerUser + 100, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := lockoutDuration(tt.failures, loginFreeAttemptsPerUser); got != tt.want {
+			t.Errorf("lockoutDuration(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// throttleStores - ตัวนับมีสองแบบ (MemoryStore / SQLLoginThrottle) ต้องทำงานเหมือนกัน
+var throttleStores = []struct {
+	name      string
+	newServer func(t *testing.T) (*Server, http.Handler)
+}{
+	{"memory", newTestServer},
+	{"sqlite", newSQLiteTestServer},
+}
+
+func login(t *testing.T, h http.Handler, username, password string) *httptest.ResponseRecorder {
+	t.Helper()
+	return doRequest(t, h, http.MethodPost, "/api/login", "", gin.H{"username": username, "password": password})
+}
+
+func assertLockedOut(t *testing.T, w *httptest.ResponseRecorder, retryAfter string) {
+	t.Helper()
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != retryAfter {
+		t.Fatalf("got %d (Retry-After %q), want 429 (Retry-After %s): %s", w.Code, w.Header().Get("Retry-After"), retryAfter, w.Body.String())
+	}
+}
+
+func TestLoginThrottlePerUsername(t *testing.T) {
+	for _, st := range throttleStores {
+		t.Run(st.name, func(t *testing.T) {
+			advance := fakeThrottleClock(t)
+			s, h := st.newServer(t)
+			userID, _ := newTestUser(t, s, "alice")
+			setTestPassword(t, s, userID, "secret123")
+
+			for i := 0; i < loginFreeAttemptsPerUser; i++ {
+				if w := login(t, h, "alice", "wrong-pass"); w.Code != http.StatusUnauthorized {
+					t.Fatalf("failure %d: %d, want 401", i+1, w.Code)
+				}
+			}
+			// ติดล็อก 30 วินาที รหัสถูกก็เข้าไม่ได้ และการ retry ระหว่างล็อกไม่ยืดเวลา
+			assertLockedOut(t, login(t, h, "alice", "secret123"), "30")
+			advance(10 * time.Second)
+			assertLockedOut(t, login(t, h, "alice", "secret123"), "20")
+
+			// หมดล็อกแล้วผิดอีกครั้ง -> ล็อกเพิ่มเป็นเท่าตัว
+			advance(20 * time.Second)
+			if w := login(t, h, "alice", "wrong-pass"); w.Code != http.StatusUnauthorized {
+				t.Fatalf("after lockout expired: %d, want 401", w.Code)
+			}
+			assertLockedOut(t, login(t, h, "alice", "secret123"), "60")
+
+			// login สำเร็จล้างตัวนับของ username
+			advance(time.Minute)
+			if w := login(t, h, "alice", "secret123"); w.Code != http.StatusOK {
+				t.Fatalf("login after lockout: %d %s", w.Code, w.Body.String())
+			}
+			for i := 0; i < loginFreeAttemptsPerUser-1; i++ {
+				if w := login(t, h, "alice", "wrong-pass"); w.Code != http.StatusUnauthorized {
+					t.Fatalf("failure %d after reset: %d, want 401", i+1, w.Code)
+				}
+			}
+			if w := login(t, h, "alice", "secret123"); w.Code != http.StatusOK {
+				t.Fatalf("counter was not reset by the successful login: %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestLoginThrottleCapsAtOneHour(t *testing.T) {
+	for _, st := range throttleStores {
+		t.Run(st.name, func(t *testing.T) {
+			advance := fakeThrottleClock(t)
+			_, h := st.newServer(t)
+
+			want := loginLockoutBase
+			for i := 0; i < loginFreeAttemptsPerUser-1; i++ {
+				login(t, h, "alice", "wrong-pass")
+			}
+			for i := 0; i < 10; i++ {
+				if w := login(t, h, "alice", "wrong-pass"); w.Code != http.StatusUnauthorized {
+					t.Fatalf("failure after lockout %d: %d, want 401", i, w.Code)
+				}
+				assertLockedOut(t, login(t, h, "alice", "wrong-pass"), fmt.Sprint(int(want.Seconds())))
+				advance(want)
+				if want *= 2; want > loginLockoutMax {
+					want = loginLockoutMax
+				}
+			}
+		})
+	}
+}
+
+func TestLoginThrottlePerIP(t *testing.T) {
+	for _, st := range throttleStores {
+		t.Run(st.name, func(t *testing.T) {
+			advance := fakeThrottleClock(t)
+			s, h := st.newServer(t)
+			userID, _ := newTestUser(t, s, "alice")
+			setTestPassword(t, s, userID, "secret123")
+
+			// เดาคนละ username จาก IP เดียวกัน -> ตัวนับของ username ไม่ถึงโควต้า แต่ของ IP ถึง
+			for i := 0; i < loginFreeAttemptsPerIP; i++ {
+				if w := login(t, h, fmt.Sprintf("user%d", i), "wrong-pass"); w.Code != http.StatusUnauthorized {
+					t.Fatalf("failure %d: %d, want 401", i+1, w.Code)
+				}
+			}
+			assertLockedOut(t, login(t, h, "alice", "secret123"), "30")
+
+			// login สำเร็จไม่ล้างตัวนับของ IP (ผิดอีกครั้งก็ล็อกต่อทันที)
+			advance(30 * time.Second)
+			if w := login(t, h, "alice", "secret123"); w.Code != http.StatusOK {
+				t.Fatalf("login after IP lockout: %d %s", w.Code, w.Body.String())
+			}
+			login(t, h, "nobody", "wrong-pass")
+			assertLockedOut(t, login(t, h, "alice", "secret123"), "60")
+		})
+	}
+}
@@ -1,64 +1,65 @@
 package main
 
 import (
-	"net/http"
+	"log"
 	"os"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
+	"time"
 )
 
 func main() {
 
-	ConnectDB()
-
-	defer DB.Close()
-
-	r := gin.Default()
+	// go run . migrate status|up|down [n]
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		db := openDB()
+		defer db.Close()
+		if err := RunMigrateCommand(db, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true
-	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	// go run . events check|rebuild|backfill [room_code...]
+	if len(os.Args) > 1 && os.Args[1] == "events" {
+		db := openDB()
+		defer db.Close()
+		if err := RunEventsCommand(db, os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-	r.Use(cors.New(config))
+	// STORAGE=memory -> ไม่ต้องมี Postgres (ข้อมูลหายเมื่อปิด server) ใช้สำหรับ dev/ทดสอบ
+	var server *Server
+	if os.Getenv("STORAGE") == "memory" {
+		log.Println("Using in-memory storage: data is lost when the server stops")
+		server = NewMemoryServer()
+	} else {
+		db := ConnectDB()
 
-	r.GET("/ping", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{
-			"message": "pong",
-			"status":  "server is running",
-			"db":      "connected",
-		})
-	})
+		defer db.Close()
 
-	api := r.Group("/api")
-	{
-		// --- ระบบ Auth ---
-		api.POST("/register", RegisterHandler)
-		api.POST("/login", LoginHandler)
+		server = NewSQLServer(db)
+	}
 
-		// --- ระบบเกม ---
-		protected := api.Group("/games")
-		protected.Use(AuthMiddleware())
-		{
-			protected.POST("", CreateGameHandler)
-			protected.POST("/join", JoinGameHandler)
-			protected.POST("/move", MakeMoveHandler)
+	// SSO (OpenID Connect) เปิดใช้เมื่อมีการตั้งค่า OIDC_ISSUER / OIDC_CLIENT_ID / OIDC_REDIRECT_URL
+	if oidcConfig := LoadOIDCConfig(); oidcConfig.Enabled() {
+		server.OIDC = NewOIDCProvider(oidcConfig)
+	}
 
-			protected.GET("/:id", GetGameHandler)            // ดูสถานะเกม
-			protected.GET("/:id/moves", GetGameMovesHandler) // ดูประวัติ
+	// ตั้ง admin คนแรก (ADMIN_USERNAME)
+	server.PromoteBootstrapAdmin()
 
-			protected.DELETE("/:id", CancelGameHandler) //ทำลายห้อง
+	// เก็บกวาด guest ที่ไม่ได้ใช้งานแล้ว ทุกๆ ชั่วโมง
+	server.StartGuestCleanup(time.Hour)
 
-			protected.POST("/:id/rematch", RematchHandler)
-			protected.POST("/:id/leave", LeaveGameHandler)
-			protected.GET("/me/active", GetMyActiveGameHandler)
-		}
-	}
+	r := server.Router()
+	r.Run(":" + listenPort())
+}
 
+func listenPort() string {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
-	r.Run(":" + port)
+	return port
 }
@@ -3,15 +3,13 @@ package main
 
 import (
 	"net/http"
-	"os"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 // AuthMiddleware - ตรวจสอบ JWT Token
-func AuthMiddleware() gin.HandlerFunc {
+func (s *Server) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -30,26 +28,53 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		tokenString := parts[1]
 
-		//ดึง Key จาก Environment
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "super_secret_tictactoe_key_2026"
+		//แกะ Token และตรวจสอบความถูกต้อง
+		claims, err := ParseToken(tokenString)
+		if err != nil || claims == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+			c.Abort()
+			return
 		}
 
-		//แกะ Token และตรวจสอบความถูกต้อง
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		})
+		// token ที่ยังไม่ผ่าน 2FA ห้ามใช้เรียก API
+		if claims.TwoFactorPending {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Two-factor authentication required"})
+			c.Abort()
+			return
+		}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		// เช็คว่า token ยังไม่ถูก revoke (เปลี่ยนรหัสผ่าน/ลบบัญชี จะเพิ่ม token_version) และเจ้าของไม่ได้โดนแบน
+		state, err := s.Users.SessionState(claims.UserID)
+		if err != nil || state.Deleted || state.TokenVersion != claims.TokenVersion {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session has been revoked. Please login again."})
+			c.Abort()
+			return
+		}
+		if state.Banned {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Your account has been banned"})
 			c.Abort()
 			return
 		}
 
 		// userID ที่แกะได้ไปฝากไว้ใน Context
 		c.Set("userID", claims.UserID)
+		c.Set("isGuest", claims.IsGuest)
+		c.Set("role", claims.Role)
 		c.Next() // อนุญาตให้ผ่านเข้าสู่ API เกมได้
 	}
 }
+
+// RequireRole - ใช้ต่อจาก AuthMiddleware เพื่อจำกัด API ให้เฉพาะ role ที่ระบุ
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to perform this action"})
+		c.Abort()
+	}
+}
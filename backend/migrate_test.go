@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newSQLiteTestDB - SQLite ไฟล์ชั่วคราวที่ยังไม่ได้ migrate
+func newSQLiteTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	t.Setenv("DB_DRIVER", DriverSQLite)
+	db, err := sql.Open(sqliteDriverName, sqliteDSN(filepath.Join(t.TempDir(), "migrate.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func migrateCommand(t *testing.T, db *sql.DB, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := runMigrateCommand(&out, db, args); err != nil {
+		t.Fatalf("migrate %s: %v", strings.Join(args, " "), err)
+	}
+	return out.String()
+}
+
+// assertStatusMatchesTable - ทุกบรรทัดของ `migrate status` ต้องตรงกับแถวใน schema_migrations และ applied เฉพาะ version <= latest
+func assertStatusMatchesTable(t *testing.T, db *sql.DB, migrations []Migration, latest int) {
+	t.Helper()
+	// status สร้าง schema_migrations ให้ถ้ายังไม่มี จึงต้องเรียกก่อน query
+	lines := strings.Split(strings.TrimSpace(migrateCommand(t, db, "status")), "\n")
+	rows, err := db.Query(`SELECT version, name FROM schema_migrations`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	recorded := map[int]string{}
+	for rows.Next() {
+		var version int
+		var name string
+		if err := rows.Scan(&version, &name); err != nil {
+			t.Fatal(err)
+		}
+		recorded[version] = name
+	}
+
+	if len(lines) != len(migrations) {
+		t.Fatalf("status printed %d lines, want %d", len(lines), len(migrations))
+	}
+	for i, line := range lines {
+		m := migrations[i]
+		if !strings.HasPrefix(line, fmt.Sprintf("%04d_%s ", m.Version, m.Name)) {
+			t.Fatalf("status line %d = %q, want migration %04d_%s", i, line, m.Version, m.Name)
+		}
+		version, _ := strconv.Atoi(line[:4])
+		name, inTable := recorded[version]
+		applied := strings.Contains(line, " applied ")
+		if applied != inTable || (inTable && name != m.Name) {
+			t.Fatalf("status line %q disagrees with schema_migrations (recorded: %v %q)", line, inTable, name)
+		}
+		if applied != (m.Version <= latest) {
+			t.Fatalf("status line %q, want applied = %v", line, m.Version <= latest)
+		}
+	}
+}
+
+func TestMigrateRoundTripSQLite(t *testing.T) {
+	db := newSQLiteTestDB(t)
+	migrations, err := LoadMigrations(DriverSQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := migrations[len(migrations)-1]
+	assertStatusMatchesTable(t, db, migrations, 0)
+
+	out := migrateCommand(t, db, "up")
+	if got := strings.Count(out, "applied  "); got != len(migrations) {
+		t.Fatalf("up applied %d migrations, want %d:\n%s", got, len(migrations), out)
+	}
+	assertStatusMatchesTable(t, db, migrations, last.Version)
+
+	out = migrateCommand(t, db, "down")
+	if want := fmt.Sprintf("reverted %04d_%s\n", last.Version, last.Name); out != want {
+		t.Fatalf("down = %q, want %q", out, want)
+	}
+	assertStatusMatchesTable(t, db, migrations, migrations[len(migrations)-2].Version)
+
+	out = migrateCommand(t, db, "up")
+	if want := fmt.Sprintf("applied  %04d_%s\n", last.Version, last.Name); out != want {
+		t.Fatalf("up after down = %q, want %q", out, want)
+	}
+	assertStatusMatchesTable(t, db, migrations, last.Version)
+	if out := migrateCommand(t, db, "up"); out != "database is up to date\n" {
+		t.Fatalf("second up = %q", out)
+	}
+
+	// down ทุกตัวแล้ว up ใหม่ได้ครบ (ไฟล์ down ทุกไฟล์ต้องย้อนได้จริง)
+	migrateCommand(t, db, "down", strconv.Itoa(len(migrations)))
+	assertStatusMatchesTable(t, db, migrations, 0)
+	migrateCommand(t, db, "up")
+	assertStatusMatchesTable(t, db, migrations, last.Version)
+}
+
+func TestRunMigrateCommandRejectsBadArgs(t *testing.T) {
+	db := newSQLiteTestDB(t)
+	tests := [][]string{
+		{"sideways"},
+		{"down", "0"},
+		{"down", "-1"},
+		{"down", "two"},
+	}
+	for _, args := range tests {
+		var out bytes.Buffer
+		if err := runMigrateCommand(&out, db, args); err == nil {
+			t.Errorf("migrate %s: expected an error", strings.Join(args, " "))
+		}
+		if out.Len() != 0 {
+			t.Errorf("migrate %s printed %q", strings.Join(args, " "), out.String())
+		}
+	}
+	if _, err := LoadMigrations("oracle"); err == nil {
+		t.Error("LoadMigrations accepted an unknown driver")
+	}
+}
@@ -1,6 +1,6 @@
--- backend/init.sql
+-- ตารางหลักชุดแรกของระบบ (users, games, moves)
+-- ใช้ IF NOT EXISTS ทุกที่ เพราะ Database เก่าที่สร้างจาก init.sql มีตารางพวกนี้อยู่แล้ว
 
--- 1. ตาราง Users
 CREATE TABLE IF NOT EXISTS users (
     id SERIAL PRIMARY KEY,
     username VARCHAR(50) UNIQUE NOT NULL,
@@ -8,7 +8,6 @@ CREATE TABLE IF NOT EXISTS users (
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- 2. ตาราง Games
 CREATE TABLE IF NOT EXISTS games (
     id SERIAL PRIMARY KEY,
     room_code VARCHAR(6) UNIQUE NOT NULL,
@@ -16,15 +15,12 @@ CREATE TABLE IF NOT EXISTS games (
     player2_id INT REFERENCES users(id),
     current_turn_id INT REFERENCES users(id),
     board VARCHAR(9) DEFAULT '---------', -- เก็บเป็น 'XOXO----'
-    status VARCHAR(20) DEFAULT 'WAITING', -- WAITING, IN_PROGRESS, FINISHED, DRAW
-    next_room_code VARCHAR(6),  
-    rematch_p1 BOOLEAN NOT NULL DEFAULT FALSE,
-    rematch_p2 BOOLEAN NOT NULL DEFAULT FALSE,
+    status VARCHAR(20) DEFAULT 'WAITING', -- WAITING, IN_PROGRESS, FINISHED, DRAW, ABANDONED, VOIDED
     winner_id INT REFERENCES users(id),
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
--- 3. ตาราง Moves (สำคัญมากสำหรับการทำ Replay และกัน Race Condition)
+-- ประวัติการเดิน (Replay และกัน Race Condition)
 CREATE TABLE IF NOT EXISTS moves (
     id SERIAL PRIMARY KEY,
     game_id INT REFERENCES games(id) ON DELETE CASCADE,
@@ -36,4 +32,4 @@ CREATE TABLE IF NOT EXISTS moves (
 
     -- ห้ามลงซ้ำช่องเดิมในเกมเดียวกัน (Database Level Protection)
     CONSTRAINT unique_move_per_cell UNIQUE (game_id, x, y)
-);
\ No newline at end of file
+);
@@ -0,0 +1,3 @@
+ALTER TABLE games DROP COLUMN IF EXISTS rematch_p2;
+ALTER TABLE games DROP COLUMN IF EXISTS rematch_p1;
+ALTER TABLE games DROP COLUMN IF EXISTS next_room_code;
@@ -0,0 +1,4 @@
+-- ระบบ Rematch: ห้องใหม่ที่ลิงก์ต่อกัน และการยินยอมของผู้เล่นแต่ละฝั่ง
+ALTER TABLE games ADD COLUMN IF NOT EXISTS next_room_code VARCHAR(6);
+ALTER TABLE games ADD COLUMN IF NOT EXISTS rematch_p1 BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS rematch_p2 BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,4 @@
+-- token_version: เพิ่มเมื่อเปลี่ยนรหัสผ่าน/ชื่อ เพื่อ revoke token เก่า
+-- deleted_at: ลบบัญชีแบบ anonymize (เก็บแถวไว้เพื่อไม่ให้ FK ของ games/moves พัง)
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
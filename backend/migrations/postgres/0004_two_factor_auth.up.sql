@@ -0,0 +1,14 @@
+-- TOTP 2FA: secret มีค่าตั้งแต่ตอน setup แต่ยังไม่ active จนกว่าจะ confirm
+-- totp_last_step: step ล่าสุดที่ใช้ไปแล้ว กันเอารหัสเดิมมาใช้ซ้ำ
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step BIGINT NOT NULL DEFAULT 0;
+
+-- Recovery Codes (ใช้ได้ครั้งเดียว เก็บเป็น hash)
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS oidc_login_states;
+ALTER TABLE users DROP COLUMN IF EXISTS oidc_subject;
+ALTER TABLE users DROP COLUMN IF EXISTS oidc_issuer;
+-- บัญชี SSO อย่างเดียวไม่มีรหัสผ่าน จะ login ไม่ได้อยู่แล้ว ใส่ค่าที่ bcrypt ไม่มีทางตรงแทน NULL
+UPDATE users SET password_hash = '!' WHERE password_hash IS NULL;
+ALTER TABLE users ALTER COLUMN password_hash SET NOT NULL;
@@ -0,0 +1,15 @@
+-- บัญชี SSO อย่างเดียวไม่มีรหัสผ่าน (password_hash = NULL)
+ALTER TABLE users ALTER COLUMN password_hash DROP NOT NULL;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_issuer VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_subject VARCHAR(255);
+
+-- หนึ่งตัวตนบน provider ผูกได้บัญชีเดียว (ชื่อ unique_oidc_identity ถูกเช็คใน SQLUserStore.CreateOIDCUser)
+CREATE UNIQUE INDEX IF NOT EXISTS unique_oidc_identity ON users (oidc_issuer, oidc_subject);
+
+-- state/nonce/PKCE verifier ระหว่างรอ provider redirect กลับมา (ใช้ได้ครั้งเดียว)
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    state VARCHAR(64) PRIMARY KEY,
+    nonce VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,16 @@
+-- นับการ login ผิดต่อ username / IP และเวลาที่ติดล็อก (อยู่รอดแม้ server restart)
+CREATE TABLE IF NOT EXISTS login_throttles (
+    throttle_key VARCHAR(100) PRIMARY KEY, -- 'user:<username>' หรือ 'ip:<address>'
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP,
+    locked_until TIMESTAMP
+);
+
+-- Audit Log ของการ login ที่ล้มเหลว
+CREATE TABLE IF NOT EXISTS login_failures (
+    id SERIAL PRIMARY KEY,
+    username VARCHAR(50),
+    ip_address VARCHAR(64),
+    reason VARCHAR(30) NOT NULL, -- invalid_credentials, invalid_2fa_code, invalid_recovery_code, locked_out
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- ผู้เล่นชั่วคราว (POST /api/guest) อัปเกรดเป็นบัญชีจริงได้ภายหลัง
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_guest BOOLEAN NOT NULL DEFAULT FALSE;
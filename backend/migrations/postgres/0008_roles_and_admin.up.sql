@@ -0,0 +1,23 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'player'
+    CHECK (role IN ('player', 'moderator', 'admin'));
+
+-- ประวัติการแบน (มีผลเมื่อ lifted_at เป็น NULL)
+CREATE TABLE IF NOT EXISTS user_bans (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES users(id),
+    reason TEXT NOT NULL,
+    banned_by INT REFERENCES users(id),
+    lifted_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ทุก action ของ moderator/admin
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id SERIAL PRIMARY KEY,
+    actor_id INT NOT NULL REFERENCES users(id),
+    action VARCHAR(50) NOT NULL, -- end_game, adjudicate_game, void_game, ban_user, set_role, ...
+    target_type VARCHAR(20) NOT NULL, -- user, game
+    target_id INT NOT NULL,
+    details TEXT, -- JSON
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,5 @@
+-- การพักการเล่นไม่มีความหมายใน schema เก่า (ทุกแถวจะกลายเป็น BAN) จึงลบทิ้ง
+DELETE FROM user_bans WHERE kind = 'SUSPENSION';
+ALTER TABLE user_bans DROP COLUMN IF EXISTS lifted_by;
+ALTER TABLE user_bans DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE user_bans DROP COLUMN IF EXISTS kind;
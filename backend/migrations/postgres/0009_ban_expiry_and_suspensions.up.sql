@@ -0,0 +1,5 @@
+-- BAN = ห้ามใช้งาน, SUSPENSION = ห้ามเข้าเกม / expires_at NULL = ถาวร
+ALTER TABLE user_bans ADD COLUMN IF NOT EXISTS kind VARCHAR(20) NOT NULL DEFAULT 'BAN'
+    CHECK (kind IN ('BAN', 'SUSPENSION'));
+ALTER TABLE user_bans ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+ALTER TABLE user_bans ADD COLUMN IF NOT EXISTS lifted_by INT REFERENCES users(id);
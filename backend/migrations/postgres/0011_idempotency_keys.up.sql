@@ -0,0 +1,11 @@
+-- ผลลัพธ์ของ request ที่มี Idempotency-Key (ต่อผู้ใช้) เก็บไว้ตอบซ้ำเมื่อ client retry
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idem_key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL, -- sha256 ของ method + path + body
+    response_status INT NOT NULL DEFAULT 0, -- 0 = request แรกยังทำงานอยู่
+    response_body TEXT,
+    created_at TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (user_id, idem_key)
+);
@@ -0,0 +1,13 @@
+-- ประวัติทุกอย่างที่เกิดกับห้องเกม (created, joined, moved, resigned, ...) เรียงตาม seq
+-- games.board / status / winner_id ถือเป็น projection ที่สร้างใหม่จาก event เหล่านี้ได้ (go run . events rebuild)
+CREATE TABLE IF NOT EXISTS game_events (
+    id SERIAL PRIMARY KEY,
+    game_id INT NOT NULL REFERENCES games(id) ON DELETE CASCADE,
+    seq INT NOT NULL,
+    event_type VARCHAR(30) NOT NULL,
+    player_id INT REFERENCES users(id),
+    data TEXT NOT NULL DEFAULT '{}', -- JSON
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_event_seq UNIQUE (game_id, seq)
+);
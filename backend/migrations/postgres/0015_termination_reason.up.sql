@@ -0,0 +1,11 @@
+-- เกมจบเพราะอะไร: line, draw, resignation, abandonment, timeout, adjudication (NULL = ยังไม่จบ)
+ALTER TABLE games ADD COLUMN IF NOT EXISTS termination_reason VARCHAR(20);
+
+-- เกมเก่าเดาจาก status ได้แค่คร่าวๆ ค่าที่ตรงกับ event จริงได้จาก `go run . events rebuild`
+UPDATE games SET termination_reason = CASE
+        WHEN status = 'FINISHED' THEN 'line'
+        WHEN status = 'DRAW' THEN 'draw'
+        WHEN status = 'ABANDONED' AND winner_id IS NOT NULL THEN 'resignation'
+        WHEN status = 'ABANDONED' THEN 'abandonment'
+    END
+WHERE termination_reason IS NULL AND status IN ('FINISHED', 'DRAW', 'ABANDONED');
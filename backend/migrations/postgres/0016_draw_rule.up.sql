@@ -0,0 +1,2 @@
+-- กติกาการตัดสินเสมอของห้อง: full_board (กระดานเต็ม), dead_position, perfect_play (ดู backend/logic.go)
+ALTER TABLE games ADD COLUMN IF NOT EXISTS draw_rule VARCHAR(20) NOT NULL DEFAULT 'full_board';
@@ -0,0 +1,9 @@
+-- ห้องที่ไม่ใช่ standard ใช้กับ schema เก่าไม่ได้ (กระดานยาวเกิน 9 ช่อง) จึงลบทิ้ง
+DELETE FROM games WHERE variant <> 'standard';
+ALTER TABLE moves DROP CONSTRAINT IF EXISTS moves_y_check;
+ALTER TABLE moves DROP CONSTRAINT IF EXISTS moves_x_check;
+ALTER TABLE moves ADD CONSTRAINT moves_x_check CHECK (x >= 0 AND x <= 2);
+ALTER TABLE moves ADD CONSTRAINT moves_y_check CHECK (y >= 0 AND y <= 2);
+ALTER TABLE games ALTER COLUMN board TYPE VARCHAR(9);
+ALTER TABLE games DROP COLUMN IF EXISTS next_board;
+ALTER TABLE games DROP COLUMN IF EXISTS variant;
@@ -0,0 +1,11 @@
+-- variant ของห้อง (standard, ultimate ดู backend/variant.go) ultimate มีกระดาน 9x9 = 81 ช่อง
+-- จึงต้องขยาย board และเลิกจำกัด x, y ของ moves ไว้ที่ 0-2 (ขอบเขตของแต่ละ variant ตรวจใน server)
+ALTER TABLE games ADD COLUMN IF NOT EXISTS variant VARCHAR(20) NOT NULL DEFAULT 'standard';
+-- ultimate: กระดานย่อย (0-8) ที่ตาต่อไปต้องลง (NULL = ลงได้ทุกกระดานย่อยที่ยังไม่จบ)
+ALTER TABLE games ADD COLUMN IF NOT EXISTS next_board INT;
+ALTER TABLE games ALTER COLUMN board TYPE TEXT;
+
+ALTER TABLE moves DROP CONSTRAINT IF EXISTS moves_x_check;
+ALTER TABLE moves DROP CONSTRAINT IF EXISTS moves_y_check;
+ALTER TABLE moves ADD CONSTRAINT moves_x_check CHECK (x >= 0);
+ALTER TABLE moves ADD CONSTRAINT moves_y_check CHECK (y >= 0);
@@ -0,0 +1,5 @@
+-- ขนาดกระดานและจำนวนที่ต้องเรียงถึงชนะของแต่ละห้อง (gravity ตั้งเองได้ตอนสร้างห้อง variant อื่นขนาดตายตัว)
+ALTER TABLE games ADD COLUMN IF NOT EXISTS width INT NOT NULL DEFAULT 3;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS height INT NOT NULL DEFAULT 3;
+ALTER TABLE games ADD COLUMN IF NOT EXISTS win_length INT NOT NULL DEFAULT 3;
+UPDATE games SET width = 9, height = 9 WHERE variant = 'ultimate';
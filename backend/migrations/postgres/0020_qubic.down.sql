@@ -0,0 +1,7 @@
+-- ห้อง 3 มิติใช้กับ schema เก่าไม่ได้ (ช่องซ้ำกันเมื่อไม่มี z) จึงลบทิ้ง
+DELETE FROM games WHERE depth > 1;
+ALTER TABLE moves DROP CONSTRAINT IF EXISTS unique_move_per_cell;
+ALTER TABLE moves ADD CONSTRAINT unique_move_per_cell UNIQUE (game_id, x, y);
+ALTER TABLE moves DROP CONSTRAINT IF EXISTS moves_z_check;
+ALTER TABLE moves DROP COLUMN IF EXISTS z;
+ALTER TABLE games DROP COLUMN IF EXISTS depth;
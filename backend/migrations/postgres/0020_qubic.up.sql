@@ -0,0 +1,8 @@
+-- จำนวนชั้นของกระดาน (1 = 2 มิติ, qubic = 4x4x4 ดู backend/qubic.go)
+ALTER TABLE games ADD COLUMN IF NOT EXISTS depth INT NOT NULL DEFAULT 1;
+
+-- ชั้นของการเดินแต่ละครั้ง ช่องเดียวกันในคนละชั้นจึงไม่ซ้ำกัน
+ALTER TABLE moves ADD COLUMN IF NOT EXISTS z INT NOT NULL DEFAULT 0;
+ALTER TABLE moves ADD CONSTRAINT moves_z_check CHECK (z >= 0);
+ALTER TABLE moves DROP CONSTRAINT IF EXISTS unique_move_per_cell;
+ALTER TABLE moves ADD CONSTRAINT unique_move_per_cell UNIQUE (game_id, x, y, z);
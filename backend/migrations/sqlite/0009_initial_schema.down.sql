@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS admin_audit_log;
+DROP TABLE IF EXISTS user_bans;
+DROP TABLE IF EXISTS login_failures;
+DROP TABLE IF EXISTS login_throttles;
+DROP TABLE IF EXISTS oidc_login_states;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS moves;
+DROP TABLE IF EXISTS games;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,99 @@
+-- Schema ของ SQLite (DB_DRIVER=sqlite) เทียบเท่า migrations/postgres/0001-0009 รวมกัน
+-- เลข version ตรงกับฝั่ง Postgres migration ถัดไปต้องเพิ่มทั้งสองฝั่งด้วยเลขเดียวกัน
+
+CREATE TABLE IF NOT EXISTS users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(50) UNIQUE NOT NULL,
+    password_hash VARCHAR(255), -- NULL = บัญชี SSO อย่างเดียว / guest
+    token_version INT NOT NULL DEFAULT 0,
+    deleted_at TIMESTAMP,
+    totp_secret VARCHAR(64),
+    totp_enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    totp_last_step BIGINT NOT NULL DEFAULT 0,
+    oidc_issuer VARCHAR(255),
+    oidc_subject VARCHAR(255),
+    is_guest BOOLEAN NOT NULL DEFAULT FALSE,
+    role VARCHAR(20) NOT NULL DEFAULT 'player' CHECK (role IN ('player', 'moderator', 'admin')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS unique_oidc_identity ON users (oidc_issuer, oidc_subject);
+
+CREATE TABLE IF NOT EXISTS games (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_code VARCHAR(6) UNIQUE NOT NULL,
+    player1_id INT REFERENCES users(id),
+    player2_id INT REFERENCES users(id),
+    current_turn_id INT REFERENCES users(id),
+    board VARCHAR(9) DEFAULT '---------',
+    status VARCHAR(20) DEFAULT 'WAITING',
+    winner_id INT REFERENCES users(id),
+    next_room_code VARCHAR(6),
+    rematch_p1 BOOLEAN NOT NULL DEFAULT FALSE,
+    rematch_p2 BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS moves (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id INT REFERENCES games(id) ON DELETE CASCADE,
+    player_id INT REFERENCES users(id),
+    x INT NOT NULL CHECK (x >= 0 AND x <= 2),
+    y INT NOT NULL CHECK (y >= 0 AND y <= 2),
+    move_order INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_move_per_cell UNIQUE (game_id, x, y)
+);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash VARCHAR(64) NOT NULL,
+    used_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    state VARCHAR(64) PRIMARY KEY,
+    nonce VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS login_throttles (
+    throttle_key VARCHAR(100) PRIMARY KEY,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP,
+    locked_until TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS login_failures (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username VARCHAR(50),
+    ip_address VARCHAR(64),
+    reason VARCHAR(30) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS user_bans (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INT NOT NULL REFERENCES users(id),
+    kind VARCHAR(20) NOT NULL DEFAULT 'BAN' CHECK (kind IN ('BAN', 'SUSPENSION')),
+    reason TEXT NOT NULL,
+    banned_by INT REFERENCES users(id),
+    expires_at TIMESTAMP,
+    lifted_at TIMESTAMP,
+    lifted_by INT REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id INT NOT NULL REFERENCES users(id),
+    action VARCHAR(50) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,
+    target_id INT NOT NULL,
+    details TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- เพิ่มทุกครั้งที่ state ของเกมเปลี่ยน ใช้เป็น ETag / expected_version ของ optimistic concurrency
+ALTER TABLE games ADD COLUMN version INT NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+-- เกมที่นำเข้าจาก game record (POST /api/games/import) ผู้เล่นเป็นแค่ชื่อ ไม่ผูกกับ users
+CREATE TABLE IF NOT EXISTS archived_games (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    room_code VARCHAR(6), -- ห้องเดิมตาม record (ถ้ามี)
+    variant VARCHAR(20) NOT NULL DEFAULT 'standard',
+    player_x VARCHAR(50) NOT NULL,
+    player_o VARCHAR(50) NOT NULL,
+    result VARCHAR(7) NOT NULL, -- 1-0, 0-1, 1/2-1/2
+    termination VARCHAR(20) NOT NULL,
+    board VARCHAR(9) NOT NULL,
+    moves TEXT NOT NULL, -- ช่องแบบ algebraic คั่นด้วยช่องว่าง เช่น 'a3 b2 c1'
+    started_at TIMESTAMP,
+    ended_at TIMESTAMP,
+    imported_by INT REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
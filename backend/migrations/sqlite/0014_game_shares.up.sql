@@ -0,0 +1,7 @@
+-- ลิงก์สาธารณะของเกมที่จบแล้ว (ผู้เล่นเปิดเองผ่าน POST /api/games/:id/share) หนึ่งห้องมีได้ลิงก์เดียว
+CREATE TABLE IF NOT EXISTS game_shares (
+    game_id INT PRIMARY KEY REFERENCES games(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    created_by INT REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
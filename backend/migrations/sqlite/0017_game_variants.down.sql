@@ -0,0 +1,21 @@
+-- ห้องที่ไม่ใช่ standard ใช้กับ schema เก่าไม่ได้ (กระดานเกิน 3x3) จึงลบทิ้ง
+DELETE FROM games WHERE variant <> 'standard';
+
+CREATE TABLE moves_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id INT REFERENCES games(id) ON DELETE CASCADE,
+    player_id INT REFERENCES users(id),
+    x INT NOT NULL CHECK (x >= 0 AND x <= 2),
+    y INT NOT NULL CHECK (y >= 0 AND y <= 2),
+    move_order INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_move_per_cell UNIQUE (game_id, x, y)
+);
+INSERT INTO moves_old (id, game_id, player_id, x, y, move_order, created_at)
+    SELECT id, game_id, player_id, x, y, move_order, created_at FROM moves;
+DROP TABLE moves;
+ALTER TABLE moves_old RENAME TO moves;
+
+ALTER TABLE games DROP COLUMN next_board;
+ALTER TABLE games DROP COLUMN variant;
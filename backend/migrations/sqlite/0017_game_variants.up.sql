@@ -0,0 +1,21 @@
+-- variant ของห้อง (standard, ultimate ดู backend/variant.go) ultimate มีกระดาน 9x9 = 81 ช่อง
+-- sqlite ไม่สนความยาวของ VARCHAR อยู่แล้ว แต่ CHECK ของ x, y ใน moves ต้องสร้างตารางใหม่ถึงจะเปลี่ยนได้
+ALTER TABLE games ADD COLUMN variant VARCHAR(20) NOT NULL DEFAULT 'standard';
+-- ultimate: กระดานย่อย (0-8) ที่ตาต่อไปต้องลง (NULL = ลงได้ทุกกระดานย่อยที่ยังไม่จบ)
+ALTER TABLE games ADD COLUMN next_board INT;
+
+CREATE TABLE moves_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id INT REFERENCES games(id) ON DELETE CASCADE,
+    player_id INT REFERENCES users(id),
+    x INT NOT NULL CHECK (x >= 0),
+    y INT NOT NULL CHECK (y >= 0),
+    move_order INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_move_per_cell UNIQUE (game_id, x, y)
+);
+INSERT INTO moves_new (id, game_id, player_id, x, y, move_order, created_at)
+    SELECT id, game_id, player_id, x, y, move_order, created_at FROM moves;
+DROP TABLE moves;
+ALTER TABLE moves_new RENAME TO moves;
@@ -0,0 +1,5 @@
+-- ห้อง gravity ไม่มีขนาดกระดานให้อ่านแล้ว จึงลบทิ้ง
+DELETE FROM games WHERE variant = 'gravity';
+ALTER TABLE games DROP COLUMN win_length;
+ALTER TABLE games DROP COLUMN height;
+ALTER TABLE games DROP COLUMN width;
@@ -0,0 +1,3 @@
+-- ห้อง quantum ไม่มี spooky mark ให้อ่านแล้ว จึงลบทิ้ง
+DELETE FROM games WHERE variant = 'quantum';
+ALTER TABLE games DROP COLUMN quantum;
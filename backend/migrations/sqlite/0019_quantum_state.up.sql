@@ -0,0 +1,3 @@
+-- quantum: spooky mark ทั้งหมดของห้อง (JSON ของ QuantumState ดู backend/quantum.go) NULL = variant อื่น
+-- games.board ยังเก็บเฉพาะหมาก classical เหมือนเดิม
+ALTER TABLE games ADD COLUMN quantum TEXT;
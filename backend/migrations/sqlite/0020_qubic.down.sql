@@ -0,0 +1,20 @@
+-- ห้อง 3 มิติใช้กับ schema เก่าไม่ได้ (ช่องซ้ำกันเมื่อไม่มี z) จึงลบทิ้ง
+DELETE FROM games WHERE depth > 1;
+
+CREATE TABLE moves_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id INT REFERENCES games(id) ON DELETE CASCADE,
+    player_id INT REFERENCES users(id),
+    x INT NOT NULL CHECK (x >= 0),
+    y INT NOT NULL CHECK (y >= 0),
+    move_order INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_move_per_cell UNIQUE (game_id, x, y)
+);
+INSERT INTO moves_old (id, game_id, player_id, x, y, move_order, created_at)
+    SELECT id, game_id, player_id, x, y, move_order, created_at FROM moves;
+DROP TABLE moves;
+ALTER TABLE moves_old RENAME TO moves;
+
+ALTER TABLE games DROP COLUMN depth;
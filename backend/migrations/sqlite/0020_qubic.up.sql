@@ -0,0 +1,21 @@
+-- จำนวนชั้นของกระดาน (1 = 2 มิติ, qubic = 4x4x4 ดู backend/qubic.go)
+ALTER TABLE games ADD COLUMN depth INT NOT NULL DEFAULT 1;
+
+-- ชั้นของการเดินแต่ละครั้ง ช่องเดียวกันในคนละชั้นจึงไม่ซ้ำกัน
+-- sqlite เปลี่ยน UNIQUE constraint ไม่ได้ ต้องสร้างตารางใหม่
+CREATE TABLE moves_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    game_id INT REFERENCES games(id) ON DELETE CASCADE,
+    player_id INT REFERENCES users(id),
+    x INT NOT NULL CHECK (x >= 0),
+    y INT NOT NULL CHECK (y >= 0),
+    z INT NOT NULL DEFAULT 0 CHECK (z >= 0),
+    move_order INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_move_per_cell UNIQUE (game_id, x, y, z)
+);
+INSERT INTO moves_new (id, game_id, player_id, x, y, move_order, created_at)
+    SELECT id, game_id, player_id, x, y, move_order, created_at FROM moves;
+DROP TABLE moves;
+ALTER TABLE moves_new RENAME TO moves;
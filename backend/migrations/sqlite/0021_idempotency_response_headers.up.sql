@@ -0,0 +1,2 @@
+-- header ของผลลัพธ์ที่ต้องตอบซ้ำพร้อม body (JSON object เช่น {"ETag": "..."}) NULL = คีย์ที่บันทึกไว้ก่อนมีคอลัมน์นี้
+ALTER TABLE idempotency_keys ADD COLUMN response_headers TEXT;
@@ -0,0 +1,103 @@
+// backend/misere.go
+package main
+
+import "fmt"
+
+// Misère: กระดาน 3x3 เหมือน standard แต่ใครเรียงครบแถวก่อนแพ้
+type misereVariant struct {
+	standardVariant
+}
+
+func (misereVariant) Outcome(g *Game) (string, []int) {
+	winner, line := CheckLine(g.Board, boardDims(g), g.WinLength)
+	switch winner {
+	case "X":
+		return "O", line
+	case "O":
+		return "X", line
+	}
+	return winner, line
+}
+
+// Notakto: ทั้งสองฝั่งลง X บนกระดาน 3x3 หนึ่งถึงสามกระดานวางเรียงกันในแนวนอน (games.width = 3 x จำนวนกระดาน)
+// กระดานที่มีแถวเรียงครบแล้ว "ตาย" ลงเพิ่มไม่ได้ ใครทำให้กระดานสุดท้ายตายแพ้ (เสมอไม่ได้ เพราะกระดานเต็มต้องมีแถวเรียงครบเสมอ)
+// legal_boards ของ notakto คือกระดานที่ยังไม่ตาย
+type notaktoVariant struct{}
+
+func (notaktoVariant) Defaults() (int, int, int, int) { return 3, 3, 1, 3 }
+
+func (notaktoVariant) SharedMark() byte { return 'X' }
+
+// notaktoBoard - 9 ช่องของกระดานที่ b (นับจากซ้าย) ในรูปแบบเดียวกับกระดานปกติ
+func notaktoBoard(g *Game, b int) string {
+	var sub []byte
+	for y := 0; y < 3; y++ {
+		start := y*g.Width + b*3
+		sub = append(sub, g.Board[start:start+3]...)
+	}
+	return string(sub)
+}
+
+// notaktoDead - กระดานที่ b มีแถวเรียงครบแล้ว (คืนช่องของแถวนั้นบนกระดานใหญ่ด้วย)
+func notaktoDead(g *Game, b int) (bool, []int) {
+	winner, line := CheckWinner(notaktoBoard(g, b))
+	if winner != "X" {
+		return false, nil
+	}
+	cells := make([]int, len(line))
+	for i, cell := range line {
+		cells[i] = (cell/3)*g.Width + b*3 + cell%3
+	}
+	return true, cells
+}
+
+// liveNotaktoBoards - กระดานที่ยังลงได้
+func liveNotaktoBoards(g *Game) []int {
+	live := []int{}
+	for b := 0; b < g.Width/3; b++ {
+		if dead, _ := notaktoDead(g, b); !dead {
+			live = append(live, b)
+		}
+	}
+	return live
+}
+
+func (notaktoVariant) CheckMove(g *Game, x, y, z int) error {
+	if err := checkInside(g, x, y, z); err != nil {
+		return err
+	}
+	if dead, _ := notaktoDead(g, x/3); dead {
+		return fmt.Errorf("Board %d is already dead", x/3)
+	}
+	if g.Board[y*g.Width+x] != '-' {
+		return errCellOccupied
+	}
+	return nil
+}
+
+func (notaktoVariant) Play(g *Game, x, y, z int, mark byte) {
+	placeMark(g, x, y, z, mark)
+}
+
+func (notaktoVariant) Outcome(g *Game) (string, []int) {
+	var lines []int
+	for b := 0; b < g.Width/3; b++ {
+		dead, line := notaktoDead(g, b)
+		if !dead {
+			return "", nil
+		}
+		lines = append(lines, line...)
+	}
+
+	// ทุกกระดานตายแล้ว คนที่เพิ่งเดินแพ้ (player1 เดินก่อน จำนวนหมากคี่ = player1 เพิ่งเดิน)
+	marks := 0
+	for i := 0; i < len(g.Board); i++ {
+		if g.Board[i] != '-' {
+			marks++
+		}
+	}
+	if marks%2 == 1 {
+		return "O", lines
+	}
+	return "X", lines
+}
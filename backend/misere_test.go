@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestMisereOutcome(t *testing.T) {
+	tests := []struct {
+		board  string
+		winner string
+	}{
+		{"XXX-OO---", "O"}, // X เรียงครบก่อนจึงแพ้
+		{"XX-OOOX--", "X"},
+		{"XOXXOOOXX", "DRAW"},
+		{"XO-------", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.board, func(t *testing.T) {
+			g := &Game{Variant: VariantMisere, Board: tt.board}
+			setGameDefaults(g)
+			if winner, _ := (misereVariant{}).Outcome(g); winner != tt.winner {
+				t.Fatalf("winner = %q, want %q", winner, tt.winner)
+			}
+		})
+	}
+}
+
+// notaktoGame - ห้อง notakto หลายกระดานที่ลง X ไว้แล้วตาม cells ({x, y} บนกระดานใหญ่)
+func notaktoGame(boards int, cells ...[2]int) *Game {
+	g := &Game{Variant: VariantNotakto, Status: "IN_PROGRESS", Width: boards * 3, Height: 3}
+	setGameDefaults(g)
+	g.Board = emptyBoard(g)
+	for _, c := range cells {
+		placeMark(g, c[0], c[1], 0, 'X')
+	}
+	return g
+}
+
+func TestNotaktoMultiBoardDeath(t *testing.T) {
+	v := notaktoVariant{}
+	// กระดาน 0 และ 1 ตายด้วยแถวบนสุด กระดาน 2 ยังเหลือ
+	g := notaktoGame(3, [2]int{0, 0}, [2]int{1, 0}, [2]int{2, 0}, [2]int{3, 0}, [2]int{4, 0}, [2]int{5, 0}, [2]int{6, 0}, [2]int{7, 0})
+
+	if got := LegalBoards(g); !reflect.DeepEqual(got, []int{2}) {
+		t.Fatalf("live boards = %v, want [2]", got)
+	}
+	if winner, _ := v.Outcome(g); winner != "" {
+		t.Fatalf("winner = %q with a live board left", winner)
+	}
+	if err := v.CheckMove(g, 1, 1, 0); err == nil {
+		t.Fatal("move on a dead board accepted")
+	}
+	if err := v.CheckMove(g, 7, 0, 0); err != errCellOccupied {
+		t.Fatalf("occupied cell: %v", err)
+	}
+	if err := v.CheckMove(g, 8, 0, 0); err != nil {
+		t.Fatalf("move on the live board: %v", err)
+	}
+
+	// ตาที่ 9 (player1 = X) ทำให้กระดานสุดท้ายตาย -> player1 แพ้
+	v.Play(g, 8, 0, 0, v.SharedMark())
+	winner, line := v.Outcome(g)
+	sort.Ints(line)
+	if winner != "O" || !reflect.DeepEqual(line, []int{0, 1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Fatalf("outcome = %q %v", winner, line)
+	}
+	if got := LegalBoards(g); len(got) != 0 {
+		t.Fatalf("live boards = %v after every board died", got)
+	}
+}
+
+func TestNotaktoLastMoveDecidesLoser(t *testing.T) {
+	// กระดานเดียว ตาที่ 4 (player2) ทำให้ตาย -> player1 ชนะ
+	g := notaktoGame(1, [2]int{0, 2}, [2]int{0, 0}, [2]int{1, 1}, [2]int{2, 2})
+	winner, line := (notaktoVariant{}).Outcome(g)
+	if winner != "X" || !reflect.DeepEqual(line, []int{0, 4, 8}) {
+		t.Fatalf("outcome = %q %v", winner, line)
+	}
+
+	// แถวแนวตั้งของกระดานที่ 2 ต้องแปลงเป็นช่องบนกระดานใหญ่ (width = 6)
+	g = notaktoGame(2, [2]int{0, 0}, [2]int{1, 0}, [2]int{2, 0}, [2]int{4, 0}, [2]int{4, 1}, [2]int{4, 2})
+	winner, line = (notaktoVariant{}).Outcome(g)
+	sort.Ints(line)
+	if winner != "X" || !reflect.DeepEqual(line, []int{0, 1, 2, 4, 10, 16}) {
+		t.Fatalf("outcome = %q %v", winner, line)
+	}
+}
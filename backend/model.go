@@ -2,27 +2,57 @@
 package main
 
 import (
+	"encoding/json"
 	"time"
 )
 
+// Role ของผู้ใช้ (users.role)
+const (
+	RolePlayer    = "player"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 // User - แทนตาราง users
 type User struct {
-	ID           int       `json:"id"`
-	Username     string    `json:"username"`
-	PasswordHash string    `json:"-"` // ไม่ส่ง password กลับไปหน้าบ้าน
-	CreatedAt    time.Time `json:"created_at"`
+	ID           int        `json:"id"`
+	Username     string     `json:"username"`
+	PasswordHash string     `json:"-"` // ไม่ส่ง password กลับไปหน้าบ้าน
+	TokenVersion int        `json:"-"` // เพิ่มทุกครั้งที่ต้องการ revoke token เดิม
+	TOTPEnabled  bool       `json:"totp_enabled"`
+	IsGuest      bool       `json:"is_guest"`
+	Role         string     `json:"role"` // player, moderator, admin
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+
+	TOTPSecret   *string `json:"-"` // nil = ยังไม่ได้เริ่มตั้ง 2FA
+	TOTPLastStep int64   `json:"-"` // time step ของรหัสล่าสุดที่ใช้ไปแล้ว (กันใช้รหัสเดิมซ้ำ)
 }
 
 // Game - แทนตาราง games
 type Game struct {
-	ID            int       `json:"id"`
-	Player1ID     *int      `json:"player1_id"` // ใช้ pointer เพราะอาจจะเป็น null ได้ (เผื่อไว้)
-	Player2ID     *int      `json:"player2_id"`
-	CurrentTurnID *int      `json:"current_turn_id"`
-	Board         string    `json:"board"`   // "---------"
-	Status        string    `json:"status"`  // WAITING, IN_PROGRESS, FINISHED
-	WinnerID      *int      `json:"winner_id"`
-	CreatedAt     time.Time `json:"created_at"`
+	ID                int           `json:"id"`
+	RoomCode          string        `json:"room_code"`
+	Player1ID         int           `json:"player1_id"`
+	Player2ID         *int          `json:"player2_id"` // nil = ยังรอคู่แข่ง
+	CurrentTurnID     int           `json:"current_turn_id"`
+	Variant           string        `json:"variant"` // standard, ultimate, gravity, misere, notakto, quantum, qubic (ดู variant.go)
+	Width             int           `json:"width"`   // ขนาดกระดาน (standard 3x3, ultimate 9x9, gravity ตั้งได้)
+	Height            int           `json:"height"`
+	Depth             int           `json:"depth"`      // จำนวนชั้น (1 = กระดาน 2 มิติ, qubic = 4)
+	WinLength         int           `json:"win_length"` // ต้องเรียงกันกี่ช่องถึงชนะ
+	Board             string        `json:"board"`      // "---------" (ultimate = 81 ช่อง)
+	NextBoard         *int          `json:"next_board"` // ultimate: กระดานย่อยที่ตาต่อไปต้องลง (nil = ที่ไหนก็ได้)
+	Quantum           *QuantumState `json:"quantum"`    // quantum: spooky mark ทั้งหมด (nil = variant อื่น ดู quantum.go)
+	Status            string        `json:"status"`     // WAITING, IN_PROGRESS, FINISHED, DRAW, ABANDONED, VOIDED
+	WinnerID          *int          `json:"winner_id"`
+	DrawRule          string        `json:"draw_rule"`          // full_board, dead_position, perfect_play (ดู logic.go)
+	TerminationReason *string       `json:"termination_reason"` // line, draw, resignation, ... (nil = ยังไม่จบ)
+	NextRoomCode      *string       `json:"next_room_code"`     // ห้อง rematch ที่สร้างต่อจากห้องนี้
+	RematchP1         bool          `json:"rematch_p1"`
+	RematchP2         bool          `json:"rematch_p2"`
+	Version           int           `json:"version"` // เพิ่มทุกครั้งที่ state เปลี่ยน (ใช้เป็น ETag)
+	CreatedAt         time.Time     `json:"created_at"`
 }
 
 // Move - แทนตาราง moves
@@ -32,5 +62,111 @@ type Move struct {
 	PlayerID  int       `json:"player_id"`
 	X         int       `json:"x"`
 	Y         int       `json:"y"`
+	Z         int       `json:"z"` // ชั้น (0 สำหรับกระดาน 2 มิติ)
 	CreatedAt time.Time `json:"created_at"`
-}
\ No newline at end of file
+}
+
+// GameEvent - แทนตาราง game_events (สิ่งที่เกิดกับห้องเกม เรียงตาม Seq, ดู game_events.go)
+type GameEvent struct {
+	ID        int           `json:"id"`
+	GameID    int           `json:"game_id"`
+	Seq       int           `json:"seq"`
+	Type      string        `json:"type"`
+	PlayerID  *int          `json:"player_id"` // ผู้เล่นที่ทำให้เกิด event (nil = ระบบ/ผู้ดูแล)
+	Data      GameEventData `json:"data"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// GameEventData - ข้อมูลเพิ่มเติมของ event แต่ละประเภท (เก็บเป็น JSON ในคอลัมน์ data)
+type GameEventData struct {
+	Player1ID     int    `json:"player1_id,omitempty"`      // created
+	Player2ID     *int   `json:"player2_id,omitempty"`      // created
+	CurrentTurnID int    `json:"current_turn_id,omitempty"` // created
+	Board         string `json:"board,omitempty"`           // created
+	Variant       string `json:"variant,omitempty"`         // created ("" = standard สำหรับ event เก่า)
+	Width         int    `json:"width,omitempty"`           // created (0 = ค่าเริ่มต้นของ variant)
+	Height        int    `json:"height,omitempty"`          // created
+	Depth         int    `json:"depth,omitempty"`           // created (0 = 1 ชั้น สำหรับ event เก่า)
+	WinLength     int    `json:"win_length,omitempty"`      // created
+	Status        string `json:"status,omitempty"`          // created, adjudicated
+	DrawRule      string `json:"draw_rule,omitempty"`       // created ("" = full_board สำหรับ event เก่า)
+	X             *int   `json:"x,omitempty"`               // moved, spooky_moved, collapsed
+	Y             *int   `json:"y,omitempty"`               // moved, spooky_moved, collapsed
+	Z             *int   `json:"z,omitempty"`               // moved (กระดาน 3 มิติเท่านั้น)
+	X2            *int   `json:"x2,omitempty"`              // spooky_moved (ช่องที่สอง)
+	Y2            *int   `json:"y2,omitempty"`              // spooky_moved
+	WinnerID      *int   `json:"winner_id,omitempty"`       // adjudicated
+	NextRoomCode  string `json:"next_room_code,omitempty"`  // rematch_agreed
+}
+
+// ArchivedGame - แทนตาราง archived_games (เกมที่นำเข้าจาก game record ดู record.go)
+type ArchivedGame struct {
+	ID          int        `json:"id"`
+	RoomCode    string     `json:"room_code,omitempty"` // ห้องเดิมตาม record (ถ้ามี)
+	Variant     string     `json:"variant"`
+	PlayerX     string     `json:"player_x"`
+	PlayerO     string     `json:"player_o"`
+	Result      string     `json:"result"` // 1-0, 0-1, 1/2-1/2
+	Termination string     `json:"termination"`
+	Board       string     `json:"board"`
+	Moves       string     `json:"moves"` // "a3 b2 c1"
+	StartedAt   *time.Time `json:"started_at"`
+	EndedAt     *time.Time `json:"ended_at"`
+	ImportedBy  int        `json:"imported_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// UserBan - แทนตาราง user_bans (การแบน / พักการเล่น)
+type UserBan struct {
+	ID        int        `json:"id"`
+	UserID    int        `json:"user_id"`
+	Kind      string     `json:"kind"` // BAN, SUSPENSION
+	Reason    string     `json:"reason"`
+	BannedBy  *int       `json:"banned_by"`
+	ExpiresAt *time.Time `json:"expires_at"` // NULL = ถาวร
+	LiftedAt  *time.Time `json:"lifted_at"`
+	LiftedBy  *int       `json:"lifted_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// AdminUser - ผู้ใช้ในหน้าค้นหาของผู้ดูแล พร้อมสถานะการลงโทษที่มีผลอยู่
+type AdminUser struct {
+	User
+	Banned    bool `json:"banned"`
+	Suspended bool `json:"suspended"`
+}
+
+// GameSummary - ห้องเกมในหน้าค้นหาของผู้ดูแล
+type GameSummary struct {
+	ID                int       `json:"id"`
+	RoomCode          string    `json:"room_code"`
+	Player1ID         *int      `json:"player1_id"`
+	Player1Name       *string   `json:"player1_name"`
+	Player2ID         *int      `json:"player2_id"`
+	Player2Name       *string   `json:"player2_name"`
+	Status            string    `json:"status"`
+	WinnerID          *int      `json:"winner_id"`
+	TerminationReason *string   `json:"termination_reason"`
+	Board             string    `json:"board"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// AuditEntry - แทนตาราง admin_audit_log
+type AuditEntry struct {
+	ID         int             `json:"id"`
+	ActorID    int             `json:"actor_id"`
+	ActorName  string          `json:"actor_name"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   int             `json:"target_id"`
+	Details    json.RawMessage `json:"details"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// OIDCLoginState - แทนตาราง oidc_login_states (SSO login ที่รอ provider redirect กลับมา)
+type OIDCLoginState struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+	CreatedAt    time.Time
+}
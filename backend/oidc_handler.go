@@ -0,0 +1,164 @@
+// backend/oidc_handler.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// state ที่ไม่ถูกใช้ภายในเวลานี้ถือว่าหมดอายุ (ผู้ใช้ไม่ได้กลับมาจากหน้า login ของ provider)
+const oidcStateTTL = 10 * time.Minute
+
+var nonAlphanum = regexp.MustCompile(`[^a-zA-Z0-9]`)
+
+// OIDCLoginHandler - เริ่ม Authorization Code Flow: สร้าง state/nonce/PKCE แล้ว redirect ไปหน้า login ของ provider
+func (s *Server) OIDCLoginHandler(c *gin.Context) {
+	if s.OIDC == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SSO login is not configured"})
+		return
+	}
+
+	state, err1 := RandomURLToken(32)
+	nonce, err2 := RandomURLToken(32)
+	verifier, err3 := RandomURLToken(48)
+	if err1 != nil || err2 != nil || err3 != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start SSO login"})
+		return
+	}
+
+	// เก็บ state ไว้ฝั่ง server (API เป็น stateless ไม่มี session cookie) แล้วเคลียร์ของที่หมดอายุไปด้วย
+	err := s.Users.SaveOIDCState(OIDCLoginState{State: state, Nonce: nonce, CodeVerifier: verifier}, time.Now().Add(-oidcStateTTL))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start SSO login"})
+		return
+	}
+
+	authURL, err := s.OIDC.AuthCodeURL(state, nonce, verifier, c.Query("login_hint"))
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "SSO provider is unavailable", "details": err.Error()})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallbackHandler - provider redirect กลับมาพร้อม code: แลกเป็น ID Token, ผูก/สร้างบัญชี แล้วออก JWT แบบเดียวกับ LoginHandler
+func (s *Server) OIDCCallbackHandler(c *gin.Context) {
+	if s.OIDC == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "SSO login is not configured"})
+		return
+	}
+
+	if errCode := c.Query("error"); errCode != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "SSO login failed", "details": errCode + " " + c.Query("error_description")})
+		return
+	}
+
+	state := c.Query("state")
+	code := c.Query("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing state or code"})
+		return
+	}
+
+	// ลบ state ทิ้งทันที (ใช้ได้ครั้งเดียว กัน replay)
+	pending, err := s.Users.TakeOIDCState(state)
+	if err != nil || time.Since(pending.CreatedAt) > oidcStateTTL {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired SSO state. Please try again."})
+		return
+	}
+
+	idToken, err := s.OIDC.Exchange(code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "SSO login failed", "details": err.Error()})
+		return
+	}
+
+	user, err := s.findOrProvisionOIDCUser(s.OIDC.cfg, idToken)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link SSO account", "details": err.Error()})
+		return
+	}
+
+	if s.rejectIfBanned(c, user.ID) {
+		return
+	}
+
+	payload, err := loginResponse(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Could not generate token"})
+		return
+	}
+
+	// มีหน้าบ้านรออยู่ -> ส่ง token กลับไปใน fragment (ไม่ไปโผล่ใน log ของ server)
+	if s.OIDC.cfg.PostLoginRedirect != "" {
+		fragment := url.Values{}
+		for k, v := range payload {
+			fragment.Set(k, fmt.Sprint(v))
+		}
+		c.Redirect(http.StatusFound, s.OIDC.cfg.PostLoginRedirect+"#"+fragment.Encode())
+		return
+	}
+
+	c.JSON(http.StatusOK, payload)
+}
+
+// findOrProvisionOIDCUser - หาบัญชีที่ผูกกับ (issuer, subject) ไว้แล้ว / ผูกกับบัญชีเดิมตาม username / หรือสร้างบัญชีใหม่
+func (s *Server) findOrProvisionOIDCUser(cfg OIDCConfig, idToken *OIDCIDTokenClaims) (User, error) {
+	user, err := s.Users.UserByOIDC(cfg.Issuer, idToken.Subject)
+	if err == nil {
+		return *user, nil
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return User{}, err
+	}
+
+	baseName := nonAlphanum.ReplaceAllString(idToken.PreferredUsername, "")
+	if baseName == "" {
+		baseName = nonAlphanum.ReplaceAllString(idToken.Name, "")
+	}
+	if len(baseName) > 20 {
+		baseName = baseName[:20]
+	}
+
+	// ผูกกับบัญชีเดิมที่ชื่อตรงกัน (เปิดใช้เฉพาะเมื่อเชื่อ preferred_username ของ provider ได้)
+	if cfg.LinkByUsername && baseName != "" {
+		user, err = s.Users.LinkOIDC(baseName, cfg.Issuer, idToken.Subject)
+		if err == nil {
+			return *user, nil
+		}
+		if !errors.Is(err, ErrNotFound) {
+			return User{}, err
+		}
+	}
+
+	// สร้างบัญชีใหม่แบบ SSO อย่างเดียว (ไม่มีรหัสผ่าน) ถ้าชื่อชนก็ต่อท้ายด้วยเลขสุ่ม
+	if len(baseName) < 3 {
+		baseName = "player"
+	}
+	username := baseName
+	for attempt := 0; attempt < 5; attempt++ {
+		user, err = s.Users.CreateOIDCUser(username, cfg.Issuer, idToken.Subject)
+		if err == nil {
+			return *user, nil
+		}
+		if !errors.Is(err, ErrDuplicate) {
+			return User{}, err
+		}
+
+		suffix := fmt.Sprintf("%04d", rand.Intn(10000))
+		if len(baseName)+len(suffix) > 20 {
+			username = baseName[:20-len(suffix)] + suffix
+		} else {
+			username = baseName + suffix
+		}
+	}
+	return User{}, fmt.Errorf("could not find a free username for %q", baseName)
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testOIDCClientID = "tictactoe-test"
+
+// testOIDCProvider - provider ปลอมบน httptest (discovery, JWKS, token endpoint) แบบเดียวกับ scripts/mockoidc
+// code ออกเองด้วย issueCode แทนหน้า /authorize
+type testOIDCProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]testOIDCCode
+}
+
+type testOIDCCode struct {
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newTestOIDCProvider(t *testing.T) *testOIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testOIDCProvider{key: key, codes: map[string]testOIDCCode{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.server.URL,
+			"authorization_endpoint": p.server.URL + "/authorize",
+			"token_endpoint":         p.server.URL + "/token",
+			"jwks_uri":               p.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"use": "sig",
+				"kid": "test-key",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		p.mu.Lock()
+		code, ok := p.codes[r.PostForm.Get("code")]
+		delete(p.codes, r.PostForm.Get("code"))
+		p.mu.Unlock()
+
+		if !ok || PKCEChallenge(r.PostForm.Get("code_verifier")) != code.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, code.claims)
+		token.Header["kid"] = "test-key"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idToken})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// issueCode - "อนุมัติ" การ login ของ username แล้วคืน code (mutate ใช้แก้ claim ของ ID Token ก่อนเซ็น)
+func (p *testOIDCProvider) issueCode(challenge, nonce, username string, mutate func(jwt.MapClaims)) string {
+	claims := jwt.MapClaims{
+		"iss":                p.server.URL,
+		"sub":                "test|" + username,
+		"aud":                testOIDCClientID,
+		"iat":                time.Now().Unix(),
+		"exp":                time.Now().Add(5 * time.Minute).Unix(),
+		"nonce":              nonce,
+		"preferred_username": username,
+	}
+	if mutate != nil {
+		mutate(claims)
+	}
+
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	code := base64.RawURLEncoding.EncodeToString(buf)
+	p.mu.Lock()
+	p.codes[code] = testOIDCCode{challenge: challenge, claims: claims}
+	p.mu.Unlock()
+	return code
+}
+
+func (p *testOIDCProvider) config(linkByUsername bool) OIDCConfig {
+	return OIDCConfig{
+		Issuer:         p.server.URL,
+		ClientID:       testOIDCClientID,
+		RedirectURL:    "http://localhost/api/auth/oidc/callback",
+		Scopes:         "openid profile",
+		LinkByUsername: linkByUsername,
+	}
+}
+
+// startOIDCLogin - เรียก /api/auth/oidc/login แล้วคืน query ของ URL ที่ถูก redirect ไปหา provider
+func startOIDCLogin(t *testing.T, h http.Handler) url.Values {
+	t.Helper()
+	w := doRequest(t, h, http.MethodGet, "/api/auth/oidc/login", "", nil)
+	if w.Code != http.StatusFound {
+		t.Fatalf("oidc login: %d %s", w.Code, w.Body.String())
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := location.Query()
+	if q.Get("code_challenge_method") != "S256" || q.Get("state") == "" || q.Get("nonce") == "" {
+		t.Fatalf("authorization URL is missing PKCE/state/nonce: %s", location)
+	}
+	return q
+}
+
+func oidcCallback(t *testing.T, h http.Handler, state, code string) *httptest.ResponseRecorder {
+	t.Helper()
+	return doRequest(t, h, http.MethodGet, "/api/auth/oidc/callback?"+url.Values{"state": {state}, "code": {code}}.Encode(), "", nil)
+}
+
+// oidcLoginAs - login ผ่าน SSO จนจบ คืน user_id ของบัญชีที่ได้
+func oidcLoginAs(t *testing.T, h http.Handler, p *testOIDCProvider, username string) int {
+	t.Helper()
+	q := startOIDCLogin(t, h)
+	w := oidcCallback(t, h, q.Get("state"), p.issueCode(q.Get("code_challenge"), q.Get("nonce"), username, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("oidc callback: %d %s", w.Code, w.Body.String())
+	}
+	return int(decodeBody(t, w)["user_id"].(float64))
+}
+
+func TestOIDCCallbackRejects(t *testing.T) {
+	p := newTestOIDCProvider(t)
+	s, h := newTestServer(t)
+	s.OIDC = NewOIDCProvider(p.config(false))
+
+	tests := []struct {
+		name   string
+		tamper func(q url.Values, p *testOIDCProvider) (state, code string)
+		status int
+	}{
+		{"unknown state", func(q url.Values, p *testOIDCProvider) (string, string) {
+			return "not-the-state", p.issueCode(q.Get("code_challenge"), q.Get("nonce"), "alice", nil)
+		}, http.StatusBadRequest},
+		{"pkce verifier mismatch", func(q url.Values, p *testOIDCProvider) (string, string) {
+			return q.Get("state"), p.issueCode(PKCEChallenge("some-other-verifier"), q.Get("nonce"), "alice", nil)
+		}, http.StatusUnauthorized},
+		{"wrong issuer", func(q url.Values, p *testOIDCProvider) (string, string) {
+			return q.Get("state"), p.issueCode(q.Get("code_challenge"), q.Get("nonce"), "alice", func(c jwt.MapClaims) { c["iss"] = "https://evil.example" })
+		}, http.StatusUnauthorized},
+		{"wrong audience", func(q url.Values, p *testOIDCProvider) (string, string) {
+			return q.Get("state"), p.issueCode(q.Get("code_challenge"), q.Get("nonce"), "alice", func(c jwt.MapClaims) { c["aud"] = "another-client" })
+		}, http.StatusUnauthorized},
+		{"wrong nonce", func(q url.Values, p *testOIDCProvider) (string, string) {
+			return q.Get("state"), p.issueCode(q.Get("code_challenge"), "replayed-nonce", "alice", nil)
+		}, http.StatusUnauthorized},
+		{"expired id token", func(q url.Values, p *testOIDCProvider) (string, string) {
+			return q.Get("state"), p.issueCode(q.Get("code_challenge"), q.Get("nonce"), "alice", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() })
+		}, http.StatusUnauthorized},
+		{"missing exp", func(q url.Values, p *testOIDCProvider) (string, string) {
+			return q.Get("state"), p.issueCode(q.Get("code_challenge"), q.Get("nonce"), "alice", func(c jwt.MapClaims) { delete(c, "exp") })
+		}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, code := tt.tamper(startOIDCLogin(t, h), p)
+			w := oidcCallback(t, h, state, code)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if _, err := s.Users.UserByOIDC(p.server.URL, "test|alice"); err == nil {
+				t.Fatal("rejected callback still provisioned a user")
+			}
+		})
+	}
+
+	// state ใช้ได้ครั้งเดียว
+	t.Run("state reused", func(t *testing.T) {
+		q := startOIDCLogin(t, h)
+		if w := oidcCallback(t, h, q.Get("state"), p.issueCode(q.Get("code_challenge"), q.Get("nonce"), "alice", nil)); w.Code != http.StatusOK {
+			t.Fatalf("first callback: %d %s", w.Code, w.Body.String())
+		}
+		if w := oidcCallback(t, h, q.Get("state"), p.issueCode(q.Get("code_challenge"), q.Get("nonce"), "alice", nil)); w.Code != http.StatusBadRequest {
+			t.Fatalf("second callback with the same state: %d, want 400", w.Code)
+		}
+	})
+}
+
+func TestOIDCProvisioning(t *testing.T) {
+	stores := []struct {
+		name      string
+		newServer func(t *testing.T) (*Server, http.Handler)
+	}{
+		{"memory", newTestServer},
+		{"sqlite", newSQLiteTestServer},
+	}
+	p := newTestOIDCProvider(t)
+	for _, st := range stores {
+		t.Run(st.name+"/first and second login", func(t *testing.T) {
+			s, h := st.newServer(t)
+			s.OIDC = NewOIDCProvider(p.config(false))
+
+			first := oidcLoginAs(t, h, p, "alice")
+			user, err := s.Users.UserByID(first)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Username != "alice" || user.PasswordHash != "" {
+				t.Fatalf("provisioned user = %q (password set: %v), want SSO-only alice", user.Username, user.PasswordHash != "")
+			}
+			if second := oidcLoginAs(t, h, p, "alice"); second != first {
+				t.Fatalf("second login got user %d, want %d", second, first)
+			}
+		})
+
+		t.Run(st.name+"/link by username off", func(t *testing.T) {
+			s, h := st.newServer(t)
+			s.OIDC = NewOIDCProvider(p.config(false))
+			localID, _ := newTestUser(t, s, "bob")
+
+			got := oidcLoginAs(t, h, p, "bob")
+			if got == localID {
+				t.Fatal("SSO login took over the local account with LinkByUsername off")
+			}
+			user, err := s.Users.UserByID(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if user.Username == "bob" || len(user.Username) != len("bob")+4 {
+				t.Fatalf("new SSO username = %q, want bob + 4 digits", user.Username)
+			}
+		})
+
+		t.Run(st.name+"/link by username on", func(t *testing.T) {
+			s, h := st.newServer(t)
+			s.OIDC = NewOIDCProvider(p.config(true))
+			localID, _ := newTestUser(t, s, "bob")
+
+			if got := oidcLoginAs(t, h, p, "bob"); got != localID {
+				t.Fatalf("SSO login got user %d, want linked local user %d", got, localID)
+			}
+			if got := oidcLoginAs(t, h, p, "bob"); got != localID {
+				t.Fatalf("second SSO login got user %d, want %d", got, localID)
+			}
+		})
+	}
+}
+
+// TestPKCEChallenge - ตัวอย่างจาก RFC 7636 ภาคผนวก B
+func TestPKCEChallenge(t *testing.T) {
+	if got := PKCEChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Fatalf("challenge = %s", got)
+	}
+}
@@ -0,0 +1,266 @@
+// backend/quantum.go
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Quantum Tic-Tac-Toe: กระดาน 3x3 แต่แต่ละตาลง "spooky mark" สองช่องพร้อมกัน (เช่น X1 ที่ a3 กับ b2)
+//   - ช่องที่ยังไม่มีหมากจริง (classical) มี spooky mark ซ้อนกันได้หลายอัน แต่ละ mark คือเส้นเชื่อมสองช่องใน entanglement graph
+//   - ถ้า mark ใหม่ทำให้ graph เกิด cycle อีกฝั่ง (คนที่ไม่ได้ปิด cycle) ต้องเลือกว่า mark นั้นจะยุบ (collapse) ลงช่องไหน
+//     แล้ว mark อื่นที่เชื่อมกันอยู่ยุบตามเป็นทอดๆ กลายเป็นหมาก classical ทั้งหมด จากนั้นคนที่เลือกค่อยเดินตาของตัวเอง
+//   - collapse ครั้งเดียวอาจทำให้เกิดแถวพร้อมกันทั้งสองฝั่ง ฝั่งที่แถวเสร็จก่อนชนะ (เลขตาที่มากที่สุดในแถวน้อยกว่า)
+//   - เหลือช่องว่างช่องเดียว = ฝั่งที่ถึงตาลงหมาก classical ช่องนั้นเลย ครบ 9 ช่องโดยไม่มีแถว = เสมอ
+//
+// games.board เก็บเฉพาะหมาก classical ส่วน spooky mark ทั้งหมดอยู่ใน games.quantum (QuantumState เก็บเป็น JSON)
+// ตาของ quantum ไม่บันทึกลง moves (ช่องเดียวกันมีได้หลาย mark) ประวัติอยู่ใน games.quantum และ game_events
+type quantumVariant struct{}
+
+// QuantumState - spooky mark ทุกอันของห้อง quantum
+type QuantumState struct {
+	Marks    []SpookyMark `json:"marks"`    // เรียงตามตาที่เดิน (ตัวที่ i = ตาที่ i+1 เช่น X1, O2)
+	Collapse *int         `json:"collapse"` // index ของ mark ที่ปิด cycle รอให้อีกฝั่งเลือกช่อง (nil = ไม่มี)
+}
+
+// SpookyMark - หมากหนึ่งตา ลงไว้สองช่องจนกว่าจะ collapse
+type SpookyMark struct {
+	Mark  string `json:"mark"`  // "X" / "O"
+	Cells [2]int `json:"cells"` // ช่องทั้งสอง (index ตาม cellIndex)
+	Cell  *int   `json:"cell"`  // ช่องที่ยุบลงแล้ว (nil = ยังเป็น spooky)
+}
+
+var errQuantumMove = errors.New("Quantum moves place two spooky marks (send x, y, x2 and y2)")
+
+// errQuantumHistory - ตาของ quantum ไม่ลง moves จึงไม่มี replay (แชร์ / replay สาธารณะ / backfill event log)
+var errQuantumHistory = errors.New("Replays are not available for the quantum variant")
+
+func (quantumVariant) Defaults() (int, int, int, int) { return 3, 3, 1, 3 }
+
+// CheckMove - ตาของ quantum ไปทาง event spooky_moved (CheckSpookyMove) ลงช่องเดียวไม่ได้
+func (quantumVariant) CheckMove(g *Game, x, y, z int) error {
+	return errQuantumMove
+}
+
+func (quantumVariant) Play(g *Game, x, y, z int, mark byte) {}
+
+// Outcome - ตัดสินจากหมาก classical (spooky mark ยังไม่นับ)
+func (quantumVariant) Outcome(g *Game) (string, []int) {
+	if g.Quantum == nil {
+		return "", nil
+	}
+	// เลขตาของหมาก classical แต่ละช่อง
+	turn := make([]int, len(g.Board))
+	for i, m := range g.Quantum.Marks {
+		if m.Cell != nil {
+			turn[*m.Cell] = i + 1
+		}
+	}
+
+	winner, first := "", 0
+	var winning []int
+	b := g.Board
+	for _, line := range lineTable(boardDims(g), g.WinLength) {
+		mark := b[line[0]]
+		if mark == '-' {
+			continue
+		}
+		// แถวเสร็จตอนหมากตัวหลังสุดในแถวลง
+		done, complete := 0, true
+		for _, cell := range line {
+			if b[cell] != mark {
+				complete = false
+				break
+			}
+			if turn[cell] > done {
+				done = turn[cell]
+			}
+		}
+		if complete && (winning == nil || done < first) {
+			winner, first, winning = string(mark), done, append([]int(nil), line...)
+		}
+	}
+	if winner != "" {
+		return winner, winning
+	}
+	if freeCells(g) == nil {
+		return "DRAW", nil
+	}
+	return "", nil
+}
+
+// emptyQuantumState - state ของห้อง quantum ที่ยังไม่มีใครเดิน
+func emptyQuantumState() *QuantumState {
+	return &QuantumState{Marks: []SpookyMark{}}
+}
+
+// quantumJSON - ค่าที่เก็บลง games.quantum (nil = NULL สำหรับ variant อื่น)
+func quantumJSON(q *QuantumState) interface{} {
+	if q == nil {
+		return nil
+	}
+	data, _ := json.Marshal(q)
+	return string(data)
+}
+
+// parseQuantum - อ่าน games.quantum กลับเป็น QuantumState
+func parseQuantum(column sql.NullString) (*QuantumState, error) {
+	if !column.Valid {
+		return nil, nil
+	}
+	var q QuantumState
+	if err := json.Unmarshal([]byte(column.String), &q); err != nil {
+		return nil, fmt.Errorf("games.quantum: %w", err)
+	}
+	return &q, nil
+}
+
+// clone - ApplyEvent ไม่แก้ state เดิมตรงๆ (memory store ยังถือ pointer เดิมไว้จนกว่าจะ commit)
+func (q *QuantumState) clone() *QuantumState {
+	c := &QuantumState{Marks: make([]SpookyMark, len(q.Marks))}
+	for i, m := range q.Marks {
+		c.Marks[i] = m
+		if m.Cell != nil {
+			cell := *m.Cell
+			c.Marks[i].Cell = &cell
+		}
+	}
+	if q.Collapse != nil {
+		pending := *q.Collapse
+		c.Collapse = &pending
+	}
+	return c
+}
+
+// freeCells - ช่องที่ยังไม่มีหมาก classical
+func freeCells(g *Game) []int {
+	var free []int
+	for i := 0; i < len(g.Board); i++ {
+		if g.Board[i] == '-' {
+			free = append(free, i)
+		}
+	}
+	return free
+}
+
+// entangled - ช่อง a กับ b เชื่อมถึงกันผ่าน spooky mark ที่ยังไม่ collapse อยู่แล้วหรือเปล่า
+func (q *QuantumState) entangled(a, b int) bool {
+	seen := map[int]bool{a: true}
+	queue := []int{a}
+	for len(queue) > 0 {
+		cell := queue[0]
+		queue = queue[1:]
+		if cell == b {
+			return true
+		}
+		for _, m := range q.Marks {
+			if m.Cell != nil {
+				continue
+			}
+			for side, c := range m.Cells {
+				if c == cell && !seen[m.Cells[1-side]] {
+					seen[m.Cells[1-side]] = true
+					queue = append(queue, m.Cells[1-side])
+				}
+			}
+		}
+	}
+	return false
+}
+
+// CheckSpookyMove - error (ส่งให้ client ได้) ถ้าลง spooky mark ที่ (x, y) กับ (x2, y2) ตอนนี้ไม่ได้
+func CheckSpookyMove(g *Game, x, y, x2, y2 int) error {
+	if g.Quantum == nil {
+		return fmt.Errorf("Spooky marks are only allowed in quantum games")
+	}
+	if g.Quantum.Collapse != nil {
+		return fmt.Errorf("The collapse must be resolved before the next move")
+	}
+	if err := checkInside(g, x, y, 0); err != nil {
+		return err
+	}
+	if err := checkInside(g, x2, y2, 0); err != nil {
+		return err
+	}
+	if x == x2 && y == y2 {
+		return fmt.Errorf("Spooky marks must go in two different cells")
+	}
+	if g.Board[cellIndex(g, x, y, 0)] != '-' || g.Board[cellIndex(g, x2, y2, 0)] != '-' {
+		return errCellOccupied
+	}
+	return nil
+}
+
+// playSpooky - ลง spooky mark (ต้องผ่าน CheckSpookyMove มาแล้ว) ถ้าปิด cycle จะรอ collapse
+func playSpooky(g *Game, x, y, x2, y2 int, mark byte) {
+	q := g.Quantum.clone()
+	a, b := cellIndex(g, x, y, 0), cellIndex(g, x2, y2, 0)
+	if q.entangled(a, b) {
+		pending := len(q.Marks)
+		q.Collapse = &pending
+	}
+	q.Marks = append(q.Marks, SpookyMark{Mark: string(mark), Cells: [2]int{a, b}})
+	g.Quantum = q
+}
+
+// CheckCollapse - error (ส่งให้ client ได้) ถ้าเลือกให้ mark ที่ปิด cycle ยุบลง (x, y) ไม่ได้
+func CheckCollapse(g *Game, x, y int) error {
+	if g.Quantum == nil || g.Quantum.Collapse == nil {
+		return fmt.Errorf("There is no collapse to resolve")
+	}
+	if err := checkInside(g, x, y, 0); err != nil {
+		return err
+	}
+	pending := g.Quantum.Marks[*g.Quantum.Collapse]
+	if cell := cellIndex(g, x, y, 0); cell != pending.Cells[0] && cell != pending.Cells[1] {
+		return fmt.Errorf("Choose one of the two cells of %s%d", pending.Mark, *g.Quantum.Collapse+1)
+	}
+	return nil
+}
+
+// collapse - ให้ mark ที่ปิด cycle ยุบลง (x, y) แล้วไล่ยุบ mark ที่เชื่อมกันต่อ (ต้องผ่าน CheckCollapse มาแล้ว)
+// ถ้าเหลือช่องว่างช่องเดียว ฝั่งที่ถึงตา (คนที่เลือก) ได้ช่องนั้นเป็นหมาก classical เลย
+func collapse(g *Game, x, y int) {
+	q := g.Quantum.clone()
+	board := []byte(g.Board)
+
+	type forced struct{ mark, cell int }
+	queue := []forced{{*q.Collapse, cellIndex(g, x, y, 0)}}
+	for len(queue) > 0 {
+		f := queue[0]
+		queue = queue[1:]
+		m := &q.Marks[f.mark]
+		if m.Cell != nil {
+			continue
+		}
+		cell := f.cell
+		m.Cell = &cell
+		board[cell] = m.Mark[0]
+		// mark อื่นที่อยู่ช่องเดียวกันต้องไปอีกช่องของตัวเอง
+		for i, other := range q.Marks {
+			if other.Cell != nil {
+				continue
+			}
+			for side, c := range other.Cells {
+				if c == cell {
+					queue = append(queue, forced{i, other.Cells[1-side]})
+				}
+			}
+		}
+	}
+	q.Collapse = nil
+	g.Quantum = q
+	g.Board = string(board)
+
+	if winner, _ := (quantumVariant{}).Outcome(g); winner != "" {
+		return
+	}
+	if free := freeCells(g); len(free) == 1 {
+		cell := free[0]
+		mark := markOf(g, g.CurrentTurnID)
+		q.Marks = append(q.Marks, SpookyMark{Mark: string(mark), Cells: [2]int{cell, cell}, Cell: &cell})
+		g.Board = g.Board[:cell] + string(mark) + g.Board[cell+1:]
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func quantumGame() *Game {
+	p2 := 2
+	g := &Game{Variant: VariantQuantum, Status: "IN_PROGRESS", Player1ID: 1, Player2ID: &p2, CurrentTurnID: 1}
+	setGameDefaults(g)
+	g.Board = emptyBoard(g)
+	return g
+}
+
+func TestQuantumCycleAndCollapseCascade(t *testing.T) {
+	g := quantumGame()
+
+	// X1: a-b, O2: b-c แถวบน ยังไม่มี cycle
+	for _, m := range []struct {
+		x, y, x2, y2 int
+		mark         byte
+	}{{0, 0, 1, 0, 'X'}, {1, 0, 2, 0, 'O'}} {
+		if err := CheckSpookyMove(g, m.x, m.y, m.x2, m.y2); err != nil {
+			t.Fatal(err)
+		}
+		playSpooky(g, m.x, m.y, m.x2, m.y2, m.mark)
+	}
+	if g.Quantum.Collapse != nil {
+		t.Fatal("collapse pending without a cycle")
+	}
+	if err := CheckSpookyMove(g, 0, 0, 0, 0); err == nil {
+		t.Fatal("spooky marks in the same cell accepted")
+	}
+
+	// X3: c-a ปิด cycle a-b-c
+	playSpooky(g, 2, 0, 0, 0, 'X')
+	if g.Quantum.Collapse == nil || *g.Quantum.Collapse != 2 {
+		t.Fatalf("collapse = %v, want mark 2", g.Quantum.Collapse)
+	}
+	if err := CheckSpookyMove(g, 0, 1, 1, 1); err == nil {
+		t.Fatal("spooky move accepted while a collapse is pending")
+	}
+	if err := CheckCollapse(g, 1, 0); err == nil {
+		t.Fatal("collapse into a cell the mark is not in accepted")
+	}
+	if err := CheckCollapse(g, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// X3 ยุบลง a -> X1 ต้องไป b -> O2 ต้องไป c
+	g.CurrentTurnID = *g.Player2ID
+	collapse(g, 0, 0)
+	if g.Board != "XXO------" {
+		t.Fatalf("board = %q", g.Board)
+	}
+	if g.Quantum.Collapse != nil {
+		t.Fatal("collapse still pending")
+	}
+	for i, want := range []int{1, 2, 0} {
+		if m := g.Quantum.Marks[i]; m.Cell == nil || *m.Cell != want {
+			t.Fatalf("mark %d collapsed into %v, want %d", i, m.Cell, want)
+		}
+	}
+	if err := CheckSpookyMove(g, 0, 0, 1, 1); err != errCellOccupied {
+		t.Fatalf("spooky mark on a classical cell: %v", err)
+	}
+}
+
+// classicalState - state ที่ทุก mark ยุบแล้ว cells[i] = ช่องของตาที่ i+1 (X เดินตาคี่)
+func classicalState(g *Game, cells ...int) {
+	board := []byte(g.Board)
+	q := emptyQuantumState()
+	for i, cell := range cells {
+		mark := "X"
+		if i%2 == 1 {
+			mark = "O"
+		}
+		cell := cell
+		q.Marks = append(q.Marks, SpookyMark{Mark: mark, Cells: [2]int{cell, cell}, Cell: &cell})
+		board[cell] = mark[0]
+	}
+	g.Quantum = q
+	g.Board = string(board)
+}
+
+func TestQuantumSimultaneousLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		cells  []int
+		winner string
+		line   []int
+	}{
+		// X แถวบนเสร็จตาที่ 7 ส่วน O แถวกลางเสร็จตาที่ 6 -> O ชนะ
+		{"earlier line wins", []int{0, 3, 1, 4, 6, 5, 2}, "O", []int{3, 4, 5}},
+		// X แถวบนเสร็จตาที่ 5 ก่อน O แถวกลางที่เสร็จตาที่ 6
+		{"first mover wins when earlier", []int{0, 3, 1, 4, 2, 5}, "X", []int{0, 1, 2}},
+		{"no line", []int{0, 1, 2}, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := quantumGame()
+			classicalState(g, tt.cells...)
+			winner, line := (quantumVariant{}).Outcome(g)
+			if winner != tt.winner || !reflect.DeepEqual(line, tt.line) {
+				t.Fatalf("outcome = %q %v, want %q %v", winner, line, tt.winner, tt.line)
+			}
+		})
+	}
+}
+
+func TestQuantumCollapseCompletesLine(t *testing.T) {
+	g := quantumGame()
+	// X O X / X O O / - X - : เหลือช่อง g กับ i แล้ว O8 กับ X9 ลง g-i ทั้งคู่ (cycle สองช่อง)
+	classicalState(g, 0, 1, 2, 4, 3, 5, 7)
+	playSpooky(g, 0, 2, 2, 2, 'O') // O8: g-i
+	playSpooky(g, 0, 2, 2, 2, 'X') // X9: g-i ปิด cycle
+	if g.Quantum.Collapse == nil {
+		t.Fatal("expected a pending collapse")
+	}
+	g.CurrentTurnID = *g.Player2ID
+	collapse(g, 0, 2)
+	if g.Board != "XOXXOOXXO" {
+		t.Fatalf("board = %q", g.Board)
+	}
+	if winner, _ := (quantumVariant{}).Outcome(g); winner != "X" {
+		t.Fatalf("winner = %q", winner)
+	}
+}
@@ -0,0 +1,28 @@
+// backend/qubic.go
+package main
+
+// Qubic: Tic-Tac-Toe สามมิติ 4x4x4 (สี่ชั้น ชั้นละ 4x4) เรียงครบ 4 ช่องชนะ มีแถวที่ชนะได้ทั้งหมด 76 แถว
+// (แนวนอน / แนวตั้ง / ทแยงในแต่ละชั้น ข้ามชั้นในแนวตรง และทแยงข้ามชั้นทั้งหมด ดู lineTable)
+//
+// ผู้เล่นส่ง x, y, z (z = ชั้น) games.board เก็บ 64 ช่องเรียงทีละชั้น (index = (z*4 + y)*4 + x)
+type qubicVariant struct{}
+
+func (qubicVariant) Defaults() (int, int, int, int) { return 4, 4, 4, 4 }
+
+func (qubicVariant) CheckMove(g *Game, x, y, z int) error {
+	if err := checkInside(g, x, y, z); err != nil {
+		return err
+	}
+	if g.Board[cellIndex(g, x, y, z)] != '-' {
+		return errCellOccupied
+	}
+	return nil
+}
+
+func (qubicVariant) Play(g *Game, x, y, z int, mark byte) {
+	placeMark(g, x, y, z, mark)
+}
+
+func (qubicVariant) Outcome(g *Game) (string, []int) {
+	return CheckLine(g.Board, boardDims(g), g.WinLength)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestQubicLineTable(t *testing.T) {
+	if got := len(lineTable([]int{4, 4, 4}, 4)); got != 76 {
+		t.Fatalf("%d lines, want 76", got)
+	}
+}
+
+func qubicGame() *Game {
+	g := &Game{Variant: VariantQubic}
+	setGameDefaults(g)
+	g.Board = emptyBoard(g)
+	return g
+}
+
+func TestQubicOutcome(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells [][3]int // ช่องที่ X ลง {x, y, z}
+		line  []int
+	}{
+		{"row in one layer", [][3]int{{0, 1, 2}, {1, 1, 2}, {2, 1, 2}, {3, 1, 2}}, []int{36, 37, 38, 39}},
+		{"pillar across layers", [][3]int{{2, 3, 0}, {2, 3, 1}, {2, 3, 2}, {2, 3, 3}}, []int{14, 30, 46, 62}},
+		{"diagonal across layers", [][3]int{{0, 0, 0}, {1, 0, 1}, {2, 0, 2}, {3, 0, 3}}, []int{0, 17, 34, 51}},
+		{"space diagonal", [][3]int{{3, 0, 0}, {2, 1, 1}, {1, 2, 2}, {0, 3, 3}}, []int{3, 22, 41, 60}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := qubicGame()
+			v := qubicVariant{}
+			for i, c := range tt.cells {
+				if winner, _ := v.Outcome(g); winner != "" {
+					t.Fatalf("winner %q after %d marks", winner, i)
+				}
+				if err := v.CheckMove(g, c[0], c[1], c[2]); err != nil {
+					t.Fatal(err)
+				}
+				v.Play(g, c[0], c[1], c[2], 'X')
+			}
+			winner, line := v.Outcome(g)
+			sort.Ints(line)
+			if winner != "X" || !reflect.DeepEqual(line, tt.line) {
+				t.Fatalf("outcome = %q %v, want X %v", winner, line, tt.line)
+			}
+		})
+	}
+}
+
+func TestQubicCheckMove(t *testing.T) {
+	g := qubicGame()
+	v := qubicVariant{}
+	v.Play(g, 1, 2, 3, 'O')
+	if err := v.CheckMove(g, 1, 2, 3); err != errCellOccupied {
+		t.Fatalf("occupied cell: %v", err)
+	}
+	if err := v.CheckMove(g, 1, 2, 4); err == nil {
+		t.Fatal("layer 4 accepted")
+	}
+	if err := v.CheckMove(g, 4, 0, 0); err == nil {
+		t.Fatal("x = 4 accepted")
+	}
+}
@@ -0,0 +1,360 @@
+// backend/record.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Game record notation (คล้าย PGN ของหมากรุก) ใช้ส่งออก/นำเข้าเกมทั้งเกมเป็นข้อความ
+//
+//	[Event "Tic-Tac-Toe"]
+//	[Room "205766"]
+//	[Variant "standard"]
+//	[Date "2026.10.19"]
+//	[Start "2026-10-19T00:19:47Z"]
+//	[End "2026-10-19T00:20:31Z"]
+//	[X "alice"]
+//	[O "bob"]
+//	[Result "1-0"]
+//	[Termination "normal"]
+//
+//	1. a3 b2 2. b3 c1 3. c3 1-0
+//
+// ช่องเขียนแบบ algebraic: คอลัมน์ a-c (x = 0-2) แถว 1-3 นับจากล่างขึ้นบน (y = 0 คือแถว 3)
+// variant ที่กระดานใหญ่กว่าใช้ตัวอักษร / เลขแถวเพิ่มตามขนาด เช่น ultimate 9x9 = a1-i9
+// กระดานที่ไม่ใช่ขนาดเริ่มต้นของ variant (gravity ที่ตั้งขนาดเอง) มี tag [Size "5x4"] บอกขนาดเพิ่ม
+// Result: 1-0 = X ชนะ, 0-1 = O ชนะ, 1/2-1/2 = เสมอ, * = ไม่มีผล
+// X คือ player1 (เดินก่อน) เสมอ
+const (
+	ResultXWins    = "1-0"
+	ResultOWins    = "0-1"
+	ResultDraw     = "1/2-1/2"
+	ResultNoResult = "*"
+
+	TerminationNormal       = "normal"           // จบบนกระดาน
+	TerminationDeadPosition = ReasonDeadPosition // เสมอก่อนกระดานเต็ม เพราะไม่มีใครเรียงครบได้อีก
+	TerminationForcedDraw   = ReasonForcedDraw   // เสมอก่อนกระดานเต็ม เพราะเล่นดีที่สุดแล้วเสมอแน่นอน
+	TerminationAbandoned    = "abandoned"        // ออกกลางเกม / ผู้ดูแลสั่งจบ
+	TerminationAdjudicated  = "adjudicated"      // ผู้ดูแลตัดสินผลไม่ตรงกับกระดาน
+	TerminationVoided       = "voided"           // ผลถูกยกเลิก
+	TerminationUnfinished   = "unterminated"     // ยังเล่นไม่จบ
+
+	recordMaxLength = 64 << 10
+)
+
+// GameRecord - เกมหนึ่งเกมในรูปแบบ notation
+type GameRecord struct {
+	Room        string
+	Variant     string
+	Width       int // ขนาดกระดาน ([Size "7x6"] ถ้าไม่ใช่ขนาดเริ่มต้นของ variant)
+	Height      int
+	PlayerX     string
+	PlayerO     string
+	Result      string
+	Termination string
+	Start       *time.Time
+	End         *time.Time
+	Moves       []RecordMove
+}
+
+// RecordMove - การเดินหนึ่งครั้ง (พิกัดเดียวกับ moves.x / moves.y)
+type RecordMove struct {
+	X int
+	Y int
+}
+
+// squareName - (x, y) -> "a3" บนกระดานสูง height แถว (ultimate 9x9 = a1-i9)
+func squareName(x, y, height int) string {
+	return fmt.Sprintf("%c%d", 'a'+x, height-y)
+}
+
+// parseSquare - "a3" -> (0, 0) บนกระดานขนาด width x height
+func parseSquare(s string, width, height int) (int, int, error) {
+	if len(s) != 2 || s[0] < 'a' || int(s[0]-'a') >= width || s[1] < '1' || int(s[1]-'0') > height {
+		return 0, 0, fmt.Errorf("invalid square %q (expected a1-%s)", s, squareName(width-1, 0, height))
+	}
+	return int(s[0] - 'a'), height - int(s[1]-'0'), nil
+}
+
+// recordSize - ขนาดกระดานเริ่มต้นของ variant ใน record (variant ที่ไม่รู้จักใช้ 3x3)
+func recordSize(variant string) (int, int) {
+	if v, ok := variants[variant]; ok {
+		width, height, _, _ := v.Defaults()
+		return width, height
+	}
+	return 3, 3
+}
+
+// recordResult - Result / Termination ของเกมใน games
+func recordResult(g *Game) (string, string) {
+	result := ResultNoResult
+	if g.WinnerID != nil {
+		result = ResultXWins
+		if *g.WinnerID != g.Player1ID {
+			result = ResultOWins
+		}
+	}
+
+	switch g.Status {
+	case "FINISHED", "DRAW":
+		termination := TerminationNormal
+		if g.Status == "DRAW" {
+			result = ResultDraw
+			if g.TerminationReason != nil && earlyDrawRules[*g.TerminationReason] != "" {
+				termination = *g.TerminationReason
+			}
+		}
+		onBoard := recordBoardResult(g.Board, termination)
+		if _, standard := variantOf(g).(standardVariant); !standard {
+			winner, _ := variantOf(g).Outcome(g)
+			onBoard = signResult(winner)
+		}
+		if onBoard != result {
+			return result, TerminationAdjudicated
+		}
+		return result, termination
+	case "ABANDONED":
+		return result, TerminationAbandoned
+	case "VOIDED":
+		return ResultNoResult, TerminationVoided
+	}
+	return ResultNoResult, TerminationUnfinished
+}
+
+// boardResult - ผลจากกระดาน 3x3 ล้วนๆ ผ่าน CheckWinner ("" = ยังไม่จบ)
+func boardResult(board string) string {
+	winner, _ := CheckWinner(board)
+	return signResult(winner)
+}
+
+// signResult - "X" / "O" / "DRAW" จาก CheckWinner หรือ Variant.Outcome -> Result ("" = ยังไม่จบ)
+func signResult(winner string) string {
+	switch winner {
+	case "X":
+		return ResultXWins
+	case "O":
+		return ResultOWins
+	case "DRAW":
+		return ResultDraw
+	}
+	return ""
+}
+
+// earlyDrawRules - Termination ของเกมที่เสมอก่อนกระดานเต็ม -> draw_rule ที่ใช้ตรวจกระดาน
+var earlyDrawRules = map[string]string{
+	TerminationDeadPosition: DrawRuleDeadPosition,
+	TerminationForcedDraw:   DrawRulePerfectPlay,
+}
+
+// recordBoardResult - เหมือน boardResult แต่นับกระดานที่ EarlyDraw ตัดสินเสมอแล้วด้วย ถ้า Termination เป็นแบบเสมอก่อนกระดานเต็ม
+func recordBoardResult(board, termination string) string {
+	if result := boardResult(board); result != "" {
+		return result
+	}
+	if rule := earlyDrawRules[termination]; rule != "" && EarlyDraw(board, rule) != "" {
+		return ResultDraw
+	}
+	return ""
+}
+
+// NewGameRecord - สร้าง record จากห้องเกม + ประวัติการเดิน (names: user id -> username)
+func NewGameRecord(g *Game, moves []Move, names map[int]string) *GameRecord {
+	r := &GameRecord{
+		Room:    g.RoomCode,
+		Variant: VariantStandard,
+		PlayerX: names[g.Player1ID],
+	}
+	if g.Variant != "" {
+		r.Variant = g.Variant
+	}
+	r.Width, r.Height = recordSize(r.Variant)
+	if g.Width != 0 && g.Height != 0 {
+		r.Width, r.Height = g.Width, g.Height
+	}
+	if g.Player2ID != nil {
+		r.PlayerO = names[*g.Player2ID]
+	}
+	r.Result, r.Termination = recordResult(g)
+
+	start := g.CreatedAt.UTC()
+	r.Start = &start
+	for _, m := range moves {
+		r.Moves = append(r.Moves, RecordMove{X: m.X, Y: m.Y})
+	}
+	if r.Termination != TerminationUnfinished && len(moves) > 0 {
+		end := moves[len(moves)-1].CreatedAt.UTC()
+		r.End = &end
+	}
+	return r
+}
+
+// String - เขียน record เป็นข้อความ
+func (r *GameRecord) String() string {
+	var b strings.Builder
+	tag := func(name, value string) {
+		value = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+		fmt.Fprintf(&b, "[%s \"%s\"]\n", name, value)
+	}
+
+	tag("Event", "Tic-Tac-Toe")
+	if r.Room != "" {
+		tag("Room", r.Room)
+	}
+	tag("Variant", r.Variant)
+	if width, height := recordSize(r.Variant); r.Width != width || r.Height != height {
+		tag("Size", fmt.Sprintf("%dx%d", r.Width, r.Height))
+	}
+	if r.Start != nil {
+		tag("Date", r.Start.Format("2006.01.02"))
+		tag("Start", r.Start.Format(time.RFC3339))
+	}
+	if r.End != nil {
+		tag("End", r.End.Format(time.RFC3339))
+	}
+	tag("X", r.PlayerX)
+	tag("O", r.PlayerO)
+	tag("Result", r.Result)
+	tag("Termination", r.Termination)
+	b.WriteString("\n")
+
+	var tokens []string
+	for i, m := range r.Moves {
+		if i%2 == 0 {
+			tokens = append(tokens, strconv.Itoa(i/2+1)+".")
+		}
+		tokens = append(tokens, squareName(m.X, m.Y, r.Height))
+	}
+	tokens = append(tokens, r.Result)
+	b.WriteString(strings.Join(tokens, " "))
+	b.WriteString("\n")
+	return b.String()
+}
+
+var (
+	recordTagLine    = regexp.MustCompile(`^\[([A-Za-z]+)\s+"((?:[^"\\]|\\.)*)"\]$`)
+	recordMoveNumber = regexp.MustCompile(`^\d+\.$`)
+)
+
+// ParseRecord - อ่าน record จากข้อความ (ตรวจแค่รูปแบบ ความถูกต้องของเกมดูที่ Replay)
+func ParseRecord(text string) (*GameRecord, error) {
+	if len(text) > recordMaxLength {
+		return nil, fmt.Errorf("record is too large")
+	}
+
+	r := &GameRecord{Variant: "standard"}
+	tags := map[string]string{}
+	var movetext []string
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && len(movetext) == 0 {
+			m := recordTagLine.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("invalid tag line %q", line)
+			}
+			tags[m[1]] = strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(m[2])
+			continue
+		}
+		movetext = append(movetext, strings.Fields(line)...)
+	}
+
+	r.Room = tags["Room"]
+	if v := tags["Variant"]; v != "" {
+		r.Variant = v
+	}
+	r.Width, r.Height = recordSize(r.Variant)
+	if v, ok := tags["Size"]; ok {
+		if _, err := fmt.Sscanf(v, "%dx%d", &r.Width, &r.Height); err != nil ||
+			r.Width < minBoardSize || r.Width > maxBoardSize || r.Height < minBoardSize || r.Height > maxBoardSize {
+			return nil, fmt.Errorf("invalid board size %q", v)
+		}
+	}
+	r.PlayerX, r.PlayerO = tags["X"], tags["O"]
+	r.Result, r.Termination = tags["Result"], tags["Termination"]
+	for name, dst := range map[string]**time.Time{"Start": &r.Start, "End": &r.End} {
+		if v, ok := tags[name]; ok {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s timestamp %q", name, v)
+			}
+			*dst = &t
+		}
+	}
+
+	for i, token := range movetext {
+		switch {
+		case recordMoveNumber.MatchString(token):
+			continue
+		case token == ResultXWins || token == ResultOWins || token == ResultDraw || token == ResultNoResult:
+			if i != len(movetext)-1 {
+				return nil, fmt.Errorf("moves after result %q", token)
+			}
+			if r.Result == "" {
+				r.Result = token
+			} else if r.Result != token {
+				return nil, fmt.Errorf("result %q does not match Result tag %q", token, r.Result)
+			}
+		default:
+			x, y, err := parseSquare(token, r.Width, r.Height)
+			if err != nil {
+				return nil, err
+			}
+			r.Moves = append(r.Moves, RecordMove{X: x, Y: y})
+		}
+	}
+
+	if r.Result == "" {
+		return nil, fmt.Errorf("missing result")
+	}
+	return r, nil
+}
+
+// Replay - เล่นตาม record บนกระดานเปล่า 3x3 (standard) คืนกระดานสุดท้าย
+// error ถ้ากระดานไม่ใช่ 3x3 ลงช่องซ้ำ หรือยังเดินต่อหลังเกมจบ
+func (r *GameRecord) Replay() (string, error) {
+	if r.Width != 3 || r.Height != 3 {
+		return "", fmt.Errorf("cannot replay a %dx%d board", r.Width, r.Height)
+	}
+	board := []byte("---------")
+	for i, m := range r.Moves {
+		if recordBoardResult(string(board), r.Termination) != "" {
+			return "", fmt.Errorf("move %d (%s) is played after the game has ended", i+1, squareName(m.X, m.Y, 3))
+		}
+		index := m.Y*3 + m.X
+		if board[index] != '-' {
+			return "", fmt.Errorf("move %d (%s) is on an occupied square", i+1, squareName(m.X, m.Y, 3))
+		}
+		board[index] = "XO"[i%2]
+	}
+	return string(board), nil
+}
+
+// Record - แปลงเกมที่นำเข้าไว้กลับเป็น record
+func (a *ArchivedGame) Record() *GameRecord {
+	r := &GameRecord{
+		Room:        a.RoomCode,
+		Variant:     a.Variant,
+		PlayerX:     a.PlayerX,
+		PlayerO:     a.PlayerO,
+		Result:      a.Result,
+		Termination: a.Termination,
+		Start:       a.StartedAt,
+		End:         a.EndedAt,
+	}
+	r.Width, r.Height = recordSize(a.Variant)
+	for _, square := range strings.Fields(a.Moves) {
+		if x, y, err := parseSquare(square, r.Width, r.Height); err == nil {
+			r.Moves = append(r.Moves, RecordMove{X: x, Y: y})
+		}
+	}
+	return r
+}
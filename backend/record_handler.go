@@ -0,0 +1,173 @@
+// backend/record_handler.go
+package main
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// playerNames - user id -> username ของผู้เล่นในห้อง (หาไม่เจอก็ข้ามไป)
+func (s *Server) playerNames(g *Game) map[int]string {
+	ids := []int{g.Player1ID}
+	if g.Player2ID != nil {
+		ids = append(ids, *g.Player2ID)
+	}
+	names := map[int]string{}
+	for _, id := range ids {
+		if name, err := s.Users.Username(id); err == nil {
+			names[id] = name
+		}
+	}
+	return names
+}
+
+// GetGameRecordHandler - ส่งออกเกมเป็น game record (ดูรูปแบบใน record.go)
+func (s *Server) GetGameRecordHandler(c *gin.Context) {
+	game, err := s.Games.GetGame(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		return
+	}
+	// record รองรับแค่กระดาน 3x3 แบบ standard (import ก็รับแค่ variant นี้ และ Replay เล่นบนกระดาน 3x3)
+	if game.Variant != VariantStandard {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Game records are not available for the " + game.Variant + " variant"})
+		return
+	}
+	moves, err := s.Games.ListMoves(game.RoomCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moves"})
+		return
+	}
+
+	record := NewGameRecord(game, moves, s.playerNames(game))
+	c.Header("Content-Disposition", `inline; filename="game-`+game.RoomCode+`.txt"`)
+	c.String(http.StatusOK, record.String())
+}
+
+// ImportGameRecordHandler - นำเข้า game record (ส่งเป็นข้อความตรงๆ หรือ JSON {"record": "..."})
+// ต้องเล่นจบบนกระดานจริง และ Result ต้องตรงกับที่ CheckWinner คำนวณได้ ถึงจะเก็บเป็นเกมที่จบแล้ว
+func (s *Server) ImportGameRecordHandler(c *gin.Context) {
+	userIDContext, _ := c.Get("userID")
+	userID := userIDContext.(int)
+
+	var text string
+	if strings.HasPrefix(c.ContentType(), "application/json") {
+		var req struct {
+			Record string `json:"record" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		text = req.Record
+	} else {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, recordMaxLength+1))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		text = string(body)
+	}
+
+	record, err := ParseRecord(text)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game record: " + err.Error()})
+		return
+	}
+	if record.Variant != "standard" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Unsupported variant: " + record.Variant})
+		return
+	}
+	if record.Width != 3 || record.Height != 3 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Unsupported board size: " + strconv.Itoa(record.Width) + "x" + strconv.Itoa(record.Height)})
+		return
+	}
+	if record.PlayerX == "" || record.PlayerO == "" || len(record.PlayerX) > 50 || len(record.PlayerO) > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "X and O tags are required (max 50 characters)"})
+		return
+	}
+	if len(record.Room) > 6 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Room tag must be at most 6 characters"})
+		return
+	}
+
+	// 1. เล่นตาม record บนกระดานเปล่า 3x3 (ลงช่องซ้ำ / เดินหลังจบ = record ผิด)
+	board, err := record.Replay()
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2. ผลต้องตรงกับกระดาน
+	result := recordBoardResult(board, record.Termination)
+	if result == "" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Record does not reach a finished position. Only games decided on the board can be imported."})
+		return
+	}
+	if record.Result != result {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Result " + record.Result + " does not match the final position (" + result + ")"})
+		return
+	}
+
+	termination := TerminationNormal
+	if boardResult(board) == "" {
+		termination = record.Termination // เสมอก่อนกระดานเต็ม (dead_position / forced_draw)
+	}
+
+	squares := make([]string, len(record.Moves))
+	for i, m := range record.Moves {
+		squares[i] = squareName(m.X, m.Y, 3)
+	}
+	archived := ArchivedGame{
+		RoomCode:    record.Room,
+		Variant:     record.Variant,
+		PlayerX:     record.PlayerX,
+		PlayerO:     record.PlayerO,
+		Result:      result,
+		Termination: termination,
+		Board:       board,
+		Moves:       strings.Join(squares, " "),
+		StartedAt:   utcOrNil(record.Start),
+		EndedAt:     utcOrNil(record.End),
+		ImportedBy:  userID,
+	}
+	if err := s.Games.ArchiveGame(&archived); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store game"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Game imported",
+		"id":      archived.ID,
+		"result":  archived.Result,
+		"board":   archived.Board,
+	})
+}
+
+// GetArchivedGameHandler - ดูเกมที่นำเข้าไว้ พร้อม record ที่เขียนใหม่ในรูปแบบมาตรฐาน
+func (s *Server) GetArchivedGameHandler(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("archive_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Archive id must be a number"})
+		return
+	}
+	archived, err := s.Games.GetArchivedGame(id)
+	if respondStoreError(c, err, "Archived game not found", "Failed to fetch archived game") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"game": archived, "record": archived.Record().String()})
+}
+
+func utcOrNil(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
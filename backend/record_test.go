@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testRecord - record standard ที่ X ชนะแถวบน (a3 b3 c3) แทน tag / movetext ได้
+func testRecord(tags, movetext string) string {
+	if tags == "" {
+		tags = "[X \"alice\"]\n[O \"bob\"]\n[Result \"1-0\"]"
+	}
+	return tags + "\n\n" + movetext + "\n"
+}
+
+func TestParseRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr string
+	}{
+		{"valid", testRecord("", "1. a3 b2 2. b3 c1 3. c3 1-0"), ""},
+		{"result only in movetext", testRecord("[X \"alice\"]\n[O \"bob\"]", "1. a3 b2 2. b3 c1 3. c3 1-0"), ""},
+		{"escaped tag value", testRecord("[X \"al\\\"ice\"]\n[O \"bob\"]\n[Result \"1-0\"]", "1. a3 b2 2. b3 c1 3. c3 1-0"), ""},
+		{"unquoted tag value", testRecord("[X alice]", "1. a3 b2 2. b3 c1 3. c3 1-0"), "invalid tag line"},
+		{"unterminated tag", testRecord("[X \"alice\"", "1. a3 b2 2. b3 c1 3. c3 1-0"), "invalid tag line"},
+		{"moves after result", testRecord("", "1. a3 b2 2. b3 c1 1-0 3. c3"), "moves after result"},
+		{"two results", testRecord("", "1. a3 b2 2. b3 c1 3. c3 1-0 1-0"), "moves after result"},
+		{"result contradicts tag", testRecord("", "1. a3 b2 2. b3 c1 3. c3 0-1"), "does not match Result tag"},
+		{"missing result", testRecord("[X \"alice\"]\n[O \"bob\"]", "1. a3 b2 2. b3 c1 3. c3"), "missing result"},
+		{"bad start timestamp", testRecord("[Start \"yesterday\"]\n[Result \"1-0\"]", "1. a3 b2 2. b3 c1 3. c3"), "invalid Start timestamp"},
+		{"bad end timestamp", testRecord("[End \"2026-10-19 00:20:31\"]\n[Result \"1-0\"]", "1. a3 b2 2. b3 c1 3. c3"), "invalid End timestamp"},
+		{"square off the board", testRecord("", "1. a3 d2 1-0"), "invalid square"},
+		{"row off the board", testRecord("", "1. a4 b2 1-0"), "invalid square"},
+		{"bad size tag", testRecord("[Size \"big\"]\n[Result \"1-0\"]", "1. a3 1-0"), "invalid board size"},
+		{"size too small", testRecord("[Size \"2x2\"]\n[Result \"1-0\"]", "1. a2 1-0"), "invalid board size"},
+		{"oversized input", testRecord("", strings.Repeat("1. a3 ", recordMaxLength/6+1)+"1-0"), "too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRecord(tt.text)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ParseRecord: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseRecordFields(t *testing.T) {
+	text := testRecord("[Room \"205766\"]\n[X \"al\\\"ice\"]\n[O \"bob\"]\n[Start \"2026-10-19T00:19:47Z\"]\n[Result \"1-0\"]\n[Termination \"normal\"]",
+		"1. a3 b2 2. b3 c1 3. c3 1-0")
+	r, err := ParseRecord(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Room != "205766" || r.Variant != "standard" || r.PlayerX != `al"ice` || r.PlayerO != "bob" || r.Result != ResultXWins || r.Termination != TerminationNormal {
+		t.Fatalf("record = %+v", r)
+	}
+	if r.Width != 3 || r.Height != 3 || r.End != nil || r.Start == nil || !r.Start.Equal(time.Date(2026, 10, 19, 0, 19, 47, 0, time.UTC)) {
+		t.Fatalf("size %dx%d, start %v, end %v", r.Width, r.Height, r.Start, r.End)
+	}
+	want := []RecordMove{{0, 0}, {1, 1}, {1, 0}, {2, 2}, {2, 0}}
+	if fmt.Sprint(r.Moves) != fmt.Sprint(want) {
+		t.Fatalf("moves = %v, want %v", r.Moves, want)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantBoard string
+		wantErr   string
+	}{
+		{"x wins", testRecord("", "1. a3 b2 2. b3 c1 3. c3 1-0"), "XXX-O---O", ""},
+		{"full board draw", testRecord("[Result \"1/2-1/2\"]", "1. a3 b2 2. c1 b3 3. b1 a1 4. c3 c2 5. a2 1/2-1/2"), "XOXXOOOXX", ""},
+		{"unfinished", testRecord("[Result \"*\"]", "1. a3 b2 *"), "X---O----", ""},
+		{"occupied square", testRecord("", "1. a3 b2 2. b2 1-0"), "", "move 3 (b2) is on an occupied square"},
+		{"move after the game ended", testRecord("", "1. a3 b2 2. b3 c1 3. c3 a1 1-0"), "", "move 6 (a1) is played after the game has ended"},
+		{"not a 3x3 board", testRecord("[Size \"4x4\"]\n[Result \"1-0\"]", "1. d1 1-0"), "", "cannot replay a 4x4 board"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseRecord(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			board, err := r.Replay()
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || board != tt.wantBoard {
+				t.Fatalf("board = %q, %v; want %q", board, err, tt.wantBoard)
+			}
+		})
+	}
+}
+
+func TestImportGameRecordHandler(t *testing.T) {
+	s, h := newTestServer(t)
+	_, token := newTestUser(t, s, "alice")
+
+	tests := []struct {
+		name   string
+		record string
+		status int
+	}{
+		{"x wins", testRecord("", "1. a3 b2 2. b3 c1 3. c3 1-0"), http.StatusCreated},
+		{"unparsable", testRecord("[X alice]", "1. a3 1-0"), http.StatusBadRequest},
+		{"missing players", testRecord("[Result \"1-0\"]", "1. a3 b2 2. b3 c1 3. c3"), http.StatusBadRequest},
+		{"room too long", testRecord("[Room \"1234567\"]\n[X \"alice\"]\n[O \"bob\"]\n[Result \"1-0\"]", "1. a3 b2 2. b3 c1 3. c3"), http.StatusBadRequest},
+		{"non-standard variant", testRecord("[Variant \"gravity\"]\n[X \"alice\"]\n[O \"bob\"]\n[Result \"1-0\"]", "1. a6 1-0"), http.StatusUnprocessableEntity},
+		{"standard on a 4x4 board", testRecord("[Size \"4x4\"]\n[X \"alice\"]\n[O \"bob\"]\n[Result \"1-0\"]", "1. d1 a4 2. d2 a3 3. d3 1-0"), http.StatusUnprocessableEntity},
+		{"occupied square", testRecord("", "1. a3 b2 2. b2 1-0"), http.StatusUnprocessableEntity},
+		{"unfinished position", testRecord("", "1. a3 b2 1-0"), http.StatusUnprocessableEntity},
+		{"result does not match position", testRecord("[X \"alice\"]\n[O \"bob\"]\n[Result \"0-1\"]", "1. a3 b2 2. b3 c1 3. c3"), http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doRequest(t, h, http.MethodPost, "/api/games/import", token, gin.H{"record": tt.record})
+			if w.Code != tt.status {
+				t.Fatalf("import: %d %s, want %d", w.Code, w.Body.String(), tt.status)
+			}
+		})
+	}
+}
+
+func TestGameRecordRoundTrip(t *testing.T) {
+	s, h := newTestServer(t)
+	_, hostToken := newTestUser(t, s, "host")
+	_, guestToken := newTestUser(t, s, "guest")
+
+	room := startTestGame(t, h, hostToken, guestToken, nil)
+	playMoves(t, h, room, hostToken, guestToken, [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}, {0, 2}})
+	w := doRequest(t, h, http.MethodGet, "/api/games/"+room+"/record", hostToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("export: %d %s", w.Code, w.Body.String())
+	}
+	exported := w.Body.String()
+
+	w = doRequest(t, h, http.MethodPost, "/api/games/import", guestToken, gin.H{"record": exported})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("import: %d %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	if body["result"] != ResultXWins || body["board"] != "XO-XO-X--" {
+		t.Fatalf("import = %v", body)
+	}
+
+	w = doRequest(t, h, http.MethodGet, fmt.Sprintf("/api/games/archive/%d", int(body["id"].(float64))), guestToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("archive: %d %s", w.Code, w.Body.String())
+	}
+	if got := decodeBody(t, w)["record"]; got != exported {
+		t.Fatalf("archived record:\n%v\nwant the exported record:\n%s", got, exported)
+	}
+
+	// variant อื่นส่งออกเป็น record ไม่ได้
+	gravity := startTestGame(t, h, hostToken, guestToken, gin.H{"variant": "gravity"})
+	if w := doRequest(t, h, http.MethodGet, "/api/games/"+gravity+"/record", hostToken, nil); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("gravity export: %d, want 422", w.Code)
+	}
+}
+
+// guest ที่นำเข้า record ไว้ลบไม่ได้ (archived_games.imported_by อ้างถึง) ต้อง anonymize แทน
+func TestCleanupStaleGuestsKeepsImportedRecords(t *testing.T) {
+	for _, st := range []struct {
+		name      string
+		newServer func(t *testing.T) (*Server, http.Handler)
+	}{{"memory", newTestServer}, {"sqlite", newSQLiteTestServer}} {
+		t.Run(st.name, func(t *testing.T) {
+			s, h := st.newServer(t)
+			guestID, guestToken := newTestGuest(t, h)
+			w := doRequest(t, h, http.MethodPost, "/api/games/import", guestToken, gin.H{"record": testRecord("", "1. a3 b2 2. b3 c1 3. c3 1-0")})
+			if w.Code != http.StatusCreated {
+				t.Fatalf("import: %d %s", w.Code, w.Body.String())
+			}
+			archiveID := int(decodeBody(t, w)["id"].(float64))
+
+			deleted, anonymized, err := s.Users.CleanupStaleGuests(time.Now().Add(time.Hour))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if deleted != 0 || anonymized != 1 {
+				t.Fatalf("deleted %d, anonymized %d; want 0 and 1", deleted, anonymized)
+			}
+			if u, err := s.Users.UserByID(guestID); err != nil || u.DeletedAt == nil {
+				t.Fatalf("guest: %+v, %v; want anonymized", u, err)
+			}
+			if a, err := s.Games.GetArchivedGame(archiveID); err != nil || a.ImportedBy != guestID {
+				t.Fatalf("archived game: %+v, %v", a, err)
+			}
+		})
+	}
+}
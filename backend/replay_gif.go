@@ -0,0 +1,111 @@
+// backend/replay_gif.go
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"sync"
+)
+
+// ภาพ replay แบบ GIF เคลื่อนไหว: เฟรมละหนึ่งการเดิน (เริ่มจากกระดานเปล่า) ปิดท้ายด้วยเฟรมผลเกม
+const (
+	defaultReplayDelayMS = 1000
+	minReplayDelayMS     = 100
+	maxReplayDelayMS     = 10000
+	// resultFrameDelayMS - เฟรมผลเกมค้างไว้อย่างน้อยเท่านี้ก่อนวนกลับไปเริ่มใหม่
+	resultFrameDelayMS = 3000
+	replayCacheSize    = 256
+)
+
+// replayPalette - สีทั้งหมดที่ boardScene.Image() วาดได้ (สีพื้น/ไฮไลต์ ผสมกับสีเส้นและตัวอักษรแบบไล่ระดับ)
+// เลือกสีที่ใกล้ที่สุดโดยไม่ dither ขอบ anti-aliased จึงยังเรียบเหมือน PNG
+var replayPalette = func() color.Palette {
+	bases := []color.RGBA{colorBackground, colorHighlight, colorX, colorO}
+	inks := []color.RGBA{colorGrid, colorX, colorO, colorText}
+	const steps = 16
+
+	p := color.Palette{colorBackground}
+	for _, base := range bases {
+		for _, ink := range inks {
+			if base == ink {
+				continue
+			}
+			for i := 1; i <= steps; i++ {
+				a := float64(i) / steps
+				mix := func(src, dst uint8) uint8 { return uint8(float64(src)*a + float64(dst)*(1-a) + 0.5) }
+				p = append(p, color.RGBA{mix(ink.R, base.R), mix(ink.G, base.G), mix(ink.B, base.B), 0xff})
+			}
+		}
+	}
+	return p
+}()
+
+// gameOver - เกมจบแล้ว state จะไม่เปลี่ยนอีก (ยกเว้น admin void / rematch ซึ่งจะเพิ่ม version)
+func gameOver(g *Game) bool {
+	return g.Status != "WAITING" && g.Status != "IN_PROGRESS"
+}
+
+// encodeReplayGIF - GIF วนซ้ำของทั้งเกม delayMS = เวลาต่อเฟรม (มิลลิวินาที)
+func encodeReplayGIF(g *Game, moves []Move, names map[int]string, delayMS int) ([]byte, error) {
+	anim := &gif.GIF{LoopCount: 0}
+	addFrame := func(scene *boardScene, delayMS int) {
+		src := scene.Image()
+		frame := image.NewPaletted(src.Bounds(), replayPalette)
+		draw.Draw(frame, frame.Bounds(), src, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delayMS/10) // หน่วยของ GIF คือ 1/100 วินาที
+	}
+
+	for i := 0; i <= len(moves); i++ {
+		addFrame(newBoardScene(g, moves, names, i), delayMS)
+	}
+	addFrame(newBoardScene(g, moves, names, -1), max(delayMS, resultFrameDelayMS))
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReplayCache - GIF ของเกมที่จบแล้ว (key = ห้อง + version + delay) เก็บในหน่วยความจำ
+// เต็มแล้วจะทิ้งอันที่เก่าที่สุด
+type ReplayCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[string][]byte
+	order   []string
+}
+
+func NewReplayCache(size int) *ReplayCache {
+	return &ReplayCache{size: size, entries: make(map[string][]byte)}
+}
+
+func replayCacheKey(g *Game, delayMS int) string {
+	return fmt.Sprintf("%s/%d/%d", g.RoomCode, g.Version, delayMS)
+}
+
+func (c *ReplayCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.entries[key]
+	return data, ok
+}
+
+func (c *ReplayCache) Put(key string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, exists := c.entries[key]; exists {
+		return
+	}
+	if len(c.order) >= c.size {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[key] = data
+	c.order = append(c.order, key)
+}
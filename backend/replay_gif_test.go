@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"image/gif"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func decodeGIF(t *testing.T, data []byte) *gif.GIF {
+	t.Helper()
+	anim, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return anim
+}
+
+func TestReplayGIFHandler(t *testing.T) {
+	s, h := newTestServer(t)
+	_, hostToken := newTestUser(t, s, "host")
+	_, guestToken := newTestUser(t, s, "guest")
+	room := startTestGame(t, h, hostToken, guestToken, nil)
+	path := "/api/games/" + room + "/replay.gif"
+
+	// เกมที่ยังเล่นอยู่ render ได้แต่ไม่เก็บ cache
+	playMoves(t, h, room, hostToken, guestToken, [][2]int{{0, 0}})
+	w := doRequest(t, h, http.MethodGet, path, hostToken, nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/gif" {
+		t.Fatalf("status = %d, type = %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	if len(s.Replays.entries) != 0 {
+		t.Fatal("in-progress replay was cached")
+	}
+
+	playMoves(t, h, room, guestToken, hostToken, [][2]int{{0, 1}, {1, 0}, {1, 1}})
+	playMoves(t, h, room, hostToken, guestToken, [][2]int{{2, 0}})
+
+	// กระดานเปล่า + ทีละตา (5 ตา) + เฟรมผลเกมที่ค้างไว้นานกว่า
+	w = doRequest(t, h, http.MethodGet, path+"?delay=500", hostToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+	anim := decodeGIF(t, w.Body.Bytes())
+	if want := []int{50, 50, 50, 50, 50, 50, resultFrameDelayMS / 10}; !reflect.DeepEqual(anim.Delay, want) {
+		t.Fatalf("delays = %v, want %v", anim.Delay, want)
+	}
+	if b := anim.Image[0].Bounds(); b.Dx() != imageWidth || b.Dy() != imageHeight {
+		t.Fatalf("frame size = %v", b)
+	}
+
+	// เกมจบแล้ว: เก็บ cache และขอซ้ำได้ข้อมูลเดิม
+	g, _ := s.Games.GetGame(room)
+	cached, ok := s.Replays.Get(replayCacheKey(g, 500))
+	if !ok || !bytes.Equal(cached, w.Body.Bytes()) {
+		t.Fatal("finished replay was not cached")
+	}
+	if again := doRequest(t, h, http.MethodGet, path+"?delay=500", hostToken, nil); !bytes.Equal(again.Body.Bytes(), cached) {
+		t.Fatal("cached replay differs")
+	}
+
+	// delay นานกว่าเฟรมผลเกม ใช้ delay นั้นกับเฟรมสุดท้ายด้วย
+	anim = decodeGIF(t, doRequest(t, h, http.MethodGet, path+"?delay=5000", hostToken, nil).Body.Bytes())
+	if last := anim.Delay[len(anim.Delay)-1]; last != 500 {
+		t.Fatalf("last frame delay = %d, want 500", last)
+	}
+
+	for _, query := range []string{"?delay=99", "?delay=10001", "?delay=fast"} {
+		if w := doRequest(t, h, http.MethodGet, path+query, hostToken, nil); w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: %d, want 400", query, w.Code)
+		}
+	}
+	if w := doRequest(t, h, http.MethodGet, "/api/games/000000/replay.gif", hostToken, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown game: %d, want 404", w.Code)
+	}
+}
+
+func TestReplayCacheEvictsOldest(t *testing.T) {
+	c := NewReplayCache(2)
+	c.Put("a", []byte("1"))
+	c.Put("b", []byte("2"))
+	c.Put("a", []byte("ignored"))
+	c.Put("c", []byte("3"))
+
+	if _, ok := c.Get("a"); ok {
+		t.Fatal("oldest entry was not evicted")
+	}
+	for key, want := range map[string]string{"b": "2", "c": "3"} {
+		if data, ok := c.Get(key); !ok || string(data) != want {
+			t.Fatalf("%s = %q, %v", key, data, ok)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+// backend/sanctions.go
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ประเภทการลงโทษ (user_bans.kind)
+// BAN        = ห้ามใช้งานทั้งหมด: login ไม่ได้ และ token ที่มีอยู่ใช้ไม่ได้ทันที
+// SUSPENSION = พักการเล่นชั่วคราว: ยัง login/ดูเกมได้ แต่สร้างห้อง/เข้าห้องไม่ได้จนกว่าจะหมดเวลา
+const (
+	SanctionBan        = "BAN"
+	SanctionSuspension = "SUSPENSION"
+)
+
+// activeSanctionCondition - เงื่อนไขว่ายังมีผลอยู่ (ยังไม่ถูกยกเลิก และยังไม่หมดอายุ) ใช้กับ user_bans ที่ตั้ง alias เป็น b
+const activeSanctionCondition = `b.lifted_at IS NULL AND (b.expires_at IS NULL OR b.expires_at > CURRENT_TIMESTAMP)`
+
+const selectSanctionColumns = `SELECT b.id, b.user_id, b.kind, b.reason, b.banned_by, b.expires_at, b.lifted_at, b.lifted_by, b.created_at FROM user_bans b`
+
+func scanSanction(row interface{ Scan(...interface{}) error }) (UserBan, error) {
+	var b UserBan
+	err := row.Scan(&b.ID, &b.UserID, &b.Kind, &b.Reason, &b.BannedBy, &b.ExpiresAt, &b.LiftedAt, &b.LiftedBy, &b.CreatedAt)
+	return b, err
+}
+
+func sanctionResponse(message string, ban *UserBan) gin.H {
+	return gin.H{"error": message, "reason": ban.Reason, "expires_at": ban.ExpiresAt}
+}
+
+// rejectIfBanned - ใช้ใน flow login ทุกแบบ ถ้าโดนแบนอยู่จะตอบ 403 และคืน true
+func (s *Server) rejectIfBanned(c *gin.Context, userID int) bool {
+	ban, err := s.Users.ActiveSanction(userID, SanctionBan)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check account status"})
+		return true
+	}
+	if ban != nil {
+		c.JSON(http.StatusForbidden, sanctionResponse("Your account has been banned", ban))
+		return true
+	}
+	return false
+}
+
+// rejectIfSuspended - ใช้ก่อนสร้าง/เข้าห้องเกม ถ้าโดนพักการเล่นอยู่จะตอบ 403 และคืน true
+func (s *Server) rejectIfSuspended(c *gin.Context, userID int) bool {
+	ban, err := s.Users.ActiveSanction(userID, SanctionSuspension)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check account status"})
+		return true
+	}
+	if ban != nil {
+		c.JSON(http.StatusForbidden, sanctionResponse("Your account is suspended from playing", ban))
+		return true
+	}
+	return false
+}
+
+// AdminBanUserHandler - แบนผู้ใช้ (expires_at ไม่ใส่ = ถาวร) มีผลกับ token ที่มีอยู่ทันที
+func (s *Server) AdminBanUserHandler(c *gin.Context) {
+	var req struct {
+		Reason    string     `json:"reason" binding:"required"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.applySanction(c, SanctionBan, req.Reason, req.ExpiresAt)
+}
+
+// AdminSuspendUserHandler - พักการเล่นชั่วคราว (ต้องกำหนด expires_at)
+func (s *Server) AdminSuspendUserHandler(c *gin.Context) {
+	var req struct {
+		Reason    string     `json:"reason" binding:"required"`
+		ExpiresAt *time.Time `json:"expires_at" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.applySanction(c, SanctionSuspension, req.Reason, req.ExpiresAt)
+}
+
+func (s *Server) applySanction(c *gin.Context, kind, reason string, expiresAt *time.Time) {
+	actorID := c.GetInt("userID")
+
+	if expiresAt != nil {
+		if !expiresAt.After(time.Now()) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at must be in the future"})
+			return
+		}
+		// คอลัมน์เป็น TIMESTAMP (ไม่มี timezone) เก็บเป็น UTC ให้เทียบกับ CURRENT_TIMESTAMP ได้ตรง
+		utc := expiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	targetID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	ban := UserBan{Kind: kind, Reason: reason, BannedBy: &actorID, ExpiresAt: expiresAt}
+	err := s.Users.WithUserLock(targetID, func(tx UserTx, u *User) error {
+		if err := checkCanManage(c, u); err != nil {
+			return err
+		}
+		existing, err := tx.ActiveSanction(kind)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			return reject(http.StatusConflict, "User already has an active "+kind)
+		}
+
+		if err := tx.AddSanction(&ban); err != nil {
+			return err
+		}
+		action := "ban_user"
+		if kind == SanctionSuspension {
+			action = "suspend_user"
+		}
+		return writeAuditLog(tx, actorID, action, "user", targetID, gin.H{"ban_id": ban.ID, "reason": reason, "expires_at": expiresAt})
+	})
+	if respondStoreError(c, err, "User not found", "Failed to save "+kind) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User sanctioned", "ban_id": ban.ID, "kind": kind, "expires_at": expiresAt})
+}
+
+// AdminUnbanUserHandler - ยกเลิกการแบน
+func (s *Server) AdminUnbanUserHandler(c *gin.Context) {
+	s.liftSanction(c, SanctionBan)
+}
+
+// AdminUnsuspendUserHandler - ยกเลิกการพักการเล่นก่อนกำหนด
+func (s *Server) AdminUnsuspendUserHandler(c *gin.Context) {
+	s.liftSanction(c, SanctionSuspension)
+}
+
+func (s *Server) liftSanction(c *gin.Context, kind string) {
+	actorID := c.GetInt("userID")
+
+	targetID, ok := targetUserID(c)
+	if !ok {
+		return
+	}
+
+	err := s.Users.WithUserLock(targetID, func(tx UserTx, u *User) error {
+		if err := checkCanManage(c, u); err != nil {
+			return err
+		}
+		lifted, err := tx.LiftSanction(kind, actorID)
+		if err != nil {
+			return err
+		}
+		if !lifted {
+			return reject(http.StatusBadRequest, "User has no active "+kind)
+		}
+
+		action := "unban_user"
+		if kind == SanctionSuspension {
+			action = "unsuspend_user"
+		}
+		return writeAuditLog(tx, actorID, action, "user", targetID, gin.H{})
+	})
+	if respondStoreError(c, err, "User not found", "Failed to lift "+kind) {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": kind + " lifted"})
+}
+
+// AdminUserBanStatusHandler - ดูสถานะการแบน/พักการเล่นปัจจุบัน และประวัติทั้งหมดของผู้ใช้
+func (s *Server) AdminUserBanStatusHandler(c *gin.Context) {
+	targetID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User id must be a number"})
+		return
+	}
+
+	ban, err1 := s.Users.ActiveSanction(targetID, SanctionBan)
+	suspension, err2 := s.Users.ActiveSanction(targetID, SanctionSuspension)
+	if err1 != nil || err2 != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ban status"})
+		return
+	}
+
+	history, err := s.Users.ListSanctions(targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch ban history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_id":    targetID,
+		"banned":     ban != nil,
+		"suspended":  suspension != nil,
+		"ban":        ban,
+		"suspension": suspension,
+		"history":    history,
+	})
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestAdmin - ผู้ใช้ role admin พร้อม token ที่มี role แล้ว
+func newTestAdmin(t *testing.T, s *Server, username string) (int, string) {
+	t.Helper()
+	adminID, _ := newTestUser(t, s, username)
+	err := s.Users.WithUserLock(adminID, func(tx UserTx, u *User) error {
+		u.Role = RoleAdmin
+		return tx.UpdateUser(u)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return adminID, testToken(t, s, adminID)
+}
+
+func TestBanBlocksExistingTokens(t *testing.T) {
+	s, h := newTestServer(t)
+	_, adminToken := newTestAdmin(t, s, "admin")
+	userID, token := newTestUser(t, s, "alice")
+	banPath := "/api/admin/users/" + strconv.Itoa(userID) + "/ban"
+
+	if w := doRequest(t, h, http.MethodPost, banPath, adminToken, gin.H{"reason": "cheating"}); w.Code != http.StatusOK {
+		t.Fatalf("ban: %d %s", w.Code, w.Body.String())
+	}
+	// token เดิมยัง valid แต่เจ้าของโดนแบน -> 403 ไม่ใช่ 401
+	w := doRequest(t, h, http.MethodGet, "/api/games/me/active", token, nil)
+	if w.Code != http.StatusForbidden || decodeBody(t, w)["error"] != "Your account has been banned" {
+		t.Fatalf("banned user: %d %s, want 403", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, h, http.MethodPost, banPath, adminToken, gin.H{"reason": "again"}); w.Code != http.StatusConflict {
+		t.Fatalf("second ban: %d, want 409", w.Code)
+	}
+
+	// ยกเลิกแบนแล้ว token เดิมใช้ต่อได้
+	if w := doRequest(t, h, http.MethodDelete, banPath, adminToken, gin.H{"reason": "appeal accepted"}); w.Code != http.StatusOK {
+		t.Fatalf("unban: %d %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, h, http.MethodGet, "/api/games/me/active", token, nil); w.Code != http.StatusOK {
+		t.Fatalf("after unban: %d %s", w.Code, w.Body.String())
+	}
+}
+
+// TestSuspensionBlocksNewGamesOnly - พักการเล่นแล้วยัง login ได้ แต่สร้างห้องไม่ได้
+func TestSuspensionBlocksNewGamesOnly(t *testing.T) {
+	s, h := newTestServer(t)
+	_, adminToken := newTestAdmin(t, s, "admin")
+	userID, token := newTestUser(t, s, "alice")
+	suspendPath := "/api/admin/users/" + strconv.Itoa(userID) + "/suspend"
+
+	if w := doRequest(t, h, http.MethodPost, suspendPath, adminToken, gin.H{"reason": "spam", "expires_at": "2000-01-01T00:00:00Z"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("suspension ending in the past: %d, want 400", w.Code)
+	}
+	until := time.Now().Add(time.Hour).Format(time.RFC3339)
+	if w := doRequest(t, h, http.MethodPost, suspendPath, adminToken, gin.H{"reason": "spam", "expires_at": until}); w.Code != http.StatusOK {
+		t.Fatalf("suspend: %d %s", w.Code, w.Body.String())
+	}
+
+	if w := doRequest(t, h, http.MethodGet, "/api/games/me/active", token, nil); w.Code != http.StatusOK {
+		t.Fatalf("suspended user lost their session: %d", w.Code)
+	}
+	if w := doRequest(t, h, http.MethodPost, "/api/games", token, gin.H{}); w.Code != http.StatusForbidden {
+		t.Fatalf("create game while suspended: %d %s, want 403", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,148 @@
+// backend/server.go
+package main
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// Server - รวม dependency ทั้งหมดของ handler (แทนการใช้ตัวแปร global)
+type Server struct {
+	Users    UserStore
+	Games    GameStore
+	Throttle LoginThrottle
+	// Idempotency - ผลลัพธ์ของ Idempotency-Key (ใช้โดย IdempotencyMiddleware)
+	Idempotency IdempotencyStore
+	// Replays - GIF replay ของเกมที่จบแล้ว
+	Replays *ReplayCache
+	// OIDC - nil ถ้าไม่ได้ตั้งค่า SSO ไว้
+	OIDC *OIDCProvider
+}
+
+// NewSQLServer - Server ที่เก็บข้อมูลทั้งหมดใน Database (PostgreSQL หรือ SQLite ตาม DB_DRIVER)
+func NewSQLServer(db *sql.DB) *Server {
+	return &Server{
+		Users:       &SQLUserStore{db: db},
+		Games:       &SQLGameStore{db: db},
+		Throttle:    &SQLLoginThrottle{db: db},
+		Idempotency: &SQLIdempotencyStore{db: db},
+		Replays:     NewReplayCache(replayCacheSize),
+	}
+}
+
+func (s *Server) storageName() string {
+	if _, ok := s.Games.(*MemoryStore); ok {
+		return "memory"
+	}
+	return "connected"
+}
+
+// Router - ผูก route ทั้งหมดเข้ากับ handler ของ Server
+func (s *Server) Router() *gin.Engine {
+	r := gin.Default()
+
+	config := cors.DefaultConfig()
+	config.AllowAllOrigins = true
+	config.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "If-Match", "If-None-Match", "Idempotency-Key"}
+	config.ExposeHeaders = []string{"ETag", "Idempotent-Replayed"}
+
+	r.Use(cors.New(config))
+
+	r.GET("/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "pong",
+			"status":  "server is running",
+			"db":      s.storageName(),
+		})
+	})
+
+	api := r.Group("/api")
+	{
+		// --- ระบบ Auth ---
+		api.POST("/register", s.RegisterHandler)
+		api.POST("/login", s.LoginHandler)
+		api.POST("/login/2fa", s.LoginTwoFactorHandler)
+		api.GET("/auth/oidc/login", s.OIDCLoginHandler)
+		api.GET("/auth/oidc/callback", s.OIDCCallbackHandler)
+
+		// --- เล่นแบบ Guest (ไม่ต้องสมัคร) ---
+		api.POST("/guest", s.CreateGuestHandler)
+		api.POST("/guest/upgrade", s.AuthMiddleware(), s.UpgradeGuestHandler)
+
+		// --- จัดการบัญชี ---
+		account := api.Group("/account")
+		account.Use(s.AuthMiddleware())
+		{
+			account.PUT("/password", s.ChangePasswordHandler)
+			account.PUT("/username", s.ChangeUsernameHandler)
+			account.DELETE("", s.DeleteAccountHandler)
+		}
+
+		// --- 2FA (TOTP) ---
+		twoFactor := api.Group("/2fa")
+		twoFactor.Use(s.AuthMiddleware())
+		{
+			twoFactor.POST("/setup", s.SetupTwoFactorHandler)
+			twoFactor.POST("/confirm", s.ConfirmTwoFactorHandler)
+			twoFactor.POST("/disable", s.DisableTwoFactorHandler)
+		}
+
+		// --- replay สาธารณะ (ไม่ต้อง login) ---
+		api.GET("/public/replays/:token", s.GetPublicReplayHandler)
+
+		// --- ระบบเกม ---
+		protected := api.Group("/games")
+		protected.Use(s.AuthMiddleware(), s.IdempotencyMiddleware())
+		{
+			protected.POST("", s.CreateGameHandler)
+			protected.POST("/join", s.JoinGameHandler)
+			protected.POST("/move", s.MakeMoveHandler)
+			protected.POST("/collapse", s.CollapseHandler) // quantum: เลือกช่องที่ spooky mark ยุบลง
+
+			protected.GET("/:id", s.GetGameHandler)            // ดูสถานะเกม
+			protected.GET("/:id/moves", s.GetGameMovesHandler) // ดูประวัติ
+			protected.GET("/:id/events", s.GetGameEventsHandler)
+			protected.GET("/:id/record", s.GetGameRecordHandler) // ส่งออกเป็น game record
+			protected.GET("/:id/board.svg", s.GetBoardSVGHandler)
+			protected.GET("/:id/board.png", s.GetBoardPNGHandler)
+			protected.GET("/:id/replay.gif", s.GetReplayGIFHandler)
+			protected.POST("/:id/share", s.ShareGameHandler)     // เปิดลิงก์สาธารณะ
+			protected.DELETE("/:id/share", s.UnshareGameHandler) // ปิดลิงก์สาธารณะ
+
+			protected.POST("/import", s.ImportGameRecordHandler)
+			protected.GET("/archive/:archive_id", s.GetArchivedGameHandler)
+
+			protected.DELETE("/:id", s.CancelGameHandler) //ทำลายห้อง
+
+			protected.POST("/:id/rematch", s.RematchHandler)
+			protected.POST("/:id/leave", s.LeaveGameHandler)
+			protected.GET("/me/active", s.GetMyActiveGameHandler)
+		}
+
+		// --- ระบบหลังบ้าน (moderator / admin) ---
+		admin := api.Group("/admin")
+		admin.Use(s.AuthMiddleware(), RequireRole(RoleModerator, RoleAdmin))
+		{
+			admin.GET("/users", s.AdminListUsersHandler)
+			admin.GET("/games", s.AdminListGamesHandler)
+			admin.POST("/games/:id/end", s.AdminEndGameHandler)
+			admin.POST("/games/:id/adjudicate", s.AdminAdjudicateGameHandler)
+			admin.GET("/users/:id/ban-status", s.AdminUserBanStatusHandler)
+			admin.POST("/users/:id/ban", s.AdminBanUserHandler)
+			admin.DELETE("/users/:id/ban", s.AdminUnbanUserHandler)
+			admin.POST("/users/:id/suspend", s.AdminSuspendUserHandler)
+			admin.DELETE("/users/:id/suspend", s.AdminUnsuspendUserHandler)
+
+			// admin เท่านั้น
+			admin.POST("/games/:id/void", RequireRole(RoleAdmin), s.AdminVoidGameHandler)
+			admin.PUT("/users/:id/role", RequireRole(RoleAdmin), s.AdminSetRoleHandler)
+			admin.GET("/audit", RequireRole(RoleAdmin), s.AdminListAuditLogHandler)
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,172 @@
+// backend/share_handler.go
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ลิงก์สาธารณะ: ผู้เล่นเปิดให้เกมที่จบแล้วดูได้โดยไม่ต้อง login
+// ข้อมูลที่ส่งออกมีแค่ชื่อผู้เล่น การเดิน และผล ไม่มี user id / room code
+const shareTokenBytes = 18
+
+// publicReplayMove - การเดินหนึ่งครั้งใน replay สาธารณะ (ระบุผู้เล่นด้วยเครื่องหมาย X / O แทน id)
+type publicReplayMove struct {
+	Number int    `json:"number"`
+	Mark   string `json:"mark"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Z      *int   `json:"z,omitempty"` // ชั้น (กระดาน 3 มิติเท่านั้น square เป็นช่องในชั้นนั้น)
+	Square string `json:"square"`
+}
+
+// publicPlayerName - ชื่อที่เปิดเผยได้ (บัญชีที่ถูกลบมีชื่อเป็น deleted_<id> ซึ่งมี id อยู่ในตัว)
+// username ปกติเป็น alphanum ล้วน จึงไม่มีใครชื่อขึ้นต้นด้วย deleted_ ได้
+func publicPlayerName(names map[int]string, id int) string {
+	name, ok := names[id]
+	if !ok || strings.HasPrefix(name, "deleted_") {
+		return "Deleted user"
+	}
+	return name
+}
+
+// sharedGameFor - ห้องของ :id ที่ผู้ใช้ที่ login อยู่เป็นผู้เล่น (ตอบ error ไปเองถ้าไม่สำเร็จ)
+func (s *Server) sharedGameFor(c *gin.Context) (*Game, bool) {
+	userIDContext, _ := c.Get("userID")
+	userID := userIDContext.(int)
+
+	game, err := s.Games.GetGame(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+		return nil, false
+	}
+	if userID != game.Player1ID && (game.Player2ID == nil || userID != *game.Player2ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You are not a player in this game"})
+		return nil, false
+	}
+	return game, true
+}
+
+// hasReplay - ตอบ 422 เองถ้าเป็นเกม quantum (ดู errQuantumHistory)
+func hasReplay(c *gin.Context, g *Game) bool {
+	if g.Variant == VariantQuantum {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": errQuantumHistory.Error()})
+		return false
+	}
+	return true
+}
+
+// ShareGameHandler - เปิดลิงก์สาธารณะของเกมที่จบแล้ว (เปิดไว้แล้วจะได้ token เดิม)
+func (s *Server) ShareGameHandler(c *gin.Context) {
+	userIDContext, _ := c.Get("userID")
+	userID := userIDContext.(int)
+
+	game, ok := s.sharedGameFor(c)
+	if !ok {
+		return
+	}
+	if !gameOver(game) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only finished games can be shared"})
+		return
+	}
+	if !hasReplay(c, game) {
+		return
+	}
+
+	token, err := RandomURLToken(shareTokenBytes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate share token"})
+		return
+	}
+	token, err = s.Games.ShareGame(game.RoomCode, token, userID)
+	if respondStoreError(c, err, "Game not found", "Failed to share game") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"share_token": token,
+		"url":         "/api/public/replays/" + token,
+	})
+}
+
+// UnshareGameHandler - ปิดลิงก์สาธารณะ (ลิงก์เดิมจะใช้ไม่ได้อีก เปิดใหม่จะได้ token ใหม่)
+func (s *Server) UnshareGameHandler(c *gin.Context) {
+	game, ok := s.sharedGameFor(c)
+	if !ok {
+		return
+	}
+
+	removed, err := s.Games.UnshareGame(game.RoomCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke share link"})
+		return
+	}
+	if !removed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Game is not shared"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Share link revoked"})
+}
+
+// GetPublicReplayHandler - replay แบบอ่านอย่างเดียว ไม่ต้อง login
+func (s *Server) GetPublicReplayHandler(c *gin.Context) {
+	roomCode, err := s.Games.SharedRoomCode(c.Param("token"))
+	if respondStoreError(c, err, "Replay not found", "Failed to fetch replay") {
+		return
+	}
+	game, err := s.Games.GetGame(roomCode)
+	if respondStoreError(c, err, "Replay not found", "Failed to fetch replay") {
+		return
+	}
+	// แชร์ได้เฉพาะเกมที่จบแล้ว เช็คซ้ำอีกชั้นกันข้อมูลเกมที่ยังเล่นอยู่หลุดออกไป
+	if !gameOver(game) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Replay not found"})
+		return
+	}
+	if !hasReplay(c, game) {
+		return
+	}
+	moves, err := s.Games.ListMoves(roomCode)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch replay"})
+		return
+	}
+
+	height := game.Height
+	names := s.playerNames(game)
+	replayMoves := make([]publicReplayMove, 0, len(moves))
+	for i, m := range moves {
+		mark := string(markOf(game, m.PlayerID))
+		move := publicReplayMove{Number: i + 1, Mark: mark, X: m.X, Y: m.Y, Square: squareName(m.X, m.Y, height)}
+		if game.Depth > 1 {
+			z := m.Z
+			move.Z = &z
+		}
+		replayMoves = append(replayMoves, move)
+	}
+
+	playerO := ""
+	if game.Player2ID != nil {
+		playerO = publicPlayerName(names, *game.Player2ID)
+	}
+	var winner interface{} // nil = เสมอ / ไม่มีผล
+	result, termination := recordResult(game)
+	switch result {
+	case ResultXWins:
+		winner = "X"
+	case ResultOWins:
+		winner = "O"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"players":     gin.H{"x": publicPlayerName(names, game.Player1ID), "o": playerO},
+		"moves":       replayMoves,
+		"board":       game.Board,
+		"status":      game.Status,
+		"result":      result,
+		"termination": termination,
+		"winner":      winner,
+	})
+}
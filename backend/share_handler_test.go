@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// storedTestGame - สร้างห้องตรงๆ ใน store ตามสถานะที่ต้องการ (ไม่ต้องเล่นผ่าน HTTP)
+func storedTestGame(t *testing.T, s *Server, variant, status string, player1, player2 int) *Game {
+	t.Helper()
+	g := &Game{
+		RoomCode:      GenerateRoomCode(),
+		Player1ID:     player1,
+		Player2ID:     &player2,
+		CurrentTurnID: player1,
+		Status:        status,
+		Variant:       variant,
+	}
+	setGameDefaults(g)
+	g.Board = emptyBoard(g)
+	if err := s.Games.CreateGame(g); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestQuantumGamesHaveNoReplay(t *testing.T) {
+	s, h := newTestServer(t)
+	hostID, hostToken := newTestUser(t, s, "host")
+	guestID, _ := newTestUser(t, s, "guest")
+	g := storedTestGame(t, s, VariantQuantum, "DRAW", hostID, guestID)
+
+	// token ที่แชร์ไว้ตรงๆ ใน store (เผื่อมีลิงก์ค้างอยู่ก่อน) ก็ต้องไม่ได้ replay
+	token, err := s.Games.ShareGame(g.RoomCode, "quantum-token", hostID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths := []struct {
+		method, path string
+	}{
+		{http.MethodPost, "/api/games/" + g.RoomCode + "/share"},
+		{http.MethodGet, "/api/public/replays/" + token},
+		{http.MethodGet, "/api/games/" + g.RoomCode + "/board.svg"},
+		{http.MethodGet, "/api/games/" + g.RoomCode + "/board.png"},
+		{http.MethodGet, "/api/games/" + g.RoomCode + "/replay.gif"},
+		{http.MethodGet, "/api/games/" + g.RoomCode + "/record"},
+	}
+	for _, p := range paths {
+		t.Run(p.path, func(t *testing.T) {
+			w := doRequest(t, h, p.method, p.path, hostToken, nil)
+			if w.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want 422: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// ห้องที่สร้างก่อนมี event log = ห้องที่ไม่มีแถวใน game_events (memory store เขียน created event เสมอ จึงใช้ SQLite)
+func TestBackfillRejectsQuantumGames(t *testing.T) {
+	s, _ := newSQLiteTestServer(t)
+	hostID, _ := newTestUser(t, s, "host")
+	guestID, _ := newTestUser(t, s, "guest")
+	g := storedTestGame(t, s, VariantQuantum, "DRAW", hostID, guestID)
+	if _, err := s.Games.(*SQLGameStore).db.Exec("DELETE FROM game_events WHERE game_id = $1", g.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := backfillEvents(s.Games, []string{g.RoomCode}); err == nil {
+		t.Fatal("backfill of a quantum game succeeded")
+	}
+	if events, _ := s.Games.ListEvents(g.RoomCode); len(events) != 0 {
+		t.Fatalf("%d events written", len(events))
+	}
+}
+
+func TestShareLinkPrivacy(t *testing.T) {
+	s, h := newTestServer(t)
+	hostID, hostToken := newTestUser(t, s, "host")
+	guestID, guestToken := newTestUser(t, s, "guest")
+	_, strangerToken := newTestUser(t, s, "stranger")
+	room := startTestGame(t, h, hostToken, guestToken, nil)
+	playMoves(t, h, room, hostToken, guestToken, [][2]int{{0, 0}, {0, 1}})
+
+	// เกมที่ยังเล่นอยู่แชร์ไม่ได้
+	if w := doRequest(t, h, http.MethodPost, "/api/games/"+room+"/share", hostToken, nil); w.Code != http.StatusBadRequest {
+		t.Fatalf("share in progress: %d, want 400", w.Code)
+	}
+	// ถึงจะมี token ค้างอยู่ใน store ก็ต้องไม่เห็นเกมที่ยังเล่นอยู่
+	if _, err := s.Games.ShareGame(room, "leaked-token", hostID); err != nil {
+		t.Fatal(err)
+	}
+	if w := doRequest(t, h, http.MethodGet, "/api/public/replays/leaked-token", "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("public replay of a game in progress: %d %s, want 404", w.Code, w.Body.String())
+	}
+	if _, err := s.Games.UnshareGame(room); err != nil {
+		t.Fatal(err)
+	}
+
+	playMoves(t, h, room, hostToken, guestToken, [][2]int{{1, 0}, {1, 1}, {2, 0}})
+	if w := doRequest(t, h, http.MethodPost, "/api/games/"+room+"/share", strangerToken, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("share by a non-player: %d, want 403", w.Code)
+	}
+
+	w := doRequest(t, h, http.MethodPost, "/api/games/"+room+"/share", guestToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("share: %d %s", w.Code, w.Body.String())
+	}
+	token := decodeBody(t, w)["share_token"].(string)
+	if again := decodeBody(t, doRequest(t, h, http.MethodPost, "/api/games/"+room+"/share", hostToken, nil)); again["share_token"] != token {
+		t.Fatalf("second share returned a new token %v", again["share_token"])
+	}
+
+	// ไม่ต้อง login และไม่มี room code / user id หลุดออกไป
+	w = doRequest(t, h, http.MethodGet, "/api/public/replays/"+token, "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("public replay: %d %s", w.Code, w.Body.String())
+	}
+	body := w.Body.String()
+	for _, secret := range []string{room, `"room_code"`, `"user_id"`, `"player_id"`, fmt.Sprintf(`"player1_id":%d`, hostID), fmt.Sprintf(`"player2_id":%d`, guestID)} {
+		if strings.Contains(body, secret) {
+			t.Fatalf("public replay leaks %s: %s", secret, body)
+		}
+	}
+	replay := decodeBody(t, w)
+	players := replay["players"].(map[string]interface{})
+	if players["x"] != "host" || players["o"] != "guest" || replay["winner"] != "X" {
+		t.Fatalf("replay = %v", replay)
+	}
+	if moves := replay["moves"].([]interface{}); len(moves) != 5 {
+		t.Fatalf("%d moves, want 5", len(moves))
+	}
+
+	if w := doRequest(t, h, http.MethodDelete, "/api/games/"+room+"/share", strangerToken, nil); w.Code != http.StatusForbidden {
+		t.Fatalf("unshare by a non-player: %d, want 403", w.Code)
+	}
+	if w := doRequest(t, h, http.MethodDelete, "/api/games/"+room+"/share", hostToken, nil); w.Code != http.StatusOK {
+		t.Fatalf("unshare: %d", w.Code)
+	}
+	if w := doRequest(t, h, http.MethodGet, "/api/public/replays/"+token, "", nil); w.Code != http.StatusNotFound {
+		t.Fatalf("revoked link: %d, want 404", w.Code)
+	}
+	if w := doRequest(t, h, http.MethodDelete, "/api/games/"+room+"/share", hostToken, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("unshare twice: %d, want 404", w.Code)
+	}
+}
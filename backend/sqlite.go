@@ -0,0 +1,124 @@
+// backend/sqlite.go
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"regexp"
+	"sync"
+	"time"
+
+	"modernc.org/sqlite"
+)
+
+// SQLite (DB_DRIVER=sqlite) ใช้ SQL ชุดเดียวกับ PostgreSQL ทั้งหมด โดยให้ driver ตัวนี้แปลงให้ก่อนส่งต่อ:
+//   - placeholder $1, $2 -> ?1, ?2 (ใช้ซ้ำตัวเดิมในคำสั่งเดียวได้เหมือน Postgres)
+//   - ตัด FOR UPDATE ทิ้ง: SQLite ล็อกทั้งไฟล์ ทุก transaction เริ่มด้วย BEGIN IMMEDIATE (_txlock=immediate)
+//     จึงได้ write lock ตั้งแต่ต้น transaction ถัดไปต้องรอจนกว่าอันแรกจะ Commit/Rollback แบบเดียวกับการล็อกแถว
+//   - เวลาที่ส่งเป็นพารามิเตอร์แปลงเป็น UTC ให้เทียบกับ CURRENT_TIMESTAMP ของ SQLite ได้ตรง
+//
+// RETURNING และ ON CONFLICT ... DO UPDATE ใช้ได้ตรงๆ (SQLite 3.35+)
+const sqliteDriverName = "sqlite-pgcompat"
+
+func init() {
+	sql.Register(sqliteDriverName, &sqliteCompatDriver{inner: &sqlite.Driver{}})
+}
+
+// sqliteDSN - เปิดไฟล์พร้อมตั้งค่าที่ต้องใช้ (WAL ให้อ่านได้ระหว่างมีคนเขียน, รอ lock แทนที่จะ error ทันที, เปิด foreign key)
+func sqliteDSN(path string) string {
+	return "file:" + path + "?_txlock=immediate" +
+		"&_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)"
+}
+
+var (
+	pgPlaceholder = regexp.MustCompile(`\$(\d+)`)
+	forUpdate     = regexp.MustCompile(`(?i)\s+FOR\s+UPDATE\b`)
+	translated    sync.Map // query เดิม -> query ที่แปลงแล้ว
+)
+
+func translateForSQLite(query string) string {
+	if q, ok := translated.Load(query); ok {
+		return q.(string)
+	}
+	q := pgPlaceholder.ReplaceAllString(query, "?$1")
+	q = forUpdate.ReplaceAllString(q, "")
+	translated.Store(query, q)
+	return q
+}
+
+func utcArgs(args []driver.NamedValue) []driver.NamedValue {
+	for i, arg := range args {
+		if t, ok := arg.Value.(time.Time); ok {
+			args[i].Value = t.UTC()
+		}
+	}
+	return args
+}
+
+type sqliteCompatDriver struct {
+	inner driver.Driver
+}
+
+func (d *sqliteCompatDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.inner.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sqliteCompatConn{inner: conn}, nil
+}
+
+type sqliteCompatConn struct {
+	inner driver.Conn
+}
+
+func (c *sqliteCompatConn) Prepare(query string) (driver.Stmt, error) {
+	return c.inner.Prepare(translateForSQLite(query))
+}
+
+func (c *sqliteCompatConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.inner.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, translateForSQLite(query))
+	}
+	return c.Prepare(query)
+}
+
+func (c *sqliteCompatConn) Close() error {
+	return c.inner.Close()
+}
+
+func (c *sqliteCompatConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *sqliteCompatConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.inner.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *sqliteCompatConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.inner.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, translateForSQLite(query), utcArgs(args))
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *sqliteCompatConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.inner.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, translateForSQLite(query), utcArgs(args))
+	}
+	return nil, driver.ErrSkip
+}
+
+func (c *sqliteCompatConn) Ping(ctx context.Context) error {
+	if p, ok := c.inner.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *sqliteCompatConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.inner.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+// backend/store.go
+package main
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrNotFound - ไม่พบข้อมูลที่ขอ (แทน sql.ErrNoRows เพื่อให้ handler ไม่ผูกกับ database/sql)
+var ErrNotFound = errors.New("not found")
+
+// ErrDuplicate - ชนกับข้อมูลที่มีอยู่แล้ว (เช่น username ซ้ำ)
+var ErrDuplicate = errors.New("already exists")
+
+// GameStore - ที่เก็บข้อมูลห้องเกมและประวัติการเดิน
+type GameStore interface {
+	// CreateGame - สร้างห้องใหม่พร้อมบันทึก event created
+	CreateGame(g *Game) error
+	GetGame(roomCode string) (*Game, error)
+	ListMoves(roomCode string) ([]Move, error)
+	// ListEvents - event ทั้งหมดของห้องเรียงตาม seq
+	ListEvents(roomCode string) ([]GameEvent, error)
+	// ArchiveGame / GetArchivedGame - เกมที่นำเข้าจาก game record
+	ArchiveGame(a *ArchivedGame) error
+	GetArchivedGame(id int) (*ArchivedGame, error)
+	// ShareGame - เปิดลิงก์สาธารณะของห้องด้วย token (ห้องที่เปิดไว้แล้วคืน token เดิม)
+	ShareGame(roomCode, token string, createdBy int) (string, error)
+	// UnshareGame - ปิดลิงก์สาธารณะของห้อง (false = ไม่ได้เปิดไว้)
+	UnshareGame(roomCode string) (bool, error)
+	// SharedRoomCode - ห้องของ token ลิงก์สาธารณะ (ErrNotFound ถ้าไม่มีหรือถูกปิดไปแล้ว)
+	SharedRoomCode(token string) (string, error)
+	// ActiveRoomCode - ห้องที่ผู้เล่นยังค้างอยู่ (WAITING / IN_PROGRESS) คืน "" ถ้าไม่มี
+	ActiveRoomCode(playerID int) (string, error)
+	// DeleteWaitingGame - ลบห้องที่ยังรอคู่แข่งอยู่ เฉพาะเจ้าของห้อง (false = ลบไม่ได้)
+	DeleteWaitingGame(roomCode string, hostID int) (bool, error)
+	// SearchGames - ค้นหาห้องสำหรับผู้ดูแล (status "" / playerID 0 = ไม่กรอง, roomPrefix = ขึ้นต้นของ room code) ใหม่สุดก่อน
+	SearchGames(status string, playerID int, roomPrefix string, limit, offset int) ([]GameSummary, error)
+	// WithGameLock - ล็อกห้องเกม (เทียบเท่า SELECT ... FOR UPDATE) แล้วเรียก fn
+	// fn คืน error -> ยกเลิกทุกอย่างที่ทำผ่าน tx, คืน nil -> บันทึกทั้งหมดพร้อมกัน
+	WithGameLock(roomCode string, fn func(tx GameTx, g *Game) error) error
+}
+
+// GameTx - สิ่งที่ทำได้ระหว่างถือล็อกของห้องเกม
+type GameTx interface {
+	// UpdateGame - บันทึก state ของ g (ต้องเป็นห้องที่ถูกล็อกอยู่) และเพิ่ม g.Version
+	UpdateGame(g *Game) error
+	// AppendMove - บันทึกการเดินต่อท้ายประวัติ (ช่องที่ลงซ้ำจะได้ error)
+	AppendMove(m *Move) error
+	// AppendEvent - บันทึก event ต่อท้าย log ของห้อง (store กำหนด Seq ให้)
+	AppendEvent(e *GameEvent) error
+	// CreateGame - สร้างห้องใหม่ (พร้อม event created) ใน transaction เดียวกัน (ใช้ตอน rematch)
+	CreateGame(g *Game) error
+	// AppendAudit - บันทึก action ของผู้ดูแลพร้อมกับการเปลี่ยนแปลงของห้อง
+	AppendAudit(e *AuditEntry) error
+}
+
+// SessionState - ข้อมูลที่ AuthMiddleware ใช้ตัดสินว่า token ยังใช้ได้อยู่หรือไม่
+type SessionState struct {
+	TokenVersion int
+	Deleted      bool
+	Banned       bool
+}
+
+// UserStore - ที่เก็บข้อมูลผู้ใช้สำหรับ flow สมัคร/login และการตรวจ token
+type UserStore interface {
+	// CreateUser - สมัครสมาชิกใหม่ คืน ErrDuplicate ถ้า username ซ้ำ
+	CreateUser(username, passwordHash string) (int, error)
+	// UserByUsername - ผู้ใช้ที่ยังไม่ถูกลบ (PasswordHash = "" คือบัญชีที่ไม่มีรหัสผ่าน)
+	UserByUsername(username string) (*User, error)
+	SessionState(userID int) (SessionState, error)
+	// Username - ชื่อที่แสดงของผู้ใช้ (บัญชีที่ถูกลบแล้วจะเป็น deleted_<id>)
+	Username(userID int) (string, error)
+	// ActiveSanction - การลงโทษประเภท kind ที่ยังมีผลอยู่ (nil = ไม่มี)
+	ActiveSanction(userID int, kind string) (*UserBan, error)
+	// UserByID - ผู้ใช้ตาม id (รวมบัญชีที่ถูกลบแล้ว)
+	UserByID(userID int) (*User, error)
+	// CreateGuest - ผู้ใช้ชั่วคราวที่ไม่มีรหัสผ่าน คืน ErrDuplicate ถ้า username ซ้ำ
+	CreateGuest(username string) (*User, error)
+	// WithUserLock - ล็อกแถวผู้ใช้ที่ยังไม่ถูกลบ (เทียบเท่า SELECT ... FOR UPDATE) แล้วเรียก fn
+	// fn คืน error -> ยกเลิกทุกอย่างที่ทำผ่าน tx, คืน nil -> บันทึกทั้งหมดพร้อมกัน (เหมือน WithGameLock)
+	WithUserLock(userID int, fn func(tx UserTx, u *User) error) error
+	// CleanupStaleGuests - guest ที่สร้างก่อน cutoff และไม่ได้เล่นตั้งแต่นั้น: ไม่เคยมีเกมเลย -> ลบทิ้ง, มีประวัติ -> anonymize
+	CleanupStaleGuests(cutoff time.Time) (deleted, anonymized int64, err error)
+
+	// UserByOIDC - บัญชีที่ผูกกับ (issuer, subject) ไว้แล้ว
+	UserByOIDC(issuer, subject string) (*User, error)
+	// LinkOIDC - ผูก (issuer, subject) กับบัญชีชื่อ username ที่ยังไม่ได้ผูก SSO (ErrNotFound ถ้าไม่มี)
+	LinkOIDC(username, issuer, subject string) (*User, error)
+	// CreateOIDCUser - สร้างบัญชี SSO อย่างเดียว คืน ErrDuplicate ถ้า username ซ้ำ
+	// ถ้า callback อื่นสร้างบัญชีของ (issuer, subject) นี้ไปพร้อมกันแล้ว คืนบัญชีนั้น
+	CreateOIDCUser(username, issuer, subject string) (*User, error)
+	// SaveOIDCState - เก็บ state ของ SSO login ที่รอ callback และลบ state ที่สร้างก่อน expiredBefore
+	SaveOIDCState(st OIDCLoginState, expiredBefore time.Time) error
+	// TakeOIDCState - ดึง state แล้วลบทิ้งทันที (ใช้ได้ครั้งเดียว) ErrNotFound ถ้าไม่มี
+	TakeOIDCState(state string) (*OIDCLoginState, error)
+
+	// ListUsers - ค้นหาผู้ใช้สำหรับผู้ดูแล (query = ส่วนหนึ่งของ username ไม่สนตัวพิมพ์)
+	ListUsers(query string, limit, offset int) ([]AdminUser, error)
+	// ListSanctions - ประวัติการลงโทษทั้งหมดของผู้ใช้ ใหม่สุดก่อน
+	ListSanctions(userID int) ([]UserBan, error)
+	// ListAudit - audit log ใหม่สุดก่อน (actorID 0 / action "" = ไม่กรอง)
+	ListAudit(actorID int, action string, limit, offset int) ([]AuditEntry, error)
+}
+
+// UserTx - สิ่งที่ทำได้ระหว่างถือล็อกของผู้ใช้
+type UserTx interface {
+	// UpdateUser - บันทึก username / password / role / guest / 2FA / token_version ของ u (username ซ้ำ -> ErrDuplicate)
+	UpdateUser(u *User) error
+	// Anonymize - ลบบัญชี: ล้างข้อมูลส่วนตัว (ชื่อเป็น deleted_<id>) และ revoke token ทั้งหมด
+	// ไม่ลบแถวจริงเพราะเกมเดิมยังอ้างอิงผู้ใช้อยู่
+	Anonymize() error
+	// ReplaceRecoveryCodes - แทน recovery code ทั้งหมดด้วย hash ชุดใหม่ (nil = ลบทิ้ง)
+	ReplaceRecoveryCodes(hashes []string) error
+	// UseRecoveryCode - mark recovery code ที่ยังไม่เคยใช้ว่าใช้แล้ว (false = ไม่มี)
+	UseRecoveryCode(hash string) (bool, error)
+	// ActiveSanction - การลงโทษประเภท kind ที่ยังมีผลอยู่ (nil = ไม่มี)
+	ActiveSanction(kind string) (*UserBan, error)
+	// AddSanction - บันทึกการลงโทษใหม่ (store กำหนด ID / UserID / CreatedAt ให้)
+	AddSanction(b *UserBan) error
+	// LiftSanction - ยกเลิกการลงโทษประเภท kind ที่มีผลอยู่ (false = ไม่มี)
+	LiftSanction(kind string, liftedBy int) (bool, error)
+	// AppendAudit - บันทึก action ของผู้ดูแลพร้อมกับการเปลี่ยนแปลงของผู้ใช้
+	AppendAudit(e *AuditEntry) error
+}
+
+// LoginThrottle - ตัวนับการ login ผิด (นโยบายอยู่ใน login_throttle.go)
+type LoginThrottle interface {
+	// Lockout - ถ้า username หรือ IP นี้ยังติดล็อกอยู่ คืนเวลาที่ต้องรอ (0 = ไม่ติดล็อก)
+	Lockout(username, ip string) (time.Duration, error)
+	// RecordFailure - บันทึก audit แล้วเพิ่มตัวนับของทั้ง username และ IP
+	RecordFailure(username, ip, reason string)
+	// Reset - login สำเร็จ ล้างตัวนับของ username
+	Reset(username string)
+}
+
+// IdempotencyStore - ผลลัพธ์ของ request ที่มี Idempotency-Key (นโยบายอยู่ใน idempotency.go)
+type IdempotencyStore interface {
+	// Reserve - จองคีย์ของผู้ใช้ (reserved = true) หรือคืนผลลัพธ์ที่มีอยู่แล้ว
+	// คีย์ที่สร้างก่อน since ถือว่าหมดอายุ ใช้ใหม่ได้
+	Reserve(userID int, key, fingerprint string, since time.Time) (existing *IdempotentResponse, reserved bool, err error)
+	// Complete - บันทึกผลลัพธ์ของคีย์ที่จองไว้ (header = เฉพาะ idempotentReplayHeaders)
+	Complete(userID int, key string, status int, header map[string]string, body []byte) error
+	// Release - ยกเลิกคีย์ที่จองไว้แต่ยังไม่มีผลลัพธ์ (request ถัดไปที่ใช้คีย์นี้จะทำงานใหม่)
+	Release(userID int, key string)
+}
+
+// StatusError - ใช้คืนจากใน WithGameLock เมื่อ validation ไม่ผ่าน (ยกเลิก transaction แล้วตอบ status นี้)
+type StatusError struct {
+	Status  int
+	Message string
+}
+
+func (e *StatusError) Error() string { return e.Message }
+
+func reject(status int, message string) error {
+	return &StatusError{Status: status, Message: message}
+}
+
+// respondStoreError - แปลง error จาก store เป็น response (คืน true ถ้าตอบ error ไปแล้ว)
+func respondStoreError(c *gin.Context, err error, notFound, failed string) bool {
+	if err == nil {
+		return false
+	}
+	var statusErr *StatusError
+	switch {
+	case errors.As(err, &statusErr):
+		c.JSON(statusErr.Status, gin.H{"error": statusErr.Message})
+	case errors.Is(err, ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": notFound})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": failed})
+	}
+	return true
+}
@@ -0,0 +1,961 @@
+// backend/store_memory.go
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore - เก็บทุกอย่างไว้ใน RAM (STORAGE=memory) สำหรับ dev/ทดสอบโดยไม่ต้องมี Postgres
+// ข้อมูลหายเมื่อปิด server
+//
+// การล็อก: mu คุม map ทั้งหมด ส่วนแต่ละห้องมี mutex ของตัวเองแทน SELECT ... FOR UPDATE
+// state ของห้อง (game/moves) แก้ได้เฉพาะตอนถือทั้ง lock ของห้องและ mu อ่านได้เมื่อถืออย่างใดอย่างหนึ่ง
+// WithUserLock ใช้ userLock ตัวเดียวทั้ง store (ผู้ใช้ไม่ได้ถูกล็อกบ่อยเท่าห้องเกม)
+// ลำดับการล็อกคือ userLock -> lock ของห้อง -> mu เสมอ (ห้ามรอ lock อื่นขณะถือ mu)
+type MemoryStore struct {
+	mu       sync.Mutex
+	userLock sync.Mutex
+
+	games       map[string]*memoryGame // room_code -> ห้อง
+	nextGameID  int
+	nextMoveID  int
+	nextEventID int
+
+	archived []ArchivedGame
+	shares   map[string]string // token ลิงก์สาธารณะ -> room_code
+
+	users          map[int]*User
+	userIDs        map[string]int // username -> id
+	nextUserID     int
+	bans           []UserBan
+	recoveryCodes  map[int][]memoryRecoveryCode
+	oidcIdentities map[memoryOIDCIdentity]int // (issuer, subject) -> user id
+	oidcStates     map[string]OIDCLoginState
+	audits         []AuditEntry
+
+	throttles map[string]*memoryThrottleEntry
+
+	idempotency map[memoryIdempotencyKey]*memoryIdempotencyEntry
+}
+
+type memoryGame struct {
+	lock    sync.Mutex
+	game    Game
+	moves   []Move
+	events  []GameEvent
+	share   string // token ลิงก์สาธารณะ ("" = ไม่ได้เปิด)
+	deleted bool   // ถูกลบไปแล้วระหว่างที่อีก request รอ lock อยู่
+}
+
+type memoryRecoveryCode struct {
+	hash string
+	used bool
+}
+
+type memoryOIDCIdentity struct {
+	issuer  string
+	subject string
+}
+
+type memoryThrottleEntry struct {
+	failures      int
+	lastFailureAt time.Time
+	lockedUntil   time.Time
+}
+
+type memoryIdempotencyKey struct {
+	userID int
+	key    string
+}
+
+type memoryIdempotencyEntry struct {
+	response  IdempotentResponse
+	createdAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		games:     map[string]*memoryGame{},
+		shares:    map[string]string{},
+		users:     map[int]*User{},
+		userIDs:   map[string]int{},
+		throttles: map[string]*memoryThrottleEntry{},
+
+		recoveryCodes:  map[int][]memoryRecoveryCode{},
+		oidcIdentities: map[memoryOIDCIdentity]int{},
+		oidcStates:     map[string]OIDCLoginState{},
+
+		idempotency: map[memoryIdempotencyKey]*memoryIdempotencyEntry{},
+	}
+}
+
+// NewMemoryServer - Server ที่ใช้ MemoryStore ทั้งหมด
+func NewMemoryServer() *Server {
+	store := NewMemoryStore()
+	return &Server{Users: store, Games: store, Throttle: store, Idempotency: store, Replays: NewReplayCache(replayCacheSize)}
+}
+
+// ---------- Games ----------
+
+// createGameLocked - ต้องถือ mu อยู่
+func (s *MemoryStore) createGameLocked(g *Game) error {
+	if _, exists := s.games[g.RoomCode]; exists {
+		return ErrDuplicate
+	}
+	setGameDefaults(g)
+	s.nextGameID++
+	g.ID = s.nextGameID
+	g.CreatedAt = time.Now()
+	mg := &memoryGame{game: *g}
+	s.games[g.RoomCode] = mg
+	s.appendEventLocked(mg, createdEvent(g))
+	return nil
+}
+
+// appendEventLocked - ต้องถือ mu อยู่
+func (s *MemoryStore) appendEventLocked(mg *memoryGame, e GameEvent) {
+	s.nextEventID++
+	e.ID = s.nextEventID
+	e.GameID = mg.game.ID
+	e.Seq = len(mg.events) + 1
+	e.CreatedAt = time.Now()
+	mg.events = append(mg.events, e)
+}
+
+func (s *MemoryStore) CreateGame(g *Game) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.createGameLocked(g)
+}
+
+func (s *MemoryStore) GetGame(roomCode string) (*Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mg, ok := s.games[roomCode]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	g := mg.game
+	return &g, nil
+}
+
+func (s *MemoryStore) ListMoves(roomCode string) ([]Move, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mg, ok := s.games[roomCode]
+	if !ok {
+		return nil, nil
+	}
+	return append([]Move(nil), mg.moves...), nil
+}
+
+func (s *MemoryStore) ListEvents(roomCode string) ([]GameEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mg, ok := s.games[roomCode]
+	if !ok {
+		return nil, nil
+	}
+	return append([]GameEvent(nil), mg.events...), nil
+}
+
+func (s *MemoryStore) ArchiveGame(a *ArchivedGame) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a.ID = len(s.archived) + 1
+	a.CreatedAt = time.Now()
+	s.archived = append(s.archived, *a)
+	return nil
+}
+
+func (s *MemoryStore) GetArchivedGame(id int) (*ArchivedGame, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if id < 1 || id > len(s.archived) {
+		return nil, ErrNotFound
+	}
+	a := s.archived[id-1]
+	return &a, nil
+}
+
+func (s *MemoryStore) ShareGame(roomCode, token string, createdBy int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mg, ok := s.games[roomCode]
+	if !ok {
+		return "", ErrNotFound
+	}
+	if mg.share == "" {
+		mg.share = token
+		s.shares[token] = roomCode
+	}
+	return mg.share, nil
+}
+
+func (s *MemoryStore) UnshareGame(roomCode string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mg, ok := s.games[roomCode]
+	if !ok || mg.share == "" {
+		return false, nil
+	}
+	delete(s.shares, mg.share)
+	mg.share = ""
+	return true, nil
+}
+
+func (s *MemoryStore) SharedRoomCode(token string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	roomCode, ok := s.shares[token]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return roomCode, nil
+}
+
+func (s *MemoryStore) ActiveRoomCode(playerID int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for code, mg := range s.games {
+		g := mg.game
+		isPlayer := g.Player1ID == playerID || (g.Player2ID != nil && *g.Player2ID == playerID)
+		if isPlayer && (g.Status == "WAITING" || g.Status == "IN_PROGRESS") {
+			return code, nil
+		}
+	}
+	return "", nil
+}
+
+func (s *MemoryStore) lockGame(roomCode string) (*memoryGame, error) {
+	s.mu.Lock()
+	mg, ok := s.games[roomCode]
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	mg.lock.Lock()
+	if mg.deleted {
+		mg.lock.Unlock()
+		return nil, ErrNotFound
+	}
+	return mg, nil
+}
+
+func (s *MemoryStore) DeleteWaitingGame(roomCode string, hostID int) (bool, error) {
+	mg, err := s.lockGame(roomCode)
+	if err != nil {
+		return false, nil
+	}
+	defer mg.lock.Unlock()
+
+	if mg.game.Player1ID != hostID || mg.game.Status != "WAITING" {
+		return false, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	mg.deleted = true
+	delete(s.games, roomCode)
+	return true, nil
+}
+
+func (s *MemoryStore) WithGameLock(roomCode string, fn func(tx GameTx, g *Game) error) error {
+	mg, err := s.lockGame(roomCode)
+	if err != nil {
+		return err
+	}
+	defer mg.lock.Unlock()
+
+	// fn ทำงานกับสำเนา ถ้า fn คืน error ก็ทิ้งไปทั้งหมด (เทียบเท่า Rollback)
+	g := mg.game
+	tx := &memoryGameTx{room: mg}
+	if err := fn(tx, &g); err != nil {
+		return err
+	}
+
+	// Commit
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, created := range tx.created {
+		if _, exists := s.games[created.RoomCode]; exists {
+			return ErrDuplicate
+		}
+	}
+	for _, created := range tx.created {
+		s.createGameLocked(created)
+	}
+	if tx.updated != nil {
+		mg.game = *tx.updated
+	}
+	for _, m := range tx.moves {
+		s.nextMoveID++
+		m.ID = s.nextMoveID
+		mg.moves = append(mg.moves, *m)
+	}
+	for _, e := range tx.events {
+		s.appendEventLocked(mg, *e)
+	}
+	for _, e := range tx.audits {
+		s.appendAuditLocked(e)
+	}
+	return nil
+}
+
+// memoryGameTx - เก็บการเปลี่ยนแปลงไว้ก่อน แล้วค่อยใส่ลง store ตอน Commit
+type memoryGameTx struct {
+	room    *memoryGame
+	updated *Game
+	moves   []*Move
+	events  []*GameEvent
+	created []*Game
+	audits  []*AuditEntry
+}
+
+func (t *memoryGameTx) UpdateGame(g *Game) error {
+	g.Version++
+	updated := *g
+	t.updated = &updated
+	return nil
+}
+
+func (t *memoryGameTx) AppendMove(m *Move) error {
+	// เทียบเท่า CONSTRAINT unique_move_per_cell
+	for _, existing := range t.room.moves {
+		if existing.X == m.X && existing.Y == m.Y && existing.Z == m.Z {
+			return fmt.Errorf("cell (%d, %d, %d) already has a move", m.X, m.Y, m.Z)
+		}
+	}
+	for _, pending := range t.moves {
+		if pending.X == m.X && pending.Y == m.Y && pending.Z == m.Z {
+			return fmt.Errorf("cell (%d, %d, %d) already has a move", m.X, m.Y, m.Z)
+		}
+	}
+	m.CreatedAt = time.Now()
+	t.moves = append(t.moves, m)
+	return nil
+}
+
+func (t *memoryGameTx) AppendEvent(e *GameEvent) error {
+	t.events = append(t.events, e)
+	return nil
+}
+
+func (t *memoryGameTx) CreateGame(g *Game) error {
+	t.created = append(t.created, g)
+	return nil
+}
+
+func (t *memoryGameTx) AppendAudit(e *AuditEntry) error {
+	t.audits = append(t.audits, e)
+	return nil
+}
+
+// appendAuditLocked - ต้องถือ mu อยู่
+func (s *MemoryStore) appendAuditLocked(e *AuditEntry) {
+	e.ID = len(s.audits) + 1
+	e.CreatedAt = time.Now()
+	s.audits = append(s.audits, *e)
+}
+
+func (s *MemoryStore) SearchGames(status string, playerID int, roomPrefix string, limit, offset int) ([]GameSummary, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var matched []Game
+	for _, mg := range s.games {
+		g := mg.game
+		if status != "" && g.Status != status {
+			continue
+		}
+		if playerID != 0 && g.Player1ID != playerID && (g.Player2ID == nil || *g.Player2ID != playerID) {
+			continue
+		}
+		if !strings.HasPrefix(g.RoomCode, roomPrefix) {
+			continue
+		}
+		matched = append(matched, g)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].ID > matched[j].ID })
+
+	games := []GameSummary{}
+	for i := offset; i < len(matched) && i < offset+limit; i++ {
+		g := matched[i]
+		summary := GameSummary{
+			ID: g.ID, RoomCode: g.RoomCode, Player1ID: &g.Player1ID, Player2ID: g.Player2ID, Status: g.Status,
+			WinnerID: g.WinnerID, TerminationReason: g.TerminationReason, Board: g.Board, CreatedAt: g.CreatedAt,
+		}
+		summary.Player1Name = s.usernameLocked(&g.Player1ID)
+		summary.Player2Name = s.usernameLocked(g.Player2ID)
+		games = append(games, summary)
+	}
+	return games, nil
+}
+
+// usernameLocked - ชื่อของผู้ใช้ id (nil ถ้าไม่มี) ต้องถือ mu อยู่
+func (s *MemoryStore) usernameLocked(id *int) *string {
+	if id == nil {
+		return nil
+	}
+	user, ok := s.users[*id]
+	if !ok {
+		return nil
+	}
+	name := user.Username
+	return &name
+}
+
+// ---------- Users ----------
+
+// createUserLocked - ต้องถือ mu อยู่
+func (s *MemoryStore) createUserLocked(user *User) error {
+	if _, exists := s.userIDs[user.Username]; exists {
+		return ErrDuplicate
+	}
+	s.nextUserID++
+	user.ID = s.nextUserID
+	user.Role = RolePlayer
+	user.CreatedAt = time.Now()
+	s.users[user.ID] = user
+	s.userIDs[user.Username] = user.ID
+	return nil
+}
+
+func (s *MemoryStore) CreateUser(username, passwordHash string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user := &User{Username: username, PasswordHash: passwordHash}
+	if err := s.createUserLocked(user); err != nil {
+		return 0, err
+	}
+	return user.ID, nil
+}
+
+func (s *MemoryStore) UserByUsername(username string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.userIDs[username]
+	if !ok || s.users[id].DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	user := *s.users[id]
+	return &user, nil
+}
+
+func (s *MemoryStore) SessionState(userID int) (SessionState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return SessionState{}, ErrNotFound
+	}
+	return SessionState{
+		TokenVersion: user.TokenVersion,
+		Deleted:      user.DeletedAt != nil,
+		Banned:       s.activeSanctionLocked(userID, SanctionBan) != nil,
+	}, nil
+}
+
+func (s *MemoryStore) Username(userID int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return user.Username, nil
+}
+
+func (s *MemoryStore) ActiveSanction(userID int, kind string) (*UserBan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.activeSanctionLocked(userID, kind), nil
+}
+
+func (s *MemoryStore) activeSanctionLocked(userID int, kind string) *UserBan {
+	now := time.Now()
+	for i := len(s.bans) - 1; i >= 0; i-- {
+		b := s.bans[i]
+		if b.UserID == userID && b.Kind == kind && b.LiftedAt == nil && (b.ExpiresAt == nil || b.ExpiresAt.After(now)) {
+			return &b
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) UserByID(userID int) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[userID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	u := *user
+	return &u, nil
+}
+
+func (s *MemoryStore) CreateGuest(username string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user := &User{Username: username, IsGuest: true}
+	if err := s.createUserLocked(user); err != nil {
+		return nil, err
+	}
+	u := *user
+	return &u, nil
+}
+
+func (s *MemoryStore) WithUserLock(userID int, fn func(tx UserTx, u *User) error) error {
+	s.userLock.Lock()
+	defer s.userLock.Unlock()
+
+	s.mu.Lock()
+	user, ok := s.users[userID]
+	var u User
+	if ok {
+		u = *user
+	}
+	s.mu.Unlock()
+	if !ok || u.DeletedAt != nil {
+		return ErrNotFound
+	}
+
+	// fn ทำงานกับสำเนา ถ้า fn คืน error ก็ทิ้งไปทั้งหมด (เทียบเท่า Rollback)
+	tx := &memoryUserTx{store: s, userID: userID}
+	if err := fn(tx, &u); err != nil {
+		return err
+	}
+
+	// Commit
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if tx.updated != nil {
+		if id, exists := s.userIDs[tx.updated.Username]; exists && id != userID {
+			return ErrDuplicate
+		}
+		delete(s.userIDs, s.users[userID].Username)
+		s.userIDs[tx.updated.Username] = userID
+		s.users[userID] = tx.updated
+	}
+	if tx.codes != nil {
+		s.recoveryCodes[userID] = *tx.codes
+	}
+	for _, hash := range tx.usedCodes {
+		codes := s.recoveryCodes[userID]
+		for i := range codes {
+			if codes[i].hash == hash && !codes[i].used {
+				codes[i].used = true
+				break
+			}
+		}
+	}
+	for _, b := range tx.sanctions {
+		s.bans = append(s.bans, *b)
+	}
+	now := time.Now()
+	for _, lift := range tx.lifts {
+		for i := range s.bans {
+			b := &s.bans[i]
+			if b.UserID == userID && b.Kind == lift.kind && b.LiftedAt == nil && (b.ExpiresAt == nil || b.ExpiresAt.After(now)) {
+				liftedBy := lift.by
+				b.LiftedAt, b.LiftedBy = &now, &liftedBy
+			}
+		}
+	}
+	if tx.anonymize {
+		s.anonymizeLocked(s.users[userID])
+	}
+	for _, e := range tx.audits {
+		s.appendAuditLocked(e)
+	}
+	return nil
+}
+
+// anonymizeLocked - เทียบเท่า anonymizeUserColumns (ต้องถือ mu อยู่)
+func (s *MemoryStore) anonymizeLocked(user *User) {
+	delete(s.userIDs, user.Username)
+	user.Username = fmt.Sprintf("deleted_%d", user.ID)
+	s.userIDs[user.Username] = user.ID
+	user.PasswordHash = ""
+	user.TOTPSecret, user.TOTPEnabled = nil, false
+	user.TokenVersion++
+	now := time.Now()
+	user.DeletedAt = &now
+	for identity, id := range s.oidcIdentities {
+		if id == user.ID {
+			delete(s.oidcIdentities, identity)
+		}
+	}
+	delete(s.recoveryCodes, user.ID)
+}
+
+func (s *MemoryStore) CleanupStaleGuests(cutoff time.Time) (deleted, anonymized int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, user := range s.users {
+		if !user.IsGuest || user.DeletedAt != nil || !user.CreatedAt.Before(cutoff) {
+			continue
+		}
+		played, recent := false, false
+		for _, mg := range s.games {
+			g := mg.game
+			if g.Player1ID != id && (g.Player2ID == nil || *g.Player2ID != id) {
+				continue
+			}
+			played = true
+			if g.Status == "WAITING" || g.Status == "IN_PROGRESS" || !g.CreatedAt.Before(cutoff) {
+				recent = true
+			}
+		}
+		if recent {
+			continue
+		}
+		sanctioned := false
+		for _, b := range s.bans {
+			if b.UserID == id {
+				sanctioned = true
+			}
+		}
+		for _, a := range s.archived {
+			if a.ImportedBy == id {
+				sanctioned = true // archived_games.imported_by ยังอ้างถึง -> ลบไม่ได้ ทำได้แค่ anonymize
+			}
+		}
+		if !played && !sanctioned {
+			delete(s.userIDs, user.Username)
+			delete(s.users, id)
+			deleted++
+			continue
+		}
+		s.anonymizeLocked(user)
+		anonymized++
+	}
+	return deleted, anonymized, nil
+}
+
+func (s *MemoryStore) UserByOIDC(issuer, subject string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.oidcIdentities[memoryOIDCIdentity{issuer, subject}]
+	if !ok || s.users[id].DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	u := *s.users[id]
+	return &u, nil
+}
+
+func (s *MemoryStore) LinkOIDC(username, issuer, subject string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, ok := s.userIDs[username]
+	if !ok || s.users[id].DeletedAt != nil {
+		return nil, ErrNotFound
+	}
+	for _, linked := range s.oidcIdentities {
+		if linked == id {
+			return nil, ErrNotFound
+		}
+	}
+	s.oidcIdentities[memoryOIDCIdentity{issuer, subject}] = id
+	u := *s.users[id]
+	return &u, nil
+}
+
+func (s *MemoryStore) CreateOIDCUser(username, issuer, subject string) (*User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	identity := memoryOIDCIdentity{issuer, subject}
+	if id, ok := s.oidcIdentities[identity]; ok {
+		u := *s.users[id]
+		return &u, nil
+	}
+	user := &User{Username: username}
+	if err := s.createUserLocked(user); err != nil {
+		return nil, err
+	}
+	s.oidcIdentities[identity] = user.ID
+	u := *user
+	return &u, nil
+}
+
+func (s *MemoryStore) SaveOIDCState(st OIDCLoginState, expiredBefore time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, existing := range s.oidcStates {
+		if existing.CreatedAt.Before(expiredBefore) {
+			delete(s.oidcStates, key)
+		}
+	}
+	st.CreatedAt = time.Now()
+	s.oidcStates[st.State] = st
+	return nil
+}
+
+func (s *MemoryStore) TakeOIDCState(state string) (*OIDCLoginState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.oidcStates[state]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	delete(s.oidcStates, state)
+	return &st, nil
+}
+
+func (s *MemoryStore) ListUsers(query string, limit, offset int) ([]AdminUser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []int
+	for id, user := range s.users {
+		if strings.Contains(strings.ToLower(user.Username), strings.ToLower(query)) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+
+	users := []AdminUser{}
+	for i := offset; i < len(ids) && i < offset+limit; i++ {
+		user := s.users[ids[i]]
+		users = append(users, AdminUser{
+			User: User{
+				ID: user.ID, Username: user.Username, Role: user.Role, IsGuest: user.IsGuest,
+				DeletedAt: user.DeletedAt, CreatedAt: user.CreatedAt,
+			},
+			Banned:    s.activeSanctionLocked(user.ID, SanctionBan) != nil,
+			Suspended: s.activeSanctionLocked(user.ID, SanctionSuspension) != nil,
+		})
+	}
+	return users, nil
+}
+
+func (s *MemoryStore) ListSanctions(userID int) ([]UserBan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	history := []UserBan{}
+	for i := len(s.bans) - 1; i >= 0; i-- {
+		if s.bans[i].UserID == userID {
+			history = append(history, s.bans[i])
+		}
+	}
+	return history, nil
+}
+
+func (s *MemoryStore) ListAudit(actorID int, action string, limit, offset int) ([]AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := []AuditEntry{}
+	skipped := 0
+	for i := len(s.audits) - 1; i >= 0 && len(entries) < limit; i-- {
+		e := s.audits[i]
+		if (actorID != 0 && e.ActorID != actorID) || (action != "" && e.Action != action) {
+			continue
+		}
+		if skipped < offset {
+			skipped++
+			continue
+		}
+		if actor, ok := s.users[e.ActorID]; ok {
+			e.ActorName = actor.Username
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// memoryUserTx - เก็บการเปลี่ยนแปลงไว้ก่อน แล้วค่อยใส่ลง store ตอน Commit (เหมือน memoryGameTx)
+type memoryUserTx struct {
+	store     *MemoryStore
+	userID    int
+	updated   *User
+	anonymize bool
+	codes     *[]memoryRecoveryCode
+	usedCodes []string
+	sanctions []*UserBan
+	lifts     []memorySanctionLift
+	audits    []*AuditEntry
+}
+
+type memorySanctionLift struct {
+	kind string
+	by   int
+}
+
+func (t *memoryUserTx) UpdateUser(u *User) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+	if id, exists := t.store.userIDs[u.Username]; exists && id != t.userID {
+		return ErrDuplicate
+	}
+	updated := *u
+	t.updated = &updated
+	return nil
+}
+
+func (t *memoryUserTx) Anonymize() error {
+	t.anonymize = true
+	return nil
+}
+
+func (t *memoryUserTx) ReplaceRecoveryCodes(hashes []string) error {
+	codes := []memoryRecoveryCode{}
+	for _, hash := range hashes {
+		codes = append(codes, memoryRecoveryCode{hash: hash})
+	}
+	t.codes = &codes
+	t.usedCodes = nil
+	return nil
+}
+
+func (t *memoryUserTx) UseRecoveryCode(hash string) (bool, error) {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+	codes := t.store.recoveryCodes[t.userID]
+	if t.codes != nil {
+		codes = *t.codes
+	}
+	available := 0
+	for _, code := range codes {
+		if code.hash == hash && !code.used {
+			available++
+		}
+	}
+	for _, used := range t.usedCodes {
+		if used == hash {
+			available--
+		}
+	}
+	if available <= 0 {
+		return false, nil
+	}
+	t.usedCodes = append(t.usedCodes, hash)
+	return true, nil
+}
+
+func (t *memoryUserTx) ActiveSanction(kind string) (*UserBan, error) {
+	return t.store.ActiveSanction(t.userID, kind)
+}
+
+func (t *memoryUserTx) AddSanction(b *UserBan) error {
+	t.store.mu.Lock()
+	defer t.store.mu.Unlock()
+	// id จองไว้เลย (เหมือน sequence ของ Database) handler ใช้ใส่ใน audit log ก่อน Commit
+	b.ID = len(t.store.bans) + len(t.sanctions) + 1
+	b.UserID = t.userID
+	b.CreatedAt = time.Now()
+	t.sanctions = append(t.sanctions, b)
+	return nil
+}
+
+func (t *memoryUserTx) LiftSanction(kind string, liftedBy int) (bool, error) {
+	ban, err := t.ActiveSanction(kind)
+	if err != nil || ban == nil {
+		return false, err
+	}
+	t.lifts = append(t.lifts, memorySanctionLift{kind: kind, by: liftedBy})
+	return true, nil
+}
+
+func (t *memoryUserTx) AppendAudit(e *AuditEntry) error {
+	t.audits = append(t.audits, e)
+	return nil
+}
+
+// ---------- Login throttling ----------
+
+func (s *MemoryStore) Lockout(username, ip string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var wait time.Duration
+	for _, key := range []string{userThrottleKey(username), ipThrottleKey(ip)} {
+		if entry, ok := s.throttles[key]; ok {
+			if w := entry.lockedUntil.Sub(throttleClock()); w > wait {
+				wait = w
+			}
+		}
+	}
+	return wait, nil
+}
+
+func (s *MemoryStore) RecordFailure(username, ip, reason string) {
+	// ถูกปฏิเสธเพราะติดล็อกอยู่แล้ว ไม่ต้องนับเพิ่ม
+	if reason == "locked_out" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := throttleClock()
+	s.bumpThrottleLocked(userThrottleKey(username), loginFreeAttemptsPerUser, now)
+	s.bumpThrottleLocked(ipThrottleKey(ip), loginFreeAttemptsPerIP, now)
+}
+
+func (s *MemoryStore) bumpThrottleLocked(key string, freeAttempts int, now time.Time) {
+	entry, ok := s.throttles[key]
+	if !ok || entry.lastFailureAt.Before(now.Add(-loginFailureWindow)) {
+		entry = &memoryThrottleEntry{}
+		s.throttles[key] = entry
+	}
+	entry.failures++
+	entry.lastFailureAt = now
+	if lock := lockoutDuration(entry.failures, freeAttempts); lock > 0 {
+		entry.lockedUntil = now.Add(lock)
+	}
+}
+
+func (s *MemoryStore) Reset(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.throttles, userThrottleKey(username))
+}
+
+// ---------- Idempotency keys ----------
+
+func (s *MemoryStore) Reserve(userID int, key, fingerprint string, since time.Time) (*IdempotentResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// คีย์ที่หมดอายุลบทิ้งไปพร้อมกัน (map ไม่โตไปเรื่อยๆ เหมือน SQLIdempotencyStore)
+	for k, entry := range s.idempotency {
+		if entry.createdAt.Before(since) {
+			delete(s.idempotency, k)
+		}
+	}
+	k := memoryIdempotencyKey{userID: userID, key: key}
+	if entry, ok := s.idempotency[k]; ok {
+		existing := entry.response
+		return &existing, false, nil
+	}
+	s.idempotency[k] = &memoryIdempotencyEntry{
+		response:  IdempotentResponse{Fingerprint: fingerprint},
+		createdAt: time.Now(),
+	}
+	return nil, true, nil
+}
+
+func (s *MemoryStore) Complete(userID int, key string, status int, header map[string]string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.idempotency[memoryIdempotencyKey{userID: userID, key: key}]
+	if !ok {
+		return ErrNotFound
+	}
+	entry.response.Status = status
+	entry.response.Header = map[string]string{}
+	for name, value := range header {
+		entry.response.Header[name] = value
+	}
+	entry.response.Body = append([]byte(nil), body...)
+	return nil
+}
+
+func (s *MemoryStore) Release(userID int, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := memoryIdempotencyKey{userID: userID, key: key}
+	if entry, ok := s.idempotency[k]; ok && entry.response.Status == 0 {
+		delete(s.idempotency, k)
+	}
+}
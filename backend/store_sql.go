@@ -0,0 +1,785 @@
+// backend/store_sql.go
+// store ที่ใช้ SQL ชุดเดียวกันทั้ง PostgreSQL และ SQLite (ดู sqlite.go)
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// ---------- Games ----------
+
+type SQLGameStore struct {
+	db *sql.DB
+}
+
+const selectGameColumns = `SELECT id, room_code, player1_id, player2_id, current_turn_id, variant, width, height, depth, win_length, board, next_board, quantum, status, winner_id,
+	draw_rule, termination_reason, next_room_code, rematch_p1, rematch_p2, version, created_at FROM games`
+
+func scanGame(row interface{ Scan(...interface{}) error }) (*Game, error) {
+	var g Game
+	var quantum sql.NullString
+	err := row.Scan(&g.ID, &g.RoomCode, &g.Player1ID, &g.Player2ID, &g.CurrentTurnID, &g.Variant, &g.Width, &g.Height, &g.Depth, &g.WinLength, &g.Board, &g.NextBoard, &quantum, &g.Status, &g.WinnerID,
+		&g.DrawRule, &g.TerminationReason, &g.NextRoomCode, &g.RematchP1, &g.RematchP2, &g.Version, &g.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if g.Quantum, err = parseQuantum(quantum); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// queryRower - ใช้ได้ทั้ง *sql.DB และ *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func insertGame(q queryRower, g *Game) error {
+	query := `
+		INSERT INTO games (room_code, player1_id, player2_id, current_turn_id, status, variant, width, height, depth, win_length, board, quantum, draw_rule)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at`
+	setGameDefaults(g)
+	if err := q.QueryRow(query, g.RoomCode, g.Player1ID, g.Player2ID, g.CurrentTurnID, g.Status, g.Variant,
+		g.Width, g.Height, g.Depth, g.WinLength, g.Board, quantumJSON(g.Quantum), g.DrawRule).Scan(&g.ID, &g.CreatedAt); err != nil {
+		return err
+	}
+	created := createdEvent(g)
+	return insertGameEvent(q, &created)
+}
+
+// insertGameEvent - ต่อท้าย event log ของห้อง (ต้องถือ lock ของห้องอยู่ หรือเป็นห้องที่เพิ่งสร้าง)
+func insertGameEvent(q queryRower, e *GameEvent) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO game_events (game_id, seq, event_type, player_id, data)
+		VALUES ($1, (SELECT COALESCE(MAX(seq), 0) + 1 FROM game_events WHERE game_id = $1), $2, $3, $4)
+		RETURNING id, seq, created_at`
+	return q.QueryRow(query, e.GameID, e.Type, e.PlayerID, string(data)).Scan(&e.ID, &e.Seq, &e.CreatedAt)
+}
+
+func (s *SQLGameStore) CreateGame(g *Game) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertGame(tx, g); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *SQLGameStore) GetGame(roomCode string) (*Game, error) {
+	return scanGame(s.db.QueryRow(selectGameColumns+` WHERE room_code = $1`, roomCode))
+}
+
+func (s *SQLGameStore) ListMoves(roomCode string) ([]Move, error) {
+	query := `
+		SELECT m.id, m.game_id, m.player_id, m.x, m.y, m.z, m.created_at
+		FROM moves m
+		JOIN games g ON m.game_id = g.id
+		WHERE g.room_code = $1
+		ORDER BY m.move_order ASC`
+	rows, err := s.db.Query(query, roomCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var moves []Move
+	for rows.Next() {
+		var m Move
+		if err := rows.Scan(&m.ID, &m.GameID, &m.PlayerID, &m.X, &m.Y, &m.Z, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		moves = append(moves, m)
+	}
+	return moves, rows.Err()
+}
+
+func (s *SQLGameStore) ListEvents(roomCode string) ([]GameEvent, error) {
+	query := `
+		SELECT e.id, e.game_id, e.seq, e.event_type, e.player_id, e.data, e.created_at
+		FROM game_events e
+		JOIN games g ON e.game_id = g.id
+		WHERE g.room_code = $1
+		ORDER BY e.seq ASC`
+	rows, err := s.db.Query(query, roomCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []GameEvent
+	for rows.Next() {
+		var e GameEvent
+		var data string
+		if err := rows.Scan(&e.ID, &e.GameID, &e.Seq, &e.Type, &e.PlayerID, &data, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(data), &e.Data); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+func (s *SQLGameStore) ArchiveGame(a *ArchivedGame) error {
+	query := `
+		INSERT INTO archived_games (room_code, variant, player_x, player_o, result, termination, board, moves,
+			started_at, ended_at, imported_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, created_at`
+	return s.db.QueryRow(query, a.RoomCode, a.Variant, a.PlayerX, a.PlayerO, a.Result, a.Termination, a.Board, a.Moves,
+		a.StartedAt, a.EndedAt, a.ImportedBy).Scan(&a.ID, &a.CreatedAt)
+}
+
+func (s *SQLGameStore) GetArchivedGame(id int) (*ArchivedGame, error) {
+	var a ArchivedGame
+	var roomCode sql.NullString
+	var importedBy sql.NullInt64
+	query := `SELECT id, room_code, variant, player_x, player_o, result, termination, board, moves,
+				started_at, ended_at, imported_by, created_at
+			  FROM archived_games WHERE id = $1`
+	err := s.db.QueryRow(query, id).Scan(&a.ID, &roomCode, &a.Variant, &a.PlayerX, &a.PlayerO, &a.Result, &a.Termination,
+		&a.Board, &a.Moves, &a.StartedAt, &a.EndedAt, &importedBy, &a.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	a.RoomCode = roomCode.String
+	a.ImportedBy = int(importedBy.Int64)
+	return &a, nil
+}
+
+func (s *SQLGameStore) ShareGame(roomCode, token string, createdBy int) (string, error) {
+	_, err := s.db.Exec(`
+		INSERT INTO game_shares (game_id, token, created_by)
+		SELECT id, $2, $3 FROM games WHERE room_code = $1
+		ON CONFLICT (game_id) DO NOTHING`, roomCode, token, createdBy)
+	if err != nil {
+		return "", err
+	}
+
+	var existing string
+	err = s.db.QueryRow(`SELECT s.token FROM game_shares s JOIN games g ON s.game_id = g.id WHERE g.room_code = $1`, roomCode).Scan(&existing)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return existing, err
+}
+
+func (s *SQLGameStore) UnshareGame(roomCode string) (bool, error) {
+	result, err := s.db.Exec(`DELETE FROM game_shares WHERE game_id = (SELECT id FROM games WHERE room_code = $1)`, roomCode)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+func (s *SQLGameStore) SharedRoomCode(token string) (string, error) {
+	var roomCode string
+	err := s.db.QueryRow(`SELECT g.room_code FROM game_shares s JOIN games g ON s.game_id = g.id WHERE s.token = $1`, token).Scan(&roomCode)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return roomCode, err
+}
+
+func (s *SQLGameStore) ActiveRoomCode(playerID int) (string, error) {
+	var roomCode string
+	query := `SELECT room_code FROM games WHERE (player1_id = $1 OR player2_id = $1) AND status IN ('WAITING', 'IN_PROGRESS') LIMIT 1`
+	err := s.db.QueryRow(query, playerID).Scan(&roomCode)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return roomCode, err
+}
+
+func (s *SQLGameStore) DeleteWaitingGame(roomCode string, hostID int) (bool, error) {
+	//hard delete
+	result, err := s.db.Exec(`DELETE FROM games WHERE room_code = $1 AND player1_id = $2 AND status = 'WAITING'`, roomCode, hostID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+func (s *SQLGameStore) SearchGames(status string, playerID int, roomPrefix string, limit, offset int) ([]GameSummary, error) {
+	query := `
+		SELECT g.id, g.room_code, g.player1_id, p1.username, g.player2_id, p2.username,
+			g.status, g.winner_id, g.termination_reason, g.board, g.created_at
+		FROM games g
+		LEFT JOIN users p1 ON p1.id = g.player1_id
+		LEFT JOIN users p2 ON p2.id = g.player2_id
+		WHERE ($1 = '' OR g.status = $1)
+		AND ($2 = 0 OR g.player1_id = $2 OR g.player2_id = $2)
+		AND g.room_code LIKE $3
+		ORDER BY g.id DESC
+		LIMIT $4 OFFSET $5`
+	rows, err := s.db.Query(query, status, playerID, roomPrefix+"%", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	games := []GameSummary{}
+	for rows.Next() {
+		var g GameSummary
+		if err := rows.Scan(&g.ID, &g.RoomCode, &g.Player1ID, &g.Player1Name, &g.Player2ID, &g.Player2Name, &g.Status, &g.WinnerID, &g.TerminationReason, &g.Board, &g.CreatedAt); err != nil {
+			return nil, err
+		}
+		games = append(games, g)
+	}
+	return games, rows.Err()
+}
+
+func (s *SQLGameStore) WithGameLock(roomCode string, fn func(tx GameTx, g *Game) error) error {
+	//atomic ทั้งก้อน begin - commit
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	// ถ้าเกิดอะไรขึ้นผิดพลาดให้ Rollback เสมอ
+	defer tx.Rollback()
+
+	// SELECT ... FOR UPDATE เพื่อ Lock แถวเกมนั้นไว้จนกว่าจะ Commit/Rollback
+	g, err := scanGame(tx.QueryRow(selectGameColumns+` WHERE room_code = $1 FOR UPDATE`, roomCode))
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&sqlGameTx{tx: tx}, g); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type sqlGameTx struct {
+	tx *sql.Tx
+}
+
+func (t *sqlGameTx) UpdateGame(g *Game) error {
+	query := `UPDATE games SET player2_id = $1, current_turn_id = $2, board = $3, next_board = $4, quantum = $5, status = $6, winner_id = $7,
+				termination_reason = $8, next_room_code = $9, rematch_p1 = $10, rematch_p2 = $11, version = version + 1
+			  WHERE id = $12
+			  RETURNING version`
+	return t.tx.QueryRow(query, g.Player2ID, g.CurrentTurnID, g.Board, g.NextBoard, quantumJSON(g.Quantum), g.Status, g.WinnerID,
+		g.TerminationReason, g.NextRoomCode, g.RematchP1, g.RematchP2, g.ID).Scan(&g.Version)
+}
+
+func (t *sqlGameTx) AppendMove(m *Move) error {
+	query := `INSERT INTO moves (game_id, player_id, x, y, z, move_order)
+			  VALUES ($1, $2, $3, $4, $5, (SELECT count(*)+1 FROM moves WHERE game_id=$1))
+			  RETURNING id, created_at`
+	return t.tx.QueryRow(query, m.GameID, m.PlayerID, m.X, m.Y, m.Z).Scan(&m.ID, &m.CreatedAt)
+}
+
+func (t *sqlGameTx) AppendEvent(e *GameEvent) error {
+	return insertGameEvent(t.tx, e)
+}
+
+func (t *sqlGameTx) CreateGame(g *Game) error {
+	return insertGame(t.tx, g)
+}
+
+func (t *sqlGameTx) AppendAudit(e *AuditEntry) error {
+	return insertAudit(t.tx, e)
+}
+
+// insertAudit - บันทึกลง admin_audit_log (ใน transaction เดียวกับ action นั้น)
+func insertAudit(q queryRower, e *AuditEntry) error {
+	query := `INSERT INTO admin_audit_log (actor_id, action, target_type, target_id, details) VALUES ($1, $2, $3, $4, $5)
+			  RETURNING id, created_at`
+	return q.QueryRow(query, e.ActorID, e.Action, e.TargetType, e.TargetID, string(e.Details)).Scan(&e.ID, &e.CreatedAt)
+}
+
+// ---------- Users ----------
+
+type SQLUserStore struct {
+	db *sql.DB
+}
+
+func (s *SQLUserStore) CreateUser(username, passwordHash string) (int, error) {
+	var userID int
+	err := s.db.QueryRow(`INSERT INTO users (username, password_hash) VALUES ($1, $2) RETURNING id`, username, passwordHash).Scan(&userID)
+	if isUniqueViolation(err, "") {
+		return 0, ErrDuplicate
+	}
+	return userID, err
+}
+
+func (s *SQLUserStore) UserByUsername(username string) (*User, error) {
+	var user User
+	query := `SELECT id, username, COALESCE(password_hash, ''), token_version, totp_enabled, is_guest, role, created_at
+			  FROM users WHERE username = $1 AND deleted_at IS NULL`
+	err := s.db.QueryRow(query, username).Scan(&user.ID, &user.Username, &user.PasswordHash, &user.TokenVersion,
+		&user.TOTPEnabled, &user.IsGuest, &user.Role, &user.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (s *SQLUserStore) SessionState(userID int) (SessionState, error) {
+	var state SessionState
+	query := `SELECT u.token_version, u.deleted_at IS NOT NULL,
+				EXISTS(SELECT 1 FROM user_bans b WHERE b.user_id = u.id AND b.kind = 'BAN' AND ` + activeSanctionCondition + `)
+			  FROM users u WHERE u.id = $1`
+	err := s.db.QueryRow(query, userID).Scan(&state.TokenVersion, &state.Deleted, &state.Banned)
+	if err == sql.ErrNoRows {
+		return state, ErrNotFound
+	}
+	return state, err
+}
+
+func (s *SQLUserStore) Username(userID int) (string, error) {
+	var username string
+	err := s.db.QueryRow(`SELECT username FROM users WHERE id = $1`, userID).Scan(&username)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return username, err
+}
+
+func (s *SQLUserStore) ActiveSanction(userID int, kind string) (*UserBan, error) {
+	query := selectSanctionColumns + ` WHERE b.user_id = $1 AND b.kind = $2 AND ` + activeSanctionCondition + `
+		ORDER BY b.created_at DESC LIMIT 1`
+	ban, err := scanSanction(s.db.QueryRow(query, userID, kind))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+func (s *SQLUserStore) UserByID(userID int) (*User, error) {
+	return scanUser(s.db.QueryRow(selectUserColumns+` WHERE id = $1`, userID))
+}
+
+// selectUserColumns - คอลัมน์ที่ scanUser อ่าน (รวมข้อมูล 2FA ที่ไม่ส่งกลับไปหน้าบ้าน)
+const selectUserColumns = `SELECT id, username, COALESCE(password_hash, ''), token_version, totp_enabled, totp_secret, totp_last_step,
+	is_guest, role, deleted_at, created_at FROM users`
+
+func scanUser(row interface{ Scan(...interface{}) error }) (*User, error) {
+	var u User
+	err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.TokenVersion, &u.TOTPEnabled, &u.TOTPSecret, &u.TOTPLastStep,
+		&u.IsGuest, &u.Role, &u.DeletedAt, &u.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (s *SQLUserStore) CreateGuest(username string) (*User, error) {
+	var userID int
+	err := s.db.QueryRow(`INSERT INTO users (username, password_hash, is_guest) VALUES ($1, NULL, TRUE) RETURNING id`, username).Scan(&userID)
+	if isUniqueViolation(err, "") {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.UserByID(userID)
+}
+
+func (s *SQLUserStore) WithUserLock(userID int, fn func(tx UserTx, u *User) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	u, err := scanUser(tx.QueryRow(selectUserColumns+` WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`, userID))
+	if err != nil {
+		return err
+	}
+
+	if err := fn(&sqlUserTx{tx: tx, userID: userID}, u); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// anonymizeUserColumns - ล้างข้อมูลส่วนตัวของผู้ใช้ (ใช้ทั้งตอนลบบัญชีและตอนเก็บกวาด guest)
+// ชื่อใหม่มี "_" ซึ่ง RegisterHandler ไม่อนุญาต (alphanum) จึงไม่มีทางชนกับผู้ใช้จริง
+const anonymizeUserColumns = `username = 'deleted_' || id, password_hash = NULL,
+	totp_secret = NULL, totp_enabled = FALSE, oidc_issuer = NULL, oidc_subject = NULL,
+	token_version = token_version + 1, deleted_at = CURRENT_TIMESTAMP`
+
+func (s *SQLUserStore) CleanupStaleGuests(cutoff time.Time) (deleted, anonymized int64, err error) {
+	staleCondition := `is_guest = TRUE AND deleted_at IS NULL AND created_at < $1
+		AND NOT EXISTS (
+			SELECT 1 FROM games g
+			WHERE (g.player1_id = users.id OR g.player2_id = users.id)
+			AND (g.status IN ('WAITING', 'IN_PROGRESS') OR g.created_at >= $1)
+		)`
+
+	result, err := s.db.Exec(`DELETE FROM users WHERE `+staleCondition+`
+		AND NOT EXISTS (SELECT 1 FROM games g WHERE g.player1_id = users.id OR g.player2_id = users.id)
+		AND NOT EXISTS (SELECT 1 FROM user_bans b WHERE b.user_id = users.id)
+		AND NOT EXISTS (SELECT 1 FROM archived_games a WHERE a.imported_by = users.id)`, cutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+	deleted, _ = result.RowsAffected()
+
+	result, err = s.db.Exec(`UPDATE users SET `+anonymizeUserColumns+` WHERE `+staleCondition, cutoff)
+	if err != nil {
+		return deleted, 0, err
+	}
+	anonymized, _ = result.RowsAffected()
+	return deleted, anonymized, nil
+}
+
+func (s *SQLUserStore) UserByOIDC(issuer, subject string) (*User, error) {
+	return scanUser(s.db.QueryRow(selectUserColumns+` WHERE oidc_issuer = $1 AND oidc_subject = $2 AND deleted_at IS NULL`, issuer, subject))
+}
+
+func (s *SQLUserStore) LinkOIDC(username, issuer, subject string) (*User, error) {
+	var userID int
+	query := `UPDATE users SET oidc_issuer = $1, oidc_subject = $2
+			  WHERE username = $3 AND oidc_subject IS NULL AND deleted_at IS NULL
+			  RETURNING id`
+	err := s.db.QueryRow(query, issuer, subject, username).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.UserByID(userID)
+}
+
+func (s *SQLUserStore) CreateOIDCUser(username, issuer, subject string) (*User, error) {
+	var userID int
+	query := `INSERT INTO users (username, password_hash, oidc_issuer, oidc_subject) VALUES ($1, NULL, $2, $3) RETURNING id`
+	err := s.db.QueryRow(query, username, issuer, subject).Scan(&userID)
+	if isUniqueViolation(err, "unique_oidc_identity") {
+		// callback สองอันมาพร้อมกัน อีกอันสร้างบัญชีไปแล้ว
+		return s.UserByOIDC(issuer, subject)
+	}
+	if isUniqueViolation(err, "") {
+		return nil, ErrDuplicate
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.UserByID(userID)
+}
+
+func (s *SQLUserStore) SaveOIDCState(st OIDCLoginState, expiredBefore time.Time) error {
+	s.db.Exec(`DELETE FROM oidc_login_states WHERE created_at < $1`, expiredBefore)
+	_, err := s.db.Exec(`INSERT INTO oidc_login_states (state, nonce, code_verifier) VALUES ($1, $2, $3)`, st.State, st.Nonce, st.CodeVerifier)
+	return err
+}
+
+func (s *SQLUserStore) TakeOIDCState(state string) (*OIDCLoginState, error) {
+	st := OIDCLoginState{State: state}
+	err := s.db.QueryRow(`DELETE FROM oidc_login_states WHERE state = $1 RETURNING nonce, code_verifier, created_at`, state).
+		Scan(&st.Nonce, &st.CodeVerifier, &st.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (s *SQLUserStore) ListUsers(query string, limit, offset int) ([]AdminUser, error) {
+	rows, err := s.db.Query(`
+		SELECT u.id, u.username, u.role, u.is_guest, u.deleted_at, u.created_at,
+			EXISTS(SELECT 1 FROM user_bans b WHERE b.user_id = u.id AND b.kind = 'BAN' AND `+activeSanctionCondition+`),
+			EXISTS(SELECT 1 FROM user_bans b WHERE b.user_id = u.id AND b.kind = 'SUSPENSION' AND `+activeSanctionCondition+`)
+		FROM users u
+		WHERE LOWER(u.username) LIKE LOWER($1)
+		ORDER BY u.id ASC
+		LIMIT $2 OFFSET $3`, "%"+query+"%", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []AdminUser{}
+	for rows.Next() {
+		var u AdminUser
+		if err := rows.Scan(&u.ID, &u.Username, &u.Role, &u.IsGuest, &u.DeletedAt, &u.CreatedAt, &u.Banned, &u.Suspended); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (s *SQLUserStore) ListSanctions(userID int) ([]UserBan, error) {
+	rows, err := s.db.Query(selectSanctionColumns+` WHERE b.user_id = $1 ORDER BY b.created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []UserBan{}
+	for rows.Next() {
+		b, err := scanSanction(rows)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, b)
+	}
+	return history, rows.Err()
+}
+
+func (s *SQLUserStore) ListAudit(actorID int, action string, limit, offset int) ([]AuditEntry, error) {
+	query := `
+		SELECT a.id, a.actor_id, u.username, a.action, a.target_type, a.target_id, a.details, a.created_at
+		FROM admin_audit_log a
+		JOIN users u ON u.id = a.actor_id
+		WHERE ($1 = 0 OR a.actor_id = $1) AND ($2 = '' OR a.action = $2)
+		ORDER BY a.id DESC
+		LIMIT $3 OFFSET $4`
+	rows, err := s.db.Query(query, actorID, action, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var e AuditEntry
+		var details sql.NullString
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.ActorName, &e.Action, &e.TargetType, &e.TargetID, &details, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if details.Valid {
+			e.Details = json.RawMessage(details.String)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+type sqlUserTx struct {
+	tx     *sql.Tx
+	userID int
+}
+
+func (t *sqlUserTx) UpdateUser(u *User) error {
+	query := `UPDATE users SET username = $1, password_hash = NULLIF($2, ''), role = $3, is_guest = $4,
+				totp_enabled = $5, totp_secret = $6, totp_last_step = $7, token_version = $8
+			  WHERE id = $9`
+	_, err := t.tx.Exec(query, u.Username, u.PasswordHash, u.Role, u.IsGuest, u.TOTPEnabled, u.TOTPSecret, u.TOTPLastStep, u.TokenVersion, t.userID)
+	if isUniqueViolation(err, "") {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (t *sqlUserTx) Anonymize() error {
+	if _, err := t.tx.Exec(`UPDATE users SET `+anonymizeUserColumns+` WHERE id = $1`, t.userID); err != nil {
+		return err
+	}
+	_, err := t.tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, t.userID)
+	return err
+}
+
+func (t *sqlUserTx) ReplaceRecoveryCodes(hashes []string) error {
+	if _, err := t.tx.Exec(`DELETE FROM recovery_codes WHERE user_id = $1`, t.userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if _, err := t.tx.Exec(`INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, t.userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *sqlUserTx) UseRecoveryCode(hash string) (bool, error) {
+	result, err := t.tx.Exec(`UPDATE recovery_codes SET used_at = CURRENT_TIMESTAMP
+		WHERE id = (SELECT id FROM recovery_codes WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL LIMIT 1)`,
+		t.userID, hash)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+func (t *sqlUserTx) ActiveSanction(kind string) (*UserBan, error) {
+	query := selectSanctionColumns + ` WHERE b.user_id = $1 AND b.kind = $2 AND ` + activeSanctionCondition + `
+		ORDER BY b.created_at DESC LIMIT 1`
+	ban, err := scanSanction(t.tx.QueryRow(query, t.userID, kind))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ban, nil
+}
+
+func (t *sqlUserTx) AddSanction(b *UserBan) error {
+	b.UserID = t.userID
+	query := `INSERT INTO user_bans (user_id, kind, reason, banned_by, expires_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at`
+	return t.tx.QueryRow(query, b.UserID, b.Kind, b.Reason, b.BannedBy, b.ExpiresAt).Scan(&b.ID, &b.CreatedAt)
+}
+
+func (t *sqlUserTx) LiftSanction(kind string, liftedBy int) (bool, error) {
+	result, err := t.tx.Exec(`UPDATE user_bans AS b SET lifted_at = CURRENT_TIMESTAMP, lifted_by = $1
+		WHERE b.user_id = $2 AND b.kind = $3 AND `+activeSanctionCondition, liftedBy, t.userID, kind)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+func (t *sqlUserTx) AppendAudit(e *AuditEntry) error {
+	return insertAudit(t.tx, e)
+}
+
+// ---------- Login throttling ----------
+
+// SQLLoginThrottle - เก็บตัวนับไว้ใน Database เพื่อให้ล็อกยังอยู่แม้ server restart
+type SQLLoginThrottle struct {
+	db *sql.DB
+}
+
+func (t *SQLLoginThrottle) Lockout(username, ip string) (time.Duration, error) {
+	var lockedUntil time.Time
+	query := `SELECT locked_until FROM login_throttles
+			  WHERE throttle_key IN ($1, $2) AND locked_until IS NOT NULL
+			  ORDER BY locked_until DESC LIMIT 1`
+	err := t.db.QueryRow(query, userThrottleKey(username), ipThrottleKey(ip)).Scan(&lockedUntil)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if wait := lockedUntil.Sub(throttleClock()); wait > 0 {
+		return wait, nil
+	}
+	return 0, nil
+}
+
+func (t *SQLLoginThrottle) RecordFailure(username, ip, reason string) {
+	now := throttleClock()
+	t.db.Exec(`INSERT INTO login_failures (username, ip_address, reason, created_at) VALUES ($1, $2, $3, $4)`,
+		username, ip, reason, now)
+
+	// ถูกปฏิเสธเพราะติดล็อกอยู่แล้ว ไม่ต้องนับเพิ่ม (ไม่งั้นล็อกจะยืดไปเรื่อยๆ จากการ retry อัตโนมัติ)
+	if reason == "locked_out" {
+		return
+	}
+
+	t.bump(userThrottleKey(username), loginFreeAttemptsPerUser, now)
+	t.bump(ipThrottleKey(ip), loginFreeAttemptsPerIP, now)
+}
+
+func (t *SQLLoginThrottle) bump(key string, freeAttempts int, now time.Time) {
+	var failures int
+	upsertQuery := `
+		INSERT INTO login_throttles (throttle_key, failures, last_failure_at) VALUES ($1, 1, $2)
+		ON CONFLICT (throttle_key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failure_at < $3 THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = $2
+		RETURNING failures`
+	if err := t.db.QueryRow(upsertQuery, key, now, now.Add(-loginFailureWindow)).Scan(&failures); err != nil {
+		return
+	}
+
+	if lock := lockoutDuration(failures, freeAttempts); lock > 0 {
+		t.db.Exec(`UPDATE login_throttles SET locked_until = $1 WHERE throttle_key = $2`, now.Add(lock), key)
+	}
+}
+
+// Reset - ล้างเฉพาะตัวนับของ username (ตัวนับของ IP ปล่อยให้หมดอายุเอง
+// ไม่งั้นคนที่มีบัญชีจริงหนึ่งบัญชีจะ login สลับไปมาเพื่อรีเซ็ตตัวนับ IP ได้)
+func (t *SQLLoginThrottle) Reset(username string) {
+	t.db.Exec(`DELETE FROM login_throttles WHERE throttle_key = $1`, userThrottleKey(username))
+}
+
+// ---------- Idempotency keys ----------
+
+type SQLIdempotencyStore struct {
+	db *sql.DB
+}
+
+func (s *SQLIdempotencyStore) Reserve(userID int, key, fingerprint string, since time.Time) (*IdempotentResponse, bool, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	// คีย์ที่หมดอายุของผู้ใช้คนนี้ลบทิ้งไปพร้อมกัน (ตารางไม่โตไปเรื่อยๆ และใช้คีย์เดิมซ้ำได้หลังพ้น retention)
+	if _, err := tx.Exec(`DELETE FROM idempotency_keys WHERE user_id = $1 AND created_at < $2`, userID, since); err != nil {
+		return nil, false, err
+	}
+
+	// ถ้ามีอีก request จองคีย์เดียวกันอยู่ INSERT นี้จะรอจนอีกฝั่ง Commit แล้วค่อย DO NOTHING
+	result, err := tx.Exec(`
+		INSERT INTO idempotency_keys (user_id, idem_key, fingerprint, created_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, idem_key) DO NOTHING`, userID, key, fingerprint, time.Now())
+	if err != nil {
+		return nil, false, err
+	}
+	if inserted, _ := result.RowsAffected(); inserted == 1 {
+		return nil, true, tx.Commit()
+	}
+
+	var existing IdempotentResponse
+	var header, body sql.NullString
+	err = tx.QueryRow(`SELECT fingerprint, response_status, response_headers, response_body FROM idempotency_keys WHERE user_id = $1 AND idem_key = $2`,
+		userID, key).Scan(&existing.Fingerprint, &existing.Status, &header, &body)
+	if err != nil {
+		return nil, false, err
+	}
+	if header.Valid {
+		if err := json.Unmarshal([]byte(header.String), &existing.Header); err != nil {
+			return nil, false, err
+		}
+	}
+	existing.Body = []byte(body.String)
+	return &existing, false, tx.Commit()
+}
+
+func (s *SQLIdempotencyStore) Complete(userID int, key string, status int, header map[string]string, body []byte) error {
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`UPDATE idempotency_keys SET response_status = $1, response_headers = $2, response_body = $3 WHERE user_id = $4 AND idem_key = $5`,
+		status, string(headerJSON), string(body), userID, key)
+	return err
+}
+
+func (s *SQLIdempotencyStore) Release(userID int, key string) {
+	s.db.Exec(`DELETE FROM idempotency_keys WHERE user_id = $1 AND idem_key = $2 AND response_status = 0`, userID, key)
+}
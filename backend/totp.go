@@ -0,0 +1,120 @@
+// backend/totp.go
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ค่ามาตรฐานของ TOTP (RFC 6238) ที่แอป Authenticator ทั่วไปรองรับ
+const (
+	totpIssuer = "TicTacToe"
+	totpPeriod = 30
+	totpDigits = 6
+	totpSkew   = 1 // ยอมให้นาฬิกาคลาดได้ ±1 ช่วง (30 วินาที)
+
+	recoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret - สุ่ม secret 160 bit แล้วเข้ารหัสเป็น base32 (ตามที่ otpauth URI ต้องการ)
+func GenerateTOTPSecret() (string, error) {
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(buf), nil
+}
+
+// TOTPURI - สร้าง otpauth:// URI สำหรับทำ QR code ให้แอป Authenticator สแกน
+func TOTPURI(username, secret string) string {
+	label := url.PathEscape(totpIssuer + ":" + username)
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", totpIssuer)
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprint(totpDigits))
+	params.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + label + "?" + params.Encode()
+}
+
+// totpCode - คำนวณรหัส 6 หลักของช่วงเวลา (step) ที่ระบุ ตาม RFC 4226 (HOTP)
+func totpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", err
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000), nil
+}
+
+// VerifyTOTP - ตรวจรหัส TOTP โดยยอมให้คลาดได้ totpSkew ช่วง
+// lastStep คือ step ล่าสุดที่เคยใช้สำเร็จ รหัสที่ step <= lastStep จะถูกปฏิเสธ (กันการเอารหัสเดิมมาใช้ซ้ำ)
+// คืนค่า step ที่ตรง เพื่อให้ผู้เรียกบันทึกเป็น lastStep ใหม่
+func VerifyTOTP(secret, code string, lastStep int64) (int64, bool) {
+	return verifyTOTPAt(secret, code, lastStep, time.Now().Unix()/totpPeriod)
+}
+
+// verifyTOTPAt - VerifyTOTP โดยระบุ step ปัจจุบันเอง (test ใช้ตรวจกับเวลาที่กำหนดได้)
+func verifyTOTPAt(secret, code string, lastStep, current int64) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	for i := -totpSkew; i <= totpSkew; i++ {
+		step := current + int64(i)
+		if step <= lastStep {
+			continue
+		}
+		expected, err := totpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes - สุ่ม recovery code แบบ "xxxxx-xxxxx" สำหรับใช้ครั้งเดียวตอนทำมือถือหาย
+func GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		code := hex.EncodeToString(buf)
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode - recovery code สุ่มมาแบบ entropy สูงอยู่แล้ว ใช้ SHA-256 พอ (ไม่ต้องใช้ bcrypt ที่ช้า)
+func HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.TrimSpace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
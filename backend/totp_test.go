@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+)
+
+// secret ของ test vector ใน RFC 6238 (SHA1) คือ ASCII "12345678901234567890"
+var rfc6238Secret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// TestTOTPCodeRFC6238 - test vector จาก RFC 6238 ภาคผนวก B (ตัดเหลือ 6 หลักท้ายตาม totpDigits)
+func TestTOTPCodeRFC6238(t *testing.T) {
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		code, err := totpCode(rfc6238Secret, tt.unix/totpPeriod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != tt.code {
+			t.Errorf("T=%d: code = %s, want %s", tt.unix, code, tt.code)
+		}
+		if step, ok := verifyTOTPAt(rfc6238Secret, tt.code, 0, tt.unix/totpPeriod); !ok || step != tt.unix/totpPeriod {
+			t.Errorf("T=%d: verify = (%d, %v), want (%d, true)", tt.unix, step, ok, tt.unix/totpPeriod)
+		}
+	}
+}
+
+func TestVerifyTOTPWindow(t *testing.T) {
+	const current = int64(1234567890 / totpPeriod)
+	codeAt := func(step int64) string {
+		code, err := totpCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantStep int64
+		ok       bool
+	}{
+		{"current step", codeAt(current), 0, current, true},
+		{"one step behind", codeAt(current - 1), 0, current - 1, true},
+		{"one step ahead", codeAt(current + 1), 0, current + 1, true},
+		{"two steps behind", codeAt(current - 2), 0, 0, false},
+		{"two steps ahead", codeAt(current + 2), 0, 0, false},
+		{"already used step", codeAt(current), current, 0, false},
+		{"older than last used step", codeAt(current - 1), current, 0, false},
+		{"newer than last used step", codeAt(current + 1), current, current + 1, true},
+		{"surrounding spaces", " " + codeAt(current) + " ", 0, current, true},
+		{"wrong length", codeAt(current)[:5], 0, 0, false},
+		{"wrong code", "000000", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := verifyTOTPAt(rfc6238Secret, tt.code, tt.lastStep, current)
+			if ok != tt.ok || step != tt.wantStep {
+				t.Fatalf("verify = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.ok)
+			}
+		})
+	}
+}
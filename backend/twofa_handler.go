@@ -0,0 +1,225 @@
+// backend/twofa_handler.go
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupTwoFactorHandler - เริ่มลงทะเบียน 2FA: สุ่ม secret แล้วส่ง otpauth URI กลับไปให้ทำ QR code
+// ยังไม่ active จนกว่าจะยืนยันรหัสแรกที่ ConfirmTwoFactorHandler
+func (s *Server) SetupTwoFactorHandler(c *gin.Context) {
+	userIDContext, _ := c.Get("userID")
+	userID := userIDContext.(int)
+
+	if c.GetBool("isGuest") {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Guest accounts must be upgraded first (POST /api/guest/upgrade)"})
+		return
+	}
+
+	var username, secret string
+	err := s.Users.WithUserLock(userID, func(tx UserTx, u *User) error {
+		if u.TOTPEnabled {
+			return reject(http.StatusConflict, "Two-factor authentication is already enabled")
+		}
+
+		var err error
+		if secret, err = GenerateTOTPSecret(); err != nil {
+			return err
+		}
+		username = u.Username
+		u.TOTPSecret, u.TOTPLastStep = &secret, 0
+		return tx.UpdateUser(u)
+	})
+	if respondStoreError(c, err, "User not found", "Failed to save secret") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Scan the QR code with your authenticator app, then confirm with a code",
+		"secret":      secret,
+		"otpauth_uri": TOTPURI(username, secret),
+	})
+}
+
+// ConfirmTwoFactorHandler - ยืนยันรหัสแรกจากแอป แล้วเปิดใช้ 2FA พร้อมแจก recovery codes (แสดงครั้งเดียว)
+func (s *Server) ConfirmTwoFactorHandler(c *gin.Context) {
+	var req struct {
+		Code string `json:"code" binding:"required"`
+	}
+	userIDContext, _ := c.Get("userID")
+	userID := userIDContext.(int)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var codes []string
+	err := s.Users.WithUserLock(userID, func(tx UserTx, u *User) error {
+		if u.TOTPEnabled {
+			return reject(http.StatusConflict, "Two-factor authentication is already enabled")
+		}
+		if u.TOTPSecret == nil {
+			return reject(http.StatusBadRequest, "Call /api/2fa/setup first")
+		}
+
+		step, ok := VerifyTOTP(*u.TOTPSecret, req.Code, u.TOTPLastStep)
+		if !ok {
+			return reject(http.StatusUnauthorized, "Invalid two-factor code")
+		}
+
+		var err error
+		if codes, err = GenerateRecoveryCodes(); err != nil {
+			return err
+		}
+
+		// แทน recovery code ชุดเก่า (ถ้าเคยเปิดแล้วปิดไป) ด้วยชุดใหม่
+		hashes := make([]string, len(codes))
+		for i, code := range codes {
+			hashes[i] = HashRecoveryCode(code)
+		}
+		if err := tx.ReplaceRecoveryCodes(hashes); err != nil {
+			return err
+		}
+
+		u.TOTPEnabled, u.TOTPLastStep = true, step
+		return tx.UpdateUser(u)
+	})
+	if respondStoreError(c, err, "User not found", "Failed to enable two-factor authentication") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled. Store these recovery codes somewhere safe.",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTwoFactorHandler - ปิด 2FA (ต้องใส่รหัสผ่านและรหัส 2FA ปัจจุบัน)
+func (s *Server) DisableTwoFactorHandler(c *gin.Context) {
+	var req struct {
+		Password string `json:"password"` // บัญชี SSO อย่างเดียวไม่ต้องใส่
+		Code     string `json:"code" binding:"required"`
+	}
+	userIDContext, _ := c.Get("userID")
+	userID := userIDContext.(int)
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := s.Users.WithUserLock(userID, func(tx UserTx, u *User) error {
+		if !u.TOTPEnabled || u.TOTPSecret == nil {
+			return reject(http.StatusBadRequest, "Two-factor authentication is not enabled")
+		}
+		if u.PasswordHash != "" && !CheckPasswordHash(req.Password, u.PasswordHash) {
+			return reject(http.StatusUnauthorized, "Password is incorrect")
+		}
+		if _, ok := VerifyTOTP(*u.TOTPSecret, req.Code, u.TOTPLastStep); !ok {
+			return reject(http.StatusUnauthorized, "Invalid two-factor code")
+		}
+
+		if err := tx.ReplaceRecoveryCodes(nil); err != nil {
+			return err
+		}
+		u.TOTPEnabled, u.TOTPSecret, u.TOTPLastStep = false, nil, 0
+		return tx.UpdateUser(u)
+	})
+	if respondStoreError(c, err, "User not found", "Failed to disable two-factor authentication") {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// LoginTwoFactorHandler - แลก pending token + รหัส TOTP (หรือ recovery code) เป็น access token จริง
+func (s *Server) LoginTwoFactorHandler(c *gin.Context) {
+	var req struct {
+		PendingToken string `json:"pending_token" binding:"required"`
+		Code         string `json:"code"`
+		RecoveryCode string `json:"recovery_code"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Code == "" && req.RecoveryCode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code or recovery_code is required"})
+		return
+	}
+
+	claims, err := ParseToken(req.PendingToken)
+	if err != nil || claims == nil || !claims.TwoFactorPending {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired pending token. Please login again."})
+		return
+	}
+
+	invalidPending := reject(http.StatusUnauthorized, "Invalid or expired pending token. Please login again.")
+	pending, err := s.Users.UserByID(claims.UserID)
+	if err != nil || pending.DeletedAt != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": invalidPending.Error()})
+		return
+	}
+
+	// รหัส 6 หลักเดาได้ง่ายกว่ารหัสผ่าน ใช้ตัวนับ/ล็อกชุดเดียวกับ LoginHandler
+	clientIP := c.ClientIP()
+	wait, err := s.Throttle.Lockout(pending.Username, clientIP)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check login attempts"})
+		return
+	}
+	if wait > 0 {
+		s.Throttle.RecordFailure(pending.Username, clientIP, "locked_out")
+		respondLockedOut(c, wait)
+		return
+	}
+
+	// lock แถว user ไว้ กันการยิงรหัสเดียวกันพร้อมกันหลาย request
+	// รหัสผิดจะบันทึกหลังปล่อย lock แล้ว (SQLite ล็อกทั้งไฟล์ ถ้าถือ transaction ไว้การบันทึกจะต้องรอจนหมดเวลา)
+	var user User
+	failure := ""
+	err = s.Users.WithUserLock(claims.UserID, func(tx UserTx, u *User) error {
+		if u.TokenVersion != claims.TokenVersion || !u.TOTPEnabled || u.TOTPSecret == nil {
+			return invalidPending
+		}
+		user = *u
+
+		if req.Code != "" {
+			step, ok := VerifyTOTP(*u.TOTPSecret, req.Code, u.TOTPLastStep)
+			if !ok {
+				failure = "invalid_2fa_code"
+				return reject(http.StatusUnauthorized, "Invalid two-factor code")
+			}
+			u.TOTPLastStep = step
+			return tx.UpdateUser(u)
+		}
+
+		// recovery code ใช้ได้ครั้งเดียว -> mark used_at ทันที
+		used, err := tx.UseRecoveryCode(HashRecoveryCode(req.RecoveryCode))
+		if err != nil {
+			return err
+		}
+		if !used {
+			failure = "invalid_recovery_code"
+			return reject(http.StatusUnauthorized, "Invalid recovery code")
+		}
+		return nil
+	})
+	if failure != "" {
+		s.Throttle.RecordFailure(user.Username, clientIP, failure)
+	}
+	if errors.Is(err, ErrNotFound) {
+		err = invalidPending
+	}
+	if respondStoreError(c, err, "", "Failed to verify code") {
+		return
+	}
+	s.Throttle.Reset(user.Username)
+
+	respondWithToken(c, user)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// setTestPassword - ตั้งรหัสผ่านด้วย bcrypt cost ต่ำ (HashPassword ใช้ cost 14 ช้าเกินไปสำหรับ test)
+func setTestPassword(t *testing.T, s *Server, userID int, password string) {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Users.WithUserLock(userID, func(tx UserTx, u *User) error {
+		u.PasswordHash = string(hash)
+		return tx.UpdateUser(u)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// currentTOTPCode - รหัสของ step ปัจจุบัน + offset (ยืนยันด้วย step ไหนแล้ว step นั้นจะใช้ซ้ำไม่ได้)
+func currentTOTPCode(t *testing.T, secret string, offset int64) string {
+	t.Helper()
+	code, err := totpCode(secret, time.Now().Unix()/totpPeriod+offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// enableTestTwoFactor - เปิด 2FA ผ่าน /api/2fa/setup + confirm คืน secret กับ recovery codes
+func enableTestTwoFactor(t *testing.T, h http.Handler, token string) (string, []string) {
+	t.Helper()
+	w := doRequest(t, h, http.MethodPost, "/api/2fa/setup", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("2fa setup: %d %s", w.Code, w.Body.String())
+	}
+	secret := decodeBody(t, w)["secret"].(string)
+
+	w = doRequest(t, h, http.MethodPost, "/api/2fa/confirm", token, gin.H{"code": currentTOTPCode(t, secret, 0)})
+	if w.Code != http.StatusOK {
+		t.Fatalf("2fa confirm: %d %s", w.Code, w.Body.String())
+	}
+	var codes []string
+	for _, c := range decodeBody(t, w)["recovery_codes"].([]interface{}) {
+		codes = append(codes, c.(string))
+	}
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("got %d recovery codes, want %d", len(codes), recoveryCodeCount)
+	}
+	return secret, codes
+}
+
+// loginPending - login ด้วยรหัสผ่านของบัญชีที่เปิด 2FA ต้องได้ pending token ไม่ใช่ token จริง
+func loginPending(t *testing.T, h http.Handler, username, password string) string {
+	t.Helper()
+	w := doRequest(t, h, http.MethodPost, "/api/login", "", gin.H{"username": username, "password": password})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: %d %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	if body["two_factor_required"] != true || body["token"] != nil {
+		t.Fatalf("login with 2fa enabled returned %v, want pending token only", body)
+	}
+	return body["pending_token"].(string)
+}
+
+func TestLoginTwoFactorFlow(t *testing.T) {
+	s, h := newTestServer(t)
+	userID, token := newTestUser(t, s, "alice")
+	setTestPassword(t, s, userID, "secret123")
+	secret, _ := enableTestTwoFactor(t, h, token)
+
+	pending := loginPending(t, h, "alice", "secret123")
+	if w := doRequest(t, h, http.MethodGet, "/api/games/me/active", pending, nil); w.Code != http.StatusUnauthorized {
+		t.Fatalf("pending token accepted by AuthMiddleware: %d", w.Code)
+	}
+
+	// step ที่ใช้ confirm ไปแล้วใช้ซ้ำไม่ได้ -> ใช้รหัสของ step ถัดไป (ยังอยู่ในช่วง ±1)
+	user, err := s.Users.UserByID(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	used, err := totpCode(secret, user.TOTPLastStep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := doRequest(t, h, http.MethodPost, "/api/login/2fa", "", gin.H{"pending_token": pending, "code": used})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("reused step: %d %s, want 401", w.Code, w.Body.String())
+	}
+	next, err := totpCode(secret, user.TOTPLastStep+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = doRequest(t, h, http.MethodPost, "/api/login/2fa", "", gin.H{"pending_token": pending, "code": next})
+	if w.Code != http.StatusOK {
+		t.Fatalf("login/2fa: %d %s", w.Code, w.Body.String())
+	}
+	full := decodeBody(t, w)["token"].(string)
+	if w := doRequest(t, h, http.MethodGet, "/api/games/me/active", full, nil); w.Code != http.StatusOK {
+		t.Fatalf("token from login/2fa rejected: %d %s", w.Code, w.Body.String())
+	}
+
+	// token จริงแลกซ้ำเป็น pending ไม่ได้
+	w = doRequest(t, h, http.MethodPost, "/api/login/2fa", "", gin.H{"pending_token": full, "code": "123456"})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("full token used as pending token: %d, want 401", w.Code)
+	}
+}
+
+func TestRecoveryCodeWorksOnce(t *testing.T) {
+	s, h := newTestServer(t)
+	userID, token := newTestUser(t, s, "alice")
+	setTestPassword(t, s, userID, "secret123")
+	_, codes := enableTestTwoFactor(t, h, token)
+
+	pending := loginPending(t, h, "alice", "secret123")
+	w := doRequest(t, h, http.MethodPost, "/api/login/2fa", "", gin.H{"pending_token": pending, "recovery_code": codes[0]})
+	if w.Code != http.StatusOK {
+		t.Fatalf("first use: %d %s", w.Code, w.Body.String())
+	}
+
+	pending = loginPending(t, h, "alice", "secret123")
+	w = doRequest(t, h, http.MethodPost, "/api/login/2fa", "", gin.H{"pending_token": pending, "recovery_code": codes[0]})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("second use: %d %s, want 401", w.Code, w.Body.String())
+	}
+
+	// code อื่นในชุดยังใช้ได้ (ตัวพิมพ์ใหญ่/ช่องว่างไม่มีผล)
+	w = doRequest(t, h, http.MethodPost, "/api/login/2fa", "", gin.H{"pending_token": pending, "recovery_code": " " + strings.ToUpper(codes[1]) + " "})
+	if w.Code != http.StatusOK {
+		t.Fatalf("another code: %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWrongTwoFactorCodeCountsAgainstThrottle(t *testing.T) {
+	s, h := newTestServer(t)
+	userID, token := newTestUser(t, s, "alice")
+	setTestPassword(t, s, userID, "secret123")
+	secret, _ := enableTestTwoFactor(t, h, token)
+
+	pending := loginPending(t, h, "alice", "secret123")
+	for i := 0; i < loginFreeAttemptsPerUser; i++ {
+		w := doRequest(t, h, http.MethodPost, "/api/login/2fa", "", gin.H{"pending_token": pending, "code": "000000"})
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("wrong code %d: %d %s, want 401", i+1, w.Code, w.Body.String())
+		}
+	}
+
+	// ครบโควต้าแล้ว รหัสถูกก็ต้องรอ
+	w := doRequest(t, h, http.MethodPost, "/api/login/2fa", "", gin.H{"pending_token": pending, "code": currentTOTPCode(t, secret, 1)})
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Fatalf("after %d wrong codes: %d (Retry-After %q), want 429", loginFreeAttemptsPerUser, w.Code, w.Header().Get("Retry-After"))
+	}
+	w = doRequest(t, h, http.MethodPost, "/api/login", "", gin.H{"username": "alice", "password": "secret123"})
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("password login while locked: %d, want 429", w.Code)
+	}
+}
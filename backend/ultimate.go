@@ -0,0 +1,106 @@
+// backend/ultimate.go
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ultimate Tic-Tac-Toe: กระดาน 9x9 แบ่งเป็นกระดานย่อย 3x3 เก้ากระดาน (เลข 0-8 เรียงแบบเดียวกับช่องของกระดานปกติ)
+//   - ช่องที่ลงในกระดานย่อย บอกว่าอีกฝั่งต้องลงกระดานย่อยไหนต่อ (ลงช่องกลาง -> อีกฝั่งต้องลงกระดานย่อยกลาง)
+//   - ถ้ากระดานย่อยนั้นจบไปแล้ว (มีคนชนะหรือเต็ม) อีกฝั่งลงกระดานย่อยไหนก็ได้ที่ยังไม่จบ
+//   - ชนะกระดานย่อย = ได้ช่องนั้นบนกระดานใหญ่ (macro) แล้วตัดสินกระดานใหญ่ด้วย CheckWinner เหมือนกระดานปกติ
+//
+// games.board เก็บ 81 ช่องเรียงแถวละ 9 (index = y*9 + x) ส่วน games.next_board คือกระดานย่อยที่ตาต่อไปต้องลง
+// winning_line ของ ultimate เป็นเลขกระดานย่อยที่เรียงกันชนะบนกระดานใหญ่
+type ultimateVariant struct{}
+
+func (ultimateVariant) Defaults() (int, int, int, int) { return 9, 9, 1, 3 }
+
+// subBoardAt - (x, y) อยู่ในกระดานย่อยไหน และเป็นช่องที่เท่าไหร่ของกระดานย่อยนั้น
+func subBoardAt(x, y int) (board, cell int) {
+	return (y/3)*3 + x/3, (y%3)*3 + x%3
+}
+
+// subBoard - 9 ช่องของกระดานย่อย b ในรูปแบบเดียวกับกระดานปกติ
+func subBoard(board string, b int) string {
+	top, left := (b/3)*3, (b%3)*3
+	var sub strings.Builder
+	for row := 0; row < 3; row++ {
+		start := (top+row)*9 + left
+		sub.WriteString(board[start : start+3])
+	}
+	return sub.String()
+}
+
+// macroBoard - กระดานใหญ่: X / O = กระดานย่อยที่มีคนชนะแล้ว, - = ยังเล่นอยู่หรือเสมอ
+func macroBoard(board string) string {
+	macro := []byte("---------")
+	for b := 0; b < 9; b++ {
+		if winner, _ := CheckWinner(subBoard(board, b)); winner == "X" || winner == "O" {
+			macro[b] = winner[0]
+		}
+	}
+	return string(macro)
+}
+
+// subBoardClosed - กระดานย่อย b จบแล้ว (มีคนชนะหรือเต็ม) ลงเพิ่มไม่ได้
+func subBoardClosed(board string, b int) bool {
+	winner, _ := CheckWinner(subBoard(board, b))
+	return winner != ""
+}
+
+// legalUltimateBoards - กระดานย่อยที่ตาต่อไปลงได้
+func legalUltimateBoards(g *Game) []int {
+	if g.NextBoard != nil {
+		return []int{*g.NextBoard}
+	}
+	legal := []int{}
+	for b := 0; b < 9; b++ {
+		if !subBoardClosed(g.Board, b) {
+			legal = append(legal, b)
+		}
+	}
+	return legal
+}
+
+func (ultimateVariant) CheckMove(g *Game, x, y, z int) error {
+	if err := checkInside(g, x, y, z); err != nil {
+		return err
+	}
+	b, _ := subBoardAt(x, y)
+	if g.NextBoard != nil && *g.NextBoard != b {
+		return fmt.Errorf("You must play in sub-board %d", *g.NextBoard)
+	}
+	if subBoardClosed(g.Board, b) {
+		return fmt.Errorf("Sub-board %d is already decided", b)
+	}
+	if g.Board[y*9+x] != '-' {
+		return errCellOccupied
+	}
+	return nil
+}
+
+func (ultimateVariant) Play(g *Game, x, y, z int, mark byte) {
+	placeMark(g, x, y, z, mark)
+
+	// ช่องที่ลง = กระดานย่อยที่อีกฝั่งต้องลงต่อ (ถ้ากระดานย่อยนั้นจบแล้วลงที่ไหนก็ได้)
+	_, next := subBoardAt(x, y)
+	g.NextBoard = nil
+	if !subBoardClosed(g.Board, next) {
+		g.NextBoard = &next
+	}
+}
+
+func (ultimateVariant) Outcome(g *Game) (string, []int) {
+	winner, line := CheckWinner(macroBoard(g.Board))
+	if winner == "X" || winner == "O" {
+		return winner, line
+	}
+	for b := 0; b < 9; b++ {
+		if !subBoardClosed(g.Board, b) {
+			return "", nil
+		}
+	}
+	return "DRAW", nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ultimateGame - ห้อง ultimate ที่กระดานย่อยแต่ละกระดานเป็นไปตาม subs (key = เลขกระดานย่อย, ค่า = 9 ช่องแบบกระดานปกติ)
+func ultimateGame(subs map[int]string) *Game {
+	g := &Game{Variant: VariantUltimate, Status: "IN_PROGRESS"}
+	setGameDefaults(g)
+	board := []byte(emptyBoard(g))
+	for b, sub := range subs {
+		top, left := (b/3)*3, (b%3)*3
+		for cell := 0; cell < 9; cell++ {
+			board[(top+cell/3)*9+left+cell%3] = sub[cell]
+		}
+	}
+	g.Board = string(board)
+	return g
+}
+
+func TestUltimateForcedBoard(t *testing.T) {
+	g := ultimateGame(nil)
+	variant := ultimateVariant{}
+
+	// ช่องกลางของกระดานย่อยกลาง -> อีกฝั่งต้องลงกระดานย่อยกลาง
+	variant.Play(g, 4, 4, 0, 'X')
+	if g.NextBoard == nil || *g.NextBoard != 4 {
+		t.Fatalf("next board = %v, want 4", g.NextBoard)
+	}
+	if got := LegalBoards(g); !reflect.DeepEqual(got, []int{4}) {
+		t.Fatalf("legal boards = %v, want [4]", got)
+	}
+	if err := variant.CheckMove(g, 0, 0, 0); err == nil {
+		t.Fatal("move outside the forced board accepted")
+	}
+	if err := variant.CheckMove(g, 4, 4, 0); err != errCellOccupied {
+		t.Fatalf("occupied cell: %v", err)
+	}
+	if err := variant.CheckMove(g, 3, 3, 0); err != nil {
+		t.Fatalf("move in the forced board: %v", err)
+	}
+
+	// ช่องมุมบนซ้ายของกระดานย่อยกลาง (3, 3) -> กระดานย่อย 0
+	variant.Play(g, 3, 3, 0, 'O')
+	if g.NextBoard == nil || *g.NextBoard != 0 {
+		t.Fatalf("next board = %v, want 0", g.NextBoard)
+	}
+}
+
+func TestUltimateRedirectFromClosedBoard(t *testing.T) {
+	tests := []struct {
+		name string
+		sub0 string
+	}{
+		{"won board", "XXX-OO---"},
+		{"full drawn board", "XOXXOOOXX"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := ultimateGame(map[int]string{0: tt.sub0})
+			variant := ultimateVariant{}
+
+			// (3, 3) คือช่อง 0 ของกระดานย่อย 4 ปกติจะส่งไปกระดานย่อย 0 แต่กระดานนั้นจบแล้ว
+			variant.Play(g, 3, 3, 0, 'O')
+			if g.NextBoard != nil {
+				t.Fatalf("next board = %d, want free choice", *g.NextBoard)
+			}
+			if got := LegalBoards(g); !reflect.DeepEqual(got, []int{1, 2, 3, 4, 5, 6, 7, 8}) {
+				t.Fatalf("legal boards = %v", got)
+			}
+			if err := variant.CheckMove(g, 8, 8, 0); err != nil {
+				t.Fatalf("move in an open board: %v", err)
+			}
+			err := variant.CheckMove(g, 0, 2, 0)
+			if err == nil || !strings.Contains(err.Error(), "already decided") {
+				t.Fatalf("move in the closed board: %v", err)
+			}
+		})
+	}
+}
+
+func TestUltimateOutcome(t *testing.T) {
+	won := "XXX-OO---"
+	g := ultimateGame(map[int]string{0: won, 4: won, 8: won})
+	if winner, line := (ultimateVariant{}).Outcome(g); winner != "X" || !reflect.DeepEqual(line, []int{0, 4, 8}) {
+		t.Fatalf("outcome = %q %v", winner, line)
+	}
+
+	// ทุกกระดานย่อยจบแล้วแต่ไม่มีแถวบนกระดานใหญ่ = เสมอ
+	drawn := map[int]string{}
+	for b := 0; b < 9; b++ {
+		drawn[b] = "XOXXOOOXX"
+	}
+	drawn[0] = won
+	if winner, _ := (ultimateVariant{}).Outcome(ultimateGame(drawn)); winner != "DRAW" {
+		t.Fatalf("outcome = %q, want DRAW", winner)
+	}
+}
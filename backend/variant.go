@@ -0,0 +1,182 @@
+// backend/variant.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// variant ของห้อง (games.variant) เลือกตอนสร้างห้อง
+const (
+	VariantStandard = "standard" // 3x3 ปกติ
+	VariantUltimate = "ultimate" // 9x9 = กระดานย่อย 3x3 เก้ากระดาน (ดู ultimate.go)
+	VariantGravity  = "gravity"  // เลือกแค่คอลัมน์ หมากตกลงช่องล่างสุด (Connect Four ดู gravity.go)
+	VariantMisere   = "misere"   // 3x3 แต่ใครเรียงครบแถวแพ้ (ดู misere.go)
+	VariantNotakto  = "notakto"  // ทั้งสองฝั่งลง X บนกระดาน 3x3 หนึ่งถึงสามกระดาน ใครทำให้กระดานสุดท้ายเรียงครบแพ้ (ดู misere.go)
+	VariantQuantum  = "quantum"  // แต่ละตาลง spooky mark สองช่อง แล้ว collapse เมื่อเกิด cycle (ดู quantum.go)
+	VariantQubic    = "qubic"    // 3 มิติ 4x4x4 เรียง 4 ช่องชนะ (ดู qubic.go)
+)
+
+// ขนาดกระดานที่ตั้งเองได้ (games.width / height / win_length) ใช้กับ gravity และจำนวนกระดานของ notakto
+// แถวไม่เกิน 9 เพราะ game record เขียนแถวเป็นเลขหลักเดียว
+const (
+	minBoardSize = 3
+	maxBoardSize = 9
+)
+
+// Variant - กติกาของกระดานแต่ละแบบ
+// ApplyEvent เรียกผ่าน interface นี้ทั้งหมด จึงได้ผลเหมือนกันทั้งตอนเดินจริงและตอน replay event
+// ขนาดกระดานอยู่ใน g.Width / g.Height / g.Depth (x = 0..Width-1, y = 0..Height-1 นับจากบนลงล่าง, z = ชั้น 0..Depth-1)
+// กระดาน 2 มิติมี Depth = 1 จึงลงได้แค่ z = 0
+type Variant interface {
+	// Defaults - ขนาดกระดานและจำนวนที่ต้องเรียงกันถึงจะชนะ ถ้าตอนสร้างห้องไม่ได้กำหนดมา
+	Defaults() (width, height, depth, winLength int)
+	// CheckMove - error (ข้อความส่งให้ client ได้) ถ้าลง (x, y, z) ในห้อง g ตอนนี้ไม่ได้
+	CheckMove(g *Game, x, y, z int) error
+	// Play - ลงหมาก mark ที่ (x, y, z) แล้วอัปเดต board และ state อื่นของ variant (ต้องผ่าน CheckMove มาแล้ว)
+	Play(g *Game, x, y, z int, mark byte)
+	// Outcome - ผลของกระดาน "X" = ฝั่ง player1 ชนะ, "O" = ฝั่ง player2 ชนะ, "DRAW", "" (ยังไม่จบ)
+	// กับตำแหน่งของแถวที่ตัดสินผล (ไม่จำเป็นต้องเป็นแถวของฝั่งที่ชนะ เช่น misère)
+	Outcome(g *Game) (string, []int)
+}
+
+// sharedMarker - variant ที่ทั้งสองฝั่งลงหมากแบบเดียวกัน (notakto)
+type sharedMarker interface {
+	SharedMark() byte
+}
+
+// columnDropper - variant ที่ผู้เล่นเลือกแค่คอลัมน์ แล้ว server หาแถวที่หมากตกลงไปให้
+type columnDropper interface {
+	DropRow(g *Game, x int) (int, error)
+}
+
+var variants = map[string]Variant{
+	VariantStandard: standardVariant{},
+	VariantUltimate: ultimateVariant{},
+	VariantGravity:  gravityVariant{},
+	VariantMisere:   misereVariant{},
+	VariantNotakto:  notaktoVariant{},
+	VariantQuantum:  quantumVariant{},
+	VariantQubic:    qubicVariant{},
+}
+
+var errCellOccupied = errors.New("Cell already occupied")
+
+// variantOf - กติกาของห้อง (ห้องเก่าที่ไม่มี variant = standard)
+func variantOf(g *Game) Variant {
+	if v, ok := variants[g.Variant]; ok {
+		return v
+	}
+	return standardVariant{}
+}
+
+// markOf - หมากที่ playerID ลงในห้อง g (ปกติ player1 = X, player2 = O)
+func markOf(g *Game, playerID int) byte {
+	if v, ok := variantOf(g).(sharedMarker); ok {
+		return v.SharedMark()
+	}
+	if g.Player2ID != nil && playerID == *g.Player2ID {
+		return 'O'
+	}
+	return 'X'
+}
+
+// LegalBoards - กระดานย่อยที่ตาต่อไปลงได้ (ultimate / notakto) nil ถ้าเป็น variant อื่นหรือเกมไม่ได้กำลังเล่นอยู่
+func LegalBoards(g *Game) []int {
+	if g.Status != "IN_PROGRESS" {
+		return nil
+	}
+	switch g.Variant {
+	case VariantUltimate:
+		return legalUltimateBoards(g)
+	case VariantNotakto:
+		return liveNotaktoBoards(g)
+	}
+	return nil
+}
+
+// setGameDefaults - เติม variant / ขนาดกระดาน / กติกาเสมอ / state ของ quantum ที่ไม่ได้กำหนดมา (store เรียกก่อนบันทึกห้องใหม่)
+func setGameDefaults(g *Game) {
+	if g.Variant == "" {
+		g.Variant = VariantStandard
+	}
+	width, height, depth, winLength := variantOf(g).Defaults()
+	if g.Width == 0 || g.Height == 0 {
+		g.Width, g.Height = width, height
+	}
+	if g.Depth == 0 {
+		g.Depth = depth
+	}
+	if g.WinLength == 0 {
+		g.WinLength = winLength
+	}
+	if g.DrawRule == "" {
+		g.DrawRule = DrawRuleFullBoard
+	}
+	if g.Variant == VariantQuantum && g.Quantum == nil {
+		g.Quantum = emptyQuantumState()
+	}
+}
+
+// emptyBoard - กระดานเปล่าตามขนาดของห้อง
+func emptyBoard(g *Game) string {
+	return strings.Repeat("-", g.Width*g.Height*g.Depth)
+}
+
+// boardDims - ขนาดแต่ละแกนของกระดาน สำหรับ CheckLine (ไม่มีแกน z ถ้าเป็นกระดาน 2 มิติ)
+func boardDims(g *Game) []int {
+	if g.Depth > 1 {
+		return []int{g.Width, g.Height, g.Depth}
+	}
+	return []int{g.Width, g.Height}
+}
+
+// cellIndex - ตำแหน่งของ (x, y, z) ใน board (ชั้นละ Width*Height ช่อง แต่ละชั้นเก็บแถวละ Width ช่อง)
+func cellIndex(g *Game, x, y, z int) int {
+	return (z*g.Height+y)*g.Width + x
+}
+
+// checkInside - error ถ้า (x, y, z) อยู่นอกกระดาน
+func checkInside(g *Game, x, y, z int) error {
+	if z < 0 || z >= g.Depth {
+		if g.Depth <= 1 {
+			return fmt.Errorf("z is only allowed on 3D boards")
+		}
+		return fmt.Errorf("Move is outside the %dx%dx%d board", g.Width, g.Height, g.Depth)
+	}
+	if x < 0 || x >= g.Width || y < 0 || y >= g.Height {
+		return fmt.Errorf("Move is outside the %dx%d board", g.Width, g.Height)
+	}
+	return nil
+}
+
+// placeMark - ลง mark ที่ (x, y, z)
+func placeMark(g *Game, x, y, z int, mark byte) {
+	index := cellIndex(g, x, y, z)
+	g.Board = g.Board[:index] + string(mark) + g.Board[index+1:]
+}
+
+// ---------- standard ----------
+
+type standardVariant struct{}
+
+func (standardVariant) Defaults() (int, int, int, int) { return 3, 3, 1, 3 }
+
+func (standardVariant) CheckMove(g *Game, x, y, z int) error {
+	if err := checkInside(g, x, y, z); err != nil {
+		return err
+	}
+	if g.Board[cellIndex(g, x, y, z)] != '-' {
+		return errCellOccupied
+	}
+	return nil
+}
+
+func (standardVariant) Play(g *Game, x, y, z int, mark byte) {
+	placeMark(g, x, y, z, mark)
+}
+
+func (standardVariant) Outcome(g *Game) (string, []int) {
+	return CheckLine(g.Board, boardDims(g), g.WinLength)
+}
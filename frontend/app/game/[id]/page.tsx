@@ -9,12 +9,33 @@ interface GameData {
   player1_id: number;
   player2_id: number | null;
   current_turn_id: number;
+  variant: string; // standard, ultimate, gravity, misere, notakto, quantum, qubic
+  width: number; // ขนาดกระดาน (standard = 3x3, ultimate = 9x9, gravity ตั้งได้ ค่าเริ่มต้น 7x6)
+  height: number;
+  depth: number; // จำนวนชั้น (1 = กระดาน 2 มิติ, qubic = 4) board เรียงทีละชั้น
   board: string;
+  legal_boards: number[] | null; // ultimate / notakto: กระดานย่อยที่ตาต่อไปลงได้
+  quantum: QuantumState | null; // quantum: spooky mark ทั้งหมด (board มีแค่หมาก classical)
   status: string;
   winner_id: number | null;
+  winning_line: number[] | null; // ช่องที่เรียงกันชนะ (ไว้ไฮไลต์) ของ ultimate เป็นเลขกระดานย่อย
+  termination_reason: string | null;
+  draw_rule: string;
   next_room_code: string | null; 
   rematch_p1: boolean;          
   rematch_p2: boolean;
+  version: number;
+}
+
+interface SpookyMark {
+  mark: string;
+  cells: [number, number];
+  cell: number | null; // ช่องที่ collapse ลงแล้ว
+}
+
+interface QuantumState {
+  marks: SpookyMark[]; // ตัวที่ i = ตาที่ i+1
+  collapse: number | null; // mark ที่ปิด cycle รออีกฝั่งเลือกช่อง
 }
 
 interface MoveData {
@@ -23,6 +44,7 @@ interface MoveData {
   player_id: number;
   x: number;
   y: number;
+  z: number;
   created_at: string;
 }
 
@@ -36,6 +58,7 @@ export default function GameBoardPage() {
   const [error, setError] = useState("");
   const [copied, setCopied] = useState(false);
   const [hasPromptedSpectator, setHasPromptedSpectator] = useState(false);  
+  const [spookyFirst, setSpookyFirst] = useState<number | null>(null); // quantum: ช่องแรกที่เลือกไว้
 
   //replay
   const [isReplaying, setIsReplaying] = useState(false);
@@ -165,12 +188,17 @@ export default function GameBoardPage() {
   //เดืนหมาก
   const handleMove = async (index: number) => {
     if (!game || game.status !== "IN_PROGRESS" || game.current_turn_id !== myUserId) return;
-    if (game.board[index] !== "-") return;
-
-    // แปลง index (0-8) เป็น x, y สำหรับส่งให้ Backend
-    // y แถว x คอลลัมน์
-    const y = Math.floor(index / 3);
-    const x = index % 3;
+    if (game.quantum) return handleQuantumMove(index);
+    const isGravity = game.variant === "gravity";
+    // gravity กดช่องไหนในคอลัมน์ก็ได้ ขอแค่คอลัมน์ยังไม่เต็ม (ช่องบนสุดว่าง)
+    if (game.board[isGravity ? index % game.width : index] !== "-") return;
+
+    // แปลง index เป็น x, y (และ z ของ qubic) สำหรับส่งให้ Backend
+    // y แถว x คอลลัมน์ z ชั้น (gravity ส่งแค่คอลัมน์ server หาแถวที่หมากตกลงไปให้)
+    const layerSize = game.width * game.height;
+    const z = Math.floor(index / layerSize);
+    const y = Math.floor((index % layerSize) / game.width);
+    const x = index % game.width;
 
     const token = localStorage.getItem("token");
     try {
@@ -179,12 +207,26 @@ export default function GameBoardPage() {
         headers: {
           "Content-Type": "application/json",
           Authorization: `Bearer ${token}`,
+          // ถ้า request นี้ถูกส่งซ้ำ (เช่น browser retry) server จะตอบผลเดิมแทนการเดินซ้ำ
+          "Idempotency-Key": crypto.randomUUID(),
         },
-        body: JSON.stringify({ room_code: roomCode, x, y }),
+        // ส่ง version ที่เห็นอยู่ไปด้วย ถ้ากระดานเปลี่ยนไปแล้ว server จะไม่ยอมให้เดิน
+        body: JSON.stringify(
+          isGravity
+            ? { room_code: roomCode, x, expected_version: game.version }
+            : game.depth > 1
+              ? { room_code: roomCode, x, y, z, expected_version: game.version }
+              : { room_code: roomCode, x, y, expected_version: game.version }
+        ),
       });
 
       if (!res.ok) {
         const data = await res.json();
+        if (res.status === 409 && data.game) {
+          // กระดานที่เห็นเก่าไปแล้ว อัปเดตให้ล่าสุดแทนการแจ้ง error
+          setGame(data.game);
+          return;
+        }
         alert(data.error);
         return;
       }
@@ -196,6 +238,53 @@ export default function GameBoardPage() {
     }
   };
 
+  // quantum: รอ collapse = กดเลือกช่องที่ mark ยุบลง ไม่งั้นกดสองช่องเพื่อลง spooky mark
+  const handleQuantumMove = async (index: number) => {
+    if (!game?.quantum || game.board[index] !== "-") return;
+    const pending = game.quantum.collapse;
+
+    let path = "move";
+    let body: Record<string, number | string> = { room_code: roomCode, expected_version: game.version };
+    if (pending !== null) {
+      if (!game.quantum.marks[pending].cells.includes(index)) return;
+      path = "collapse";
+      body = { ...body, x: index % 3, y: Math.floor(index / 3) };
+    } else if (spookyFirst === null || spookyFirst === index) {
+      // กดช่องเดิมซ้ำ = ยกเลิกช่องแรก
+      setSpookyFirst(spookyFirst === null ? index : null);
+      return;
+    } else {
+      body = { ...body, x: spookyFirst % 3, y: Math.floor(spookyFirst / 3), x2: index % 3, y2: Math.floor(index / 3) };
+    }
+    setSpookyFirst(null);
+
+    const token = localStorage.getItem("token");
+    try {
+      const res = await fetch(`${API_URL}/api/games/${path}`, {
+        method: "POST",
+        headers: {
+          "Content-Type": "application/json",
+          Authorization: `Bearer ${token}`,
+          "Idempotency-Key": crypto.randomUUID(),
+        },
+        body: JSON.stringify(body),
+      });
+
+      if (!res.ok) {
+        const data = await res.json();
+        if (res.status === 409 && data.game) {
+          setGame(data.game);
+          return;
+        }
+        alert(data.error);
+        return;
+      }
+      fetchGameState();
+    } catch (err: any) {
+      console.error(err)
+    }
+  };
+
   //func copy link
   const copyInviteLink = () => {
     const link = `${window.location.origin}/game/${roomCode}`;
@@ -340,16 +429,33 @@ export default function GameBoardPage() {
     if(!isReplaying) return game.board
 
     //โหมด replay
-    let boardArr = "---------".split("");
+    let boardArr = "-".repeat(game.width * game.height * (game.depth || 1)).split("");
     for (let i = 0; i < replayStep; i++) {
       const move = movesLog[i];
-      const char = move.player_id === game.player1_id ? "X" : "O";
-      boardArr[move.y * 3 + move.x] = char;
+      // notakto ทั้งสองฝั่งลง X
+      const char = game.variant === "notakto" || move.player_id === game.player1_id ? "X" : "O";
+      boardArr[((move.z || 0) * game.height + move.y) * game.width + move.x] = char;
     }
     return boardArr.join("");
   }
 
   const displayBoard = getDisplayBoard();
+  const isUltimate = game.variant === "ultimate";
+  const isGravity = game.variant === "gravity";
+  const isQubic = game.variant === "qubic";
+  const layerSize = game.width * game.height;
+
+  const isNotakto = game.variant === "notakto";
+  const quantum = game.quantum;
+  const pendingCollapse = quantum && quantum.collapse !== null ? quantum.marks[quantum.collapse] : null;
+
+  // ช่องนี้อยู่ในกระดานย่อยไหน (ultimate 0-8 / notakto นับจากซ้าย)
+  const subBoardOf = (index: number) => {
+    const x = index % game.width;
+    const y = Math.floor(index / game.width);
+    if (isNotakto) return Math.floor(x / 3);
+    return Math.floor(y / 3) * 3 + Math.floor(x / 3);
+  };
    
   return (
     <div className="min-h-screen flex flex-col items-center py-12 bg-white text-black font-sans selection:bg-red-500 selection:text-white">
@@ -431,6 +537,12 @@ export default function GameBoardPage() {
           {!isReplaying && (game.status === "DRAW" || (game.status === "ABANDONED" && game.winner_id === null)) && (
             <div className="w-full bg-yellow-400 text-black text-center p-4 font-black uppercase tracking-widest text-2xl border-4 border-black mb-8">
               🤝 IT'S A DRAW
+              {game.termination_reason === "dead_position" && (
+                <div className="text-xs mt-1">No line can be completed</div>
+              )}
+              {game.termination_reason === "forced_draw" && (
+                <div className="text-xs mt-1">Perfect play can only draw</div>
+              )}
             </div>
           )}
 
@@ -462,32 +574,75 @@ export default function GameBoardPage() {
             );
           })()}
 
-          {/* กระดาน Tic-Tac-Toe */}
-          <div className="grid grid-cols-3 gap-2 bg-black p-2 border-4 border-black shadow-[8px_8px_0px_0px_rgba(220,38,38,1)]">
-            {displayBoard.split("").map((cell, index) => {
-              const isX = cell === "X";
-              const isO = cell === "O";
-              const isEmpty = cell === "-";
-
-              const isInteractable = isEmpty && isMyTurn && game.status === "IN_PROGRESS" && !isReplaying;
+          {/* quantum: คนที่ไม่ได้ปิด cycle เลือกว่า mark ยุบลงช่องไหน */}
+          {game.status === "IN_PROGRESS" && pendingCollapse && (
+            <div className="w-full bg-purple-600 text-white text-center p-3 font-black uppercase tracking-widest text-sm border-4 border-black mb-8">
+              {isMyTurn
+                ? `🌀 Choose where ${pendingCollapse.mark}${quantum!.collapse! + 1} collapses`
+                : `🌀 Waiting for the collapse of ${pendingCollapse.mark}${quantum!.collapse! + 1}`}
+            </div>
+          )}
 
-              return (
-                <button
-                  key={index}
-                  onClick={() => handleMove(index)}
-                  disabled={!isInteractable}
-                  className={`w-24 h-24 flex items-center justify-center text-5xl font-black transition-all ${
-                    isInteractable 
-                      ? "bg-white hover:bg-gray-200 cursor-pointer active:scale-95" // ถ้ากดได้ ให้มี Hover และคลิกยุบตัวได้
-                      : isEmpty 
-                        ? "bg-white cursor-default" // ถ้าเป็นช่องว่างแต่ไม่ใช่ตาเรา/เป็นผู้ชม ให้ใช้ cursor-default ปกติ
-                        : "bg-gray-100 cursor-default" // ช่องที่ถูกกา X/O ไปแล้ว
-                  } ${isX ? "text-red-600" : isO ? "text-blue-600" : ""}`}
+          {/* กระดาน Tic-Tac-Toe */}
+          {/* qubic มีหลายชั้น วาดทีละชั้น (กระดาน 2 มิติมีชั้นเดียว) */}
+          <div className="flex flex-col items-center gap-4">
+            {Array.from({ length: game.depth || 1 }, (_, z) => (
+              <div key={z} className="flex flex-col items-center">
+                {isQubic && <div className="font-black uppercase tracking-widest text-xs mb-1">Layer {z + 1}</div>}
+                <div
+                  className={`grid ${isUltimate ? "gap-1" : "gap-2"} bg-black p-2 border-4 border-black shadow-[8px_8px_0px_0px_rgba(220,38,38,1)]`}
+                  style={{ gridTemplateColumns: `repeat(${game.width}, minmax(0, 1fr))` }}
                 >
-                  {isEmpty ? "" : cell}
-                </button>
-              );
-            })}
+                  {displayBoard.slice(z * layerSize, (z + 1) * layerSize).split("").map((cell, offset) => {
+                    const index = z * layerSize + offset; // ตำแหน่งใน board ทั้งก้อน
+                    const isX = cell === "X";
+                    const isO = cell === "O";
+                    const isEmpty = cell === "-";
+
+                    // ultimate / notakto ลงได้เฉพาะกระดานย่อยใน legal_boards, winning_line ของ ultimate เป็นเลขกระดานย่อย
+                    const isLegalBoard = !(isUltimate || isNotakto) || (game.legal_boards?.includes(subBoardOf(index)) ?? false);
+                    // gravity กดได้ทั้งคอลัมน์ที่ยังไม่เต็ม
+                    const isOpen = isGravity ? displayBoard[index % game.width] === "-" : isEmpty;
+                    // quantum รอ collapse กดได้แค่สองช่องของ mark ที่ปิด cycle
+                    const isCollapseChoice = pendingCollapse?.cells.includes(index) ?? false;
+                    const isQuantumOpen = !pendingCollapse || isCollapseChoice;
+                    const isInteractable = isOpen && isLegalBoard && isQuantumOpen && isMyTurn && game.status === "IN_PROGRESS" && !isReplaying;
+                    // spooky mark ที่ยังไม่ collapse ในช่องนี้ เช่น X1 O4
+                    const spooky = quantum && isEmpty
+                      ? quantum.marks.flatMap((m, i) => (m.cell === null && m.cells.includes(index) ? [`${m.mark}${i + 1}`] : []))
+                      : [];
+                    const isWinningCell = !isReplaying && (game.winning_line?.includes(isUltimate ? subBoardOf(index) : index) ?? false);
+
+                    return (
+                      <button
+                        key={index}
+                        onClick={() => handleMove(index)}
+                        disabled={!isInteractable}
+                        className={`${isUltimate ? "w-10 h-10 text-xl" : isGravity || isQubic ? "w-14 h-14 text-3xl" : "w-24 h-24 text-5xl"} flex items-center justify-center font-black transition-all ${
+                          spookyFirst === index || (isCollapseChoice && !isReplaying)
+                            ? `bg-purple-200 ${isInteractable ? "cursor-pointer" : "cursor-default"}` // quantum: ช่องแรกที่เลือกไว้ / ช่องที่ให้เลือก collapse
+                            : isInteractable 
+                            ? "bg-white hover:bg-gray-200 cursor-pointer active:scale-95" // ถ้ากดได้ ให้มี Hover และคลิกยุบตัวได้
+                            : isWinningCell
+                              ? "bg-yellow-200 cursor-default" // แถวที่ชนะ
+                            : isEmpty 
+                              ? "bg-white cursor-default" // ถ้าเป็นช่องว่างแต่ไม่ใช่ตาเรา/เป็นผู้ชม ให้ใช้ cursor-default ปกติ
+                              : "bg-gray-100 cursor-default" // ช่องที่ถูกกา X/O ไปแล้ว
+                        } ${isX ? "text-red-600" : isO ? "text-blue-600" : ""}`}
+                      >
+                        {spooky.length > 0 ? (
+                          <span className="text-xs leading-tight flex flex-wrap justify-center gap-1 p-1">
+                            {spooky.map((s) => (
+                              <span key={s} className={s.startsWith("X") ? "text-red-400" : "text-blue-400"}>{s}</span>
+                            ))}
+                          </span>
+                        ) : isEmpty ? "" : cell}
+                      </button>
+                    );
+                  })}
+                </div>
+              </div>
+            ))}
           </div>
           
           {/* โซนปุ่มควบคุมหลังจากเกมจบ */}
@@ -521,12 +676,13 @@ export default function GameBoardPage() {
                     </button>
                   )}
 
-                  <button
+                  {/* quantum ไม่มีประวัติใน moves ให้ฉายซ้ำ */}
+                  {!quantum && <button
                     onClick={handleWatchReplay}
                     className="w-full bg-black text-white font-black uppercase tracking-widest py-3 border-4 border-black shadow-[4px_4px_0px_0px_rgba(220,38,38,1)] hover:translate-y-1 hover:shadow-none transition-all"
                   >
                     🎥 Watch Replay
-                  </button>
+                  </button>}
                 </>
               );
             })()}
@@ -563,7 +719,7 @@ export default function GameBoardPage() {
                 <h3 className="font-bold mb-2 border-b-2 border-black pb-1 uppercase">Move History Log</h3>
                 {movesLog.map((m, i) => (
                   <div key={m.id} className={i + 1 === replayStep ? "bg-yellow-200 font-bold" : "text-gray-600"}>
-                    Step {i + 1}: Player {m.player_id === game.player1_id ? "1 (X)" : "2 (O)"} placed at [Row {m.y}, Col {m.x}]
+                    Step {i + 1}: Player {m.player_id === game.player1_id ? "1 (X)" : "2 (O)"} placed at [Row {m.y}, Col {m.x}{isQubic ? `, Layer ${m.z + 1}` : ""}]
                   </div>
                 ))}
               </div>
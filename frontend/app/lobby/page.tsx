@@ -10,6 +10,8 @@ export default function LobbyPage() {
     const [joinRoomId, setJoinRoomId] = useState("");
     const [error, setError] = useState("");
     const [loading, setLoading] = useState(false);
+    const [variant, setVariant] = useState("standard"); // standard 3x3 / ultimate 9x9 / gravity 7x6 / qubic 4x4x4
+    const [drawRule, setDrawRule] = useState("full_board"); // กติกาเสมอของห้องที่จะสร้าง (standard เท่านั้น)
 
     const API_URL = process.env.NEXT_PUBLIC_API_URL || "http://localhost:8080";
 
@@ -69,6 +71,11 @@ export default function LobbyPage() {
                 "Content-Type": "application/json",
                 "Authorization": `Bearer ${token}`,
                 },
+                body: JSON.stringify(
+                  variant === "standard" ? { variant, draw_rule: drawRule }
+                  : variant === "notakto" ? { variant, boards: 3 }
+                  : { variant }
+                ),
             })
 
             const data = await res.json()
@@ -179,6 +186,29 @@ export default function LobbyPage() {
             >
               {loading ? "Initializing..." : "Create New Room"}
             </button>
+            <select
+              value={variant}
+              onChange={(e) => setVariant(e.target.value)}
+              className="w-full mt-3 bg-black text-white border-2 border-white p-2 text-xs font-bold uppercase tracking-widest"
+            >
+              <option value="standard">Standard 3x3</option>
+              <option value="ultimate">Ultimate 9x9</option>
+              <option value="gravity">Gravity 7x6 (Connect Four)</option>
+              <option value="misere">Misère 3x3 (line loses)</option>
+              <option value="notakto">Notakto 3 boards (X only)</option>
+              <option value="quantum">Quantum (spooky marks)</option>
+              <option value="qubic">Qubic 4x4x4 (3D)</option>
+            </select>
+            <select
+              value={drawRule}
+              disabled={variant !== "standard"}
+              onChange={(e) => setDrawRule(e.target.value)}
+              className="w-full mt-3 bg-black text-white border-2 border-white p-2 text-xs font-bold uppercase tracking-widest"
+            >
+              <option value="full_board">Draw when the board is full</option>
+              <option value="dead_position">Draw when no line can be completed</option>
+              <option value="perfect_play">Draw when perfect play can only draw</option>
+            </select>
             <p className="text-center text-xs text-gray-400 mt-3 uppercase tracking-wide">
               Host a game and wait for an opponent
             </p>